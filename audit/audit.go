@@ -0,0 +1,166 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/datastax/cassandra-data-apis/auth"
+	"github.com/datastax/cassandra-data-apis/log"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	SourceGraphQL = "graphql"
+	SourceRest    = "rest"
+)
+
+const (
+	OperationInsert         = "insert"
+	OperationUpdate         = "update"
+	OperationDelete         = "delete"
+	OperationCreateKeyspace = "createKeyspace"
+	OperationDropKeyspace   = "dropKeyspace"
+	OperationCreateTable    = "createTable"
+	OperationAlterTableAdd  = "alterTableAdd"
+	OperationAlterTableDrop = "alterTableDrop"
+	OperationDropTable      = "dropTable"
+)
+
+// Event describes a single mutation or schema change
+type Event struct {
+	Time       time.Time              `json:"time"`
+	UserOrRole string                 `json:"userOrRole,omitempty"`
+	ClientIp   string                 `json:"clientIp,omitempty"`
+	Source     string                 `json:"source"`
+	Operation  string                 `json:"operation"`
+	Keyspace   string                 `json:"keyspace,omitempty"`
+	Table      string                 `json:"table,omitempty"`
+	PrimaryKey map[string]interface{} `json:"primaryKey,omitempty"`
+	Applied    bool                   `json:"applied"`
+	Error      string                 `json:"error,omitempty"`
+}
+
+// Sink is the destination of the audit events
+type Sink interface {
+	Write(event Event) error
+}
+
+// SinkFunc adapts a function to be used as a Sink
+type SinkFunc func(event Event) error
+
+func (f SinkFunc) Write(event Event) error {
+	return f(event)
+}
+
+type writerSink struct {
+	mutex   sync.Mutex
+	encoder *json.Encoder
+}
+
+// NewWriterSink creates a sink that writes each event as a JSON line, for example to stdout
+func NewWriterSink(w io.Writer) Sink {
+	return &writerSink{encoder: json.NewEncoder(w)}
+}
+
+func (s *writerSink) Write(event Event) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.encoder.Encode(event)
+}
+
+// FileSink appends the events to a JSONL file
+type FileSink struct {
+	Sink
+	file *os.File
+}
+
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &FileSink{Sink: NewWriterSink(file), file: file}, nil
+}
+
+func (s *FileSink) Close() error {
+	return s.file.Close()
+}
+
+// Auditor sends the audit events to all the sinks. A nil auditor discards the events.
+type Auditor struct {
+	sinks  []Sink
+	logger log.Logger
+	now    func() time.Time
+}
+
+func NewAuditor(logger log.Logger, sinks ...Sink) *Auditor {
+	return &Auditor{
+		sinks:  sinks,
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// AddSink adds a sink to the auditor, it must be called before the auditor is used
+func (a *Auditor) AddSink(sink Sink) {
+	a.sinks = append(a.sinks, sink)
+}
+
+// Log fills the time, the user or role and the client ip of the event using the context and writes it to the sinks
+func (a *Auditor) Log(ctx context.Context, event Event) {
+	if a == nil {
+		return
+	}
+
+	if event.Time.IsZero() {
+		event.Time = a.now().UTC()
+	}
+	if event.UserOrRole == "" {
+		event.UserOrRole = auth.ContextUserOrRole(ctx)
+	}
+	if event.ClientIp == "" {
+		event.ClientIp = ContextClientIp(ctx)
+	}
+
+	for _, sink := range a.sinks {
+		if err := sink.Write(event); err != nil {
+			a.logger.Error("unable to write audit event",
+				"operation", event.Operation,
+				"keyspace", event.Keyspace,
+				"table", event.Table,
+				"error", err)
+		}
+	}
+}
+
+type contextKey struct {
+	name string
+}
+
+var clientIpKey = &contextKey{"clientIp"}
+
+func WithContextClientIp(ctx context.Context, clientIp string) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, clientIpKey, clientIp)
+}
+
+func ContextClientIp(ctx context.Context) string {
+	if ctx != nil {
+		if val, ok := ctx.Value(clientIpKey).(string); ok {
+			return val
+		}
+	}
+	return ""
+}
+
+// NewHandler creates a handler that adds the client ip to the request context, to be included in the audit events
+func NewHandler(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(w, r.WithContext(WithContextClientIp(r.Context(), auth.ClientIp(r))))
+	})
+}
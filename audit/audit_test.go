@@ -0,0 +1,94 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/datastax/cassandra-data-apis/auth"
+	"github.com/datastax/cassandra-data-apis/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestAuditor_Log(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	failed := 0
+	auditor := NewAuditor(log.NewZapLogger(zap.NewNop()), NewWriterSink(buffer), SinkFunc(func(event Event) error {
+		failed++
+		return errors.New("test error")
+	}))
+	now := time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC)
+	auditor.now = func() time.Time { return now }
+
+	ctx := WithContextClientIp(auth.WithContextUserOrRole(context.Background(), "role1"), "10.0.0.1")
+	auditor.Log(ctx, Event{
+		Source:     SourceRest,
+		Operation:  OperationDelete,
+		Keyspace:   "ks1",
+		Table:      "tbl1",
+		PrimaryKey: map[string]interface{}{"id": "a"},
+		Applied:    true,
+	})
+
+	// Errors of a sink don't affect the other sinks
+	assert.Equal(t, 1, failed)
+
+	var event Event
+	require.NoError(t, json.Unmarshal(buffer.Bytes(), &event))
+	assert.Equal(t, Event{
+		Time:       now,
+		UserOrRole: "role1",
+		ClientIp:   "10.0.0.1",
+		Source:     SourceRest,
+		Operation:  OperationDelete,
+		Keyspace:   "ks1",
+		Table:      "tbl1",
+		PrimaryKey: map[string]interface{}{"id": "a"},
+		Applied:    true,
+	}, event)
+
+	// A nil auditor discards the events
+	var nilAuditor *Auditor
+	nilAuditor.Log(ctx, Event{Operation: OperationInsert})
+}
+
+func TestFileSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	filePath := path.Join(dir, "audit.jsonl")
+	for i := 0; i < 2; i++ {
+		sink, err := NewFileSink(filePath)
+		require.NoError(t, err)
+		assert.NoError(t, sink.Write(Event{Operation: OperationCreateTable, Keyspace: "ks1", Table: "tbl1"}))
+		assert.NoError(t, sink.Close())
+	}
+
+	data, err := ioutil.ReadFile(filePath)
+	require.NoError(t, err)
+	line := `{"time":"0001-01-01T00:00:00Z","source":"","operation":"createTable","keyspace":"ks1","table":"tbl1",` +
+		`"applied":false}` + "\n"
+	assert.Equal(t, line+line, string(data))
+}
+
+func TestNewHandler(t *testing.T) {
+	var clientIp string
+	handler := NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientIp = ContextClientIp(r.Context())
+	}))
+
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.RemoteAddr = "10.0.0.2:51234"
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	assert.Equal(t, "10.0.0.2", clientIp)
+}
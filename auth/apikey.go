@@ -0,0 +1,180 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"github.com/datastax/cassandra-data-apis/log"
+	"github.com/spf13/viper"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ApiKeyHeader is the http header used to provide API keys
+const ApiKeyHeader = "X-Cassandra-Token"
+
+const DefaultApiKeysReloadInterval = 30 * time.Second
+
+// ApiKeyStore provides the API keys and the user or role each key maps to
+type ApiKeyStore interface {
+	Keys() (map[string]string, error)
+}
+
+// ApiKeyStoreFunc adapts a function to the ApiKeyStore interface
+type ApiKeyStoreFunc func() (map[string]string, error)
+
+func (f ApiKeyStoreFunc) Keys() (map[string]string, error) {
+	return f()
+}
+
+// NewFileApiKeyStore creates an ApiKeyStore that reads the keys from a file in any of the formats supported by viper
+// (YAML, JSON, ...) containing a "keys" list with "key" and "role" entries
+func NewFileApiKeyStore(filePath string) ApiKeyStore {
+	return ApiKeyStoreFunc(func() (map[string]string, error) {
+		v := viper.New()
+		v.SetConfigFile(filePath)
+		if err := v.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("unable to read api keys file: %s", err)
+		}
+
+		var file struct {
+			Keys []struct {
+				Key  string `mapstructure:"key"`
+				Role string `mapstructure:"role"`
+			} `mapstructure:"keys"`
+		}
+		if err := v.Unmarshal(&file); err != nil {
+			return nil, fmt.Errorf("unable to parse api keys file: %s", err)
+		}
+
+		keys := make(map[string]string, len(file.Keys))
+		for _, item := range file.Keys {
+			if item.Key == "" || item.Role == "" {
+				return nil, errors.New("api keys file entries should contain a key and a role")
+			}
+			keys[item.Key] = item.Role
+		}
+		return keys, nil
+	})
+}
+
+// NewEnvApiKeyStore creates an ApiKeyStore that reads the keys from an environment variable containing a comma
+// separated list of "key:role" pairs
+func NewEnvApiKeyStore(variable string) ApiKeyStore {
+	return ApiKeyStoreFunc(func() (map[string]string, error) {
+		value := os.Getenv(variable)
+		keys := make(map[string]string)
+		for _, pair := range strings.Split(value, ",") {
+			pair = strings.TrimSpace(pair)
+			if pair == "" {
+				continue
+			}
+			index := strings.LastIndex(pair, ":")
+			if index <= 0 || index == len(pair)-1 {
+				return nil, fmt.Errorf("invalid api key entry in environment variable %s", variable)
+			}
+			keys[pair[:index]] = pair[index+1:]
+		}
+		return keys, nil
+	})
+}
+
+// ApiKeyAuthenticator is an Authenticator that validates the API key provided in the "X-Cassandra-Token" header
+// against the keys in the stores. The keys are reloaded periodically once started.
+type ApiKeyAuthenticator struct {
+	ctx            context.Context
+	cancel         context.CancelFunc
+	mutex          sync.RWMutex
+	stores         []ApiKeyStore
+	keys           map[[sha256.Size]byte]string
+	reloadInterval time.Duration
+	logger         log.Logger
+}
+
+// NewApiKeyAuthenticator creates a new ApiKeyAuthenticator, loading the keys from the stores
+func NewApiKeyAuthenticator(
+	reloadInterval time.Duration,
+	logger log.Logger,
+	stores ...ApiKeyStore,
+) (*ApiKeyAuthenticator, error) {
+	if len(stores) == 0 {
+		return nil, errors.New("at least one api key store should be provided")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a := &ApiKeyAuthenticator{
+		ctx:            ctx,
+		cancel:         cancel,
+		stores:         stores,
+		reloadInterval: reloadInterval,
+		logger:         logger,
+	}
+
+	if err := a.Reload(); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// Authenticate returns the user or role that the API key of the request maps to
+func (a *ApiKeyAuthenticator) Authenticate(r *http.Request) (string, error) {
+	key := r.Header.Get(ApiKeyHeader)
+	if key == "" {
+		return "", ErrNoCredentials
+	}
+
+	// Keys are stored hashed to avoid comparisons that depend on the key contents
+	hash := sha256.Sum256([]byte(key))
+	a.mutex.RLock()
+	userOrRole, ok := a.keys[hash]
+	a.mutex.RUnlock()
+
+	if !ok {
+		return "", errors.New("invalid api key")
+	}
+
+	return userOrRole, nil
+}
+
+// Reload reads the keys from all the stores, the previous keys are kept when any of the stores fails
+func (a *ApiKeyAuthenticator) Reload() error {
+	keys := make(map[[sha256.Size]byte]string)
+	for _, store := range a.stores {
+		storeKeys, err := store.Keys()
+		if err != nil {
+			return err
+		}
+		for key, userOrRole := range storeKeys {
+			keys[sha256.Sum256([]byte(key))] = userOrRole
+		}
+	}
+
+	a.mutex.Lock()
+	a.keys = keys
+	a.mutex.Unlock()
+	return nil
+}
+
+// Start reloads the keys periodically until stopped
+func (a *ApiKeyAuthenticator) Start() {
+	for {
+		select {
+		case <-time.After(a.reloadInterval):
+			if err := a.Reload(); err != nil {
+				a.logger.Error("unable to reload api keys", "error", err)
+			}
+		case <-a.ctx.Done():
+			return
+		}
+	}
+}
+
+// Stop stops reloading the keys, it can be called before Start or more than once
+func (a *ApiKeyAuthenticator) Stop() {
+	a.cancel()
+}
@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestApiKeyAuthenticator(t *testing.T) {
+	filePath := writeTempFile(t, "keys.yaml", []byte("keys:\n  - key: key1\n    role: role1\n"))
+	require.NoError(t, os.Setenv("TEST_API_KEYS", "key2:role2, key3:role3"))
+	defer os.Unsetenv("TEST_API_KEYS")
+
+	tableKeys := map[string]string{"key4": "role4"}
+	authenticator, err := NewApiKeyAuthenticator(time.Minute, nopLogger{},
+		NewFileApiKeyStore(filePath),
+		NewEnvApiKeyStore("TEST_API_KEYS"),
+		ApiKeyStoreFunc(func() (map[string]string, error) { return tableKeys, nil }))
+	require.NoError(t, err)
+
+	for key, expected := range map[string]string{"key1": "role1", "key2": "role2", "key3": "role3", "key4": "role4"} {
+		role, err := authenticator.Authenticate(requestWithApiKey(key))
+		assert.NoError(t, err)
+		assert.Equal(t, expected, role)
+	}
+
+	_, err = authenticator.Authenticate(requestWithApiKey("key5"))
+	assert.EqualError(t, err, "invalid api key")
+
+	_, err = authenticator.Authenticate(httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, ErrNoCredentials, err)
+
+	// Reload keys
+	tableKeys = map[string]string{"key5": "role5"}
+	require.NoError(t, authenticator.Reload())
+	role, err := authenticator.Authenticate(requestWithApiKey("key5"))
+	assert.NoError(t, err)
+	assert.Equal(t, "role5", role)
+	_, err = authenticator.Authenticate(requestWithApiKey("key4"))
+	assert.Error(t, err)
+}
+
+func TestApiKeyAuthenticator_ReloadError(t *testing.T) {
+	var storeErr error
+	authenticator, err := NewApiKeyAuthenticator(time.Minute, nopLogger{},
+		ApiKeyStoreFunc(func() (map[string]string, error) { return map[string]string{"key1": "role1"}, storeErr }))
+	require.NoError(t, err)
+
+	// Previous keys are kept
+	storeErr = errors.New("test error")
+	assert.EqualError(t, authenticator.Reload(), "test error")
+	role, err := authenticator.Authenticate(requestWithApiKey("key1"))
+	assert.NoError(t, err)
+	assert.Equal(t, "role1", role)
+}
+
+func TestApiKeyAuthenticator_Stop(t *testing.T) {
+	authenticator, err := NewApiKeyAuthenticator(time.Millisecond, nopLogger{},
+		ApiKeyStoreFunc(func() (map[string]string, error) { return map[string]string{"key1": "role1"}, nil }))
+	require.NoError(t, err)
+
+	// Stopping before starting doesn't panic and the reloading stops as soon as it starts
+	authenticator.Stop()
+	stopped := make(chan bool)
+	go func() {
+		authenticator.Start()
+		stopped <- true
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		assert.Fail(t, "expected the authenticator to be stopped")
+	}
+	authenticator.Stop()
+}
+
+func TestEnvApiKeyStore_Invalid(t *testing.T) {
+	require.NoError(t, os.Setenv("TEST_API_KEYS", "key1"))
+	defer os.Unsetenv("TEST_API_KEYS")
+
+	_, err := NewEnvApiKeyStore("TEST_API_KEYS").Keys()
+	assert.EqualError(t, err, "invalid api key entry in environment variable TEST_API_KEYS")
+}
+
+func TestChainAuthenticator(t *testing.T) {
+	jwtAuthenticator, err := NewJwtAuthenticator(JwtOptions{Secret: "secret1"})
+	require.NoError(t, err)
+	apiKeyAuthenticator, err := NewApiKeyAuthenticator(time.Minute, nopLogger{},
+		ApiKeyStoreFunc(func() (map[string]string, error) { return map[string]string{"key1": "role1"}, nil }))
+	require.NoError(t, err)
+
+	authenticator := NewChainAuthenticator(jwtAuthenticator, apiKeyAuthenticator)
+
+	role, err := authenticator.Authenticate(requestWithApiKey("key1"))
+	assert.NoError(t, err)
+	assert.Equal(t, "role1", role)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer "+signHS256(t, "secret1", map[string]interface{}{
+		"role": "user1", "exp": time.Now().Add(time.Hour).Unix(),
+	}))
+	role, err = authenticator.Authenticate(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "user1", role)
+
+	_, err = authenticator.Authenticate(httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, ErrNoCredentials, err)
+}
+
+func requestWithApiKey(key string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(ApiKeyHeader, key)
+	return r
+}
@@ -1,6 +1,10 @@
 package auth
 
-import "context"
+import (
+	"context"
+	"net"
+	"net/http"
+)
 
 type contextKey struct {
 	name string
@@ -24,3 +28,12 @@ func ContextUserOrRole(ctx context.Context) string {
 	}
 	return ""
 }
+
+// ClientIp gets the address of the client without the port
+func ClientIp(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
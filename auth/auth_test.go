@@ -3,6 +3,8 @@ package auth
 import (
 	"context"
 	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 )
 
@@ -11,3 +13,13 @@ func TestContextUserOrRole(t *testing.T) {
 	assert.Equal(t, "user1",
 		ContextUserOrRole(WithContextUserOrRole(context.Background(), "user1")))
 }
+
+func TestClientIp(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "10.0.0.1:52000"
+	assert.Equal(t, "10.0.0.1", ClientIp(r))
+	r.RemoteAddr = "[::1]:52000"
+	assert.Equal(t, "::1", ClientIp(r))
+	r.RemoteAddr = "10.0.0.1"
+	assert.Equal(t, "10.0.0.1", ClientIp(r))
+}
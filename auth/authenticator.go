@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"errors"
+	"github.com/datastax/cassandra-data-apis/log"
+	"net/http"
+)
+
+// ErrNoCredentials is returned by an Authenticator when the request doesn't contain the credentials it handles
+var ErrNoCredentials = errors.New("no credentials provided")
+
+// Authenticator validates the credentials contained in a http request and returns the user or role that should
+// be used to execute the request.
+type Authenticator interface {
+	Authenticate(r *http.Request) (string, error)
+}
+
+// AuthenticatorFunc adapts a function to the Authenticator interface
+type AuthenticatorFunc func(r *http.Request) (string, error)
+
+func (f AuthenticatorFunc) Authenticate(r *http.Request) (string, error) {
+	return f(r)
+}
+
+// NewChainAuthenticator creates an Authenticator that returns the user or role of the first authenticator that
+// accepts the request. Authenticators can share the same credentials, e.g. API keys and tokens, so when none of
+// them accepts the request, the error of the first one that found its credentials is returned.
+func NewChainAuthenticator(authenticators ...Authenticator) Authenticator {
+	return AuthenticatorFunc(func(r *http.Request) (string, error) {
+		var firstErr error
+		for _, authenticator := range authenticators {
+			userOrRole, err := authenticator.Authenticate(r)
+			if err == nil {
+				return userOrRole, nil
+			}
+			if err != ErrNoCredentials && firstErr == nil {
+				firstErr = err
+			}
+		}
+		if firstErr != nil {
+			return "", firstErr
+		}
+		return "", ErrNoCredentials
+	})
+}
+
+type authHandler struct {
+	handler       http.Handler
+	authenticator Authenticator
+	logger        log.Logger
+}
+
+// NewAuthHandler creates a http handler that rejects the requests that fail authentication with a 401 status code
+// and populates the request context with the user or role for the rest.
+func NewAuthHandler(handler http.Handler, authenticator Authenticator, logger log.Logger) http.Handler {
+	return &authHandler{
+		handler:       handler,
+		authenticator: authenticator,
+		logger:        logger,
+	}
+}
+
+func (h *authHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	userOrRole, err := h.authenticator.Authenticate(r)
+	if err == nil && userOrRole == "" {
+		err = errors.New("no user or role found for the credentials")
+	}
+
+	if err != nil {
+		h.logger.Debug("request authentication failed",
+			"requestURI", r.RequestURI,
+			"error", err)
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	h.handler.ServeHTTP(w, r.WithContext(WithContextUserOrRole(r.Context(), userOrRole)))
+}
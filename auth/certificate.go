@@ -0,0 +1,180 @@
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const (
+	// CertificateRoleCN uses the subject common name of the client certificate as user or role
+	CertificateRoleCN = "cn"
+	// CertificateRoleSAN uses the first subject alternative name (email, DNS name or URI) of the client certificate
+	// as user or role
+	CertificateRoleSAN = "san"
+)
+
+const (
+	ClientAuthNone     = "none"
+	ClientAuthOptional = "optional"
+	ClientAuthRequired = "required"
+)
+
+// TlsOptions contains the settings used to serve the endpoints over TLS
+type TlsOptions struct {
+	CertPath string
+	KeyPath  string
+	// ClientCaPath is the path to a PEM encoded CA bundle used to verify the client certificates
+	ClientCaPath string
+	// ClientAuth is the client certificate policy: "none", "optional" or "required"
+	ClientAuth string
+}
+
+// ServerTls provides the tls configuration for the http listeners, the certificates can be reloaded and are used for
+// the new connections
+type ServerTls struct {
+	mutex   sync.RWMutex
+	options TlsOptions
+	config  *tls.Config
+}
+
+// NewServerTls creates a new ServerTls, loading the certificates
+func NewServerTls(options TlsOptions) (*ServerTls, error) {
+	s := &ServerTls{options: options}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload reads the certificate, key and client CA files, the previous configuration is kept when they're not valid
+func (s *ServerTls) Reload() error {
+	config, err := NewServerTlsConfig(s.options)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	s.config = config
+	s.mutex.Unlock()
+	return nil
+}
+
+// Config returns a tls configuration that uses the latest loaded certificates for each connection
+func (s *ServerTls) Config() *tls.Config {
+	current := func() *tls.Config {
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+		return s.config
+	}
+
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return &current().Certificates[0], nil
+		},
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return current(), nil
+		},
+	}
+}
+
+// NewServerTlsConfig creates the tls configuration for the http listeners
+func NewServerTlsConfig(options TlsOptions) (*tls.Config, error) {
+	if options.CertPath == "" || options.KeyPath == "" {
+		return nil, errors.New("both the server certificate and private key must be set")
+	}
+
+	cert, err := tls.LoadX509KeyPair(options.CertPath, options.KeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load server certificate: %s", err)
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+		ClientAuth:   tls.NoClientCert,
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+
+	clientAuth := strings.ToLower(options.ClientAuth)
+	if clientAuth == "" {
+		clientAuth = ClientAuthNone
+		if options.ClientCaPath != "" {
+			clientAuth = ClientAuthRequired
+		}
+	}
+
+	switch clientAuth {
+	case ClientAuthNone:
+		return config, nil
+	case ClientAuthOptional:
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+	case ClientAuthRequired:
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return nil, fmt.Errorf("invalid client auth: %s", options.ClientAuth)
+	}
+
+	if options.ClientCaPath == "" {
+		return nil, errors.New("client ca is required to verify client certificates")
+	}
+
+	data, err := ioutil.ReadFile(options.ClientCaPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read client ca file: %s", err)
+	}
+
+	config.ClientCAs = x509.NewCertPool()
+	if !config.ClientCAs.AppendCertsFromPEM(data) {
+		return nil, errors.New("no certificates found in client ca file")
+	}
+
+	return config, nil
+}
+
+// NewCertificateAuthenticator creates an Authenticator that uses the verified client certificate of the request to
+// obtain the user or role, from the subject common name ("cn") or subject alternative name ("san")
+func NewCertificateAuthenticator(roleSource string) (Authenticator, error) {
+	var getRole func(cert *x509.Certificate) string
+	switch strings.ToLower(roleSource) {
+	case CertificateRoleCN:
+		getRole = func(cert *x509.Certificate) string {
+			return cert.Subject.CommonName
+		}
+	case CertificateRoleSAN:
+		getRole = func(cert *x509.Certificate) string {
+			if len(cert.EmailAddresses) > 0 {
+				return cert.EmailAddresses[0]
+			}
+			if len(cert.DNSNames) > 0 {
+				return cert.DNSNames[0]
+			}
+			if len(cert.URIs) > 0 {
+				return cert.URIs[0].String()
+			}
+			return ""
+		}
+	default:
+		return nil, fmt.Errorf("invalid certificate role source: %s", roleSource)
+	}
+
+	return AuthenticatorFunc(func(r *http.Request) (string, error) {
+		// Only verified chains are considered, the certificates are verified by the tls listener
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+			return "", ErrNoCredentials
+		}
+
+		userOrRole := getRole(r.TLS.VerifiedChains[0][0])
+		if userOrRole == "" {
+			return "", fmt.Errorf("no %s found in client certificate", roleSource)
+		}
+
+		return userOrRole, nil
+	}), nil
+}
@@ -0,0 +1,189 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCertificateAuthenticator(t *testing.T) {
+	ca := newTestCertificate(t, nil, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "ca"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	})
+	server := newTestCertificate(t, ca, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "server"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	client := newTestCertificate(t, ca, &x509.Certificate{
+		Subject:        pkix.Name{CommonName: "role1"},
+		EmailAddresses: []string{"role2@example.com"},
+		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+
+	serverTls, err := NewServerTls(TlsOptions{
+		CertPath:     writeTempFile(t, "server.pem", server.certPem),
+		KeyPath:      writeTempFile(t, "server.key", server.keyPem),
+		ClientCaPath: writeTempFile(t, "ca.pem", ca.certPem),
+		ClientAuth:   ClientAuthOptional,
+	})
+	require.NoError(t, err)
+
+	for _, source := range []string{CertificateRoleCN, CertificateRoleSAN} {
+		authenticator, err := NewCertificateAuthenticator(source)
+		require.NoError(t, err)
+
+		var userOrRole string
+		ts := httptest.NewUnstartedServer(NewAuthHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userOrRole = ContextUserOrRole(r.Context())
+		}), authenticator, nopLogger{}))
+		ts.TLS = serverTls.Config()
+		ts.StartTLS()
+
+		roots := x509.NewCertPool()
+		roots.AddCert(ca.cert)
+		clientCert, err := tls.X509KeyPair(client.certPem, client.keyPem)
+		require.NoError(t, err)
+
+		httpClient := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			RootCAs:      roots,
+			Certificates: []tls.Certificate{clientCert},
+		}}}
+		resp, err := httpClient.Get(ts.URL)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		// Without client certificate
+		httpClient = &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}}
+		resp, err = httpClient.Get(ts.URL)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		ts.Close()
+
+		if source == CertificateRoleCN {
+			assert.Equal(t, "role1", userOrRole)
+		} else {
+			assert.Equal(t, "role2@example.com", userOrRole)
+		}
+	}
+}
+
+func TestServerTls_Reload(t *testing.T) {
+	server1 := newTestCertificate(t, nil, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "server1"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+	})
+	server2 := newTestCertificate(t, nil, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "server2"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+	})
+
+	certPath := writeTempFile(t, "server.pem", server1.certPem)
+	keyPath := writeTempFile(t, "server.key", server1.keyPem)
+	serverTls, err := NewServerTls(TlsOptions{CertPath: certPath, KeyPath: keyPath})
+	require.NoError(t, err)
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	ts.TLS = serverTls.Config()
+	ts.StartTLS()
+	defer ts.Close()
+
+	peerName := func() string {
+		httpClient := &http.Client{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		}}
+		resp, err := httpClient.Get(ts.URL)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		return resp.TLS.PeerCertificates[0].Subject.CommonName
+	}
+
+	assert.Equal(t, "server1", peerName())
+
+	// The previous certificate is kept when the files are not valid
+	require.NoError(t, ioutil.WriteFile(certPath, server2.certPem, 0600))
+	assert.Error(t, serverTls.Reload())
+	assert.Equal(t, "server1", peerName())
+
+	require.NoError(t, ioutil.WriteFile(keyPath, server2.keyPem, 0600))
+	assert.NoError(t, serverTls.Reload())
+	assert.Equal(t, "server2", peerName())
+}
+
+func TestNewServerTlsConfig_Invalid(t *testing.T) {
+	_, err := NewServerTlsConfig(TlsOptions{CertPath: "cert.pem"})
+	assert.EqualError(t, err, "both the server certificate and private key must be set")
+
+	server := newTestCertificate(t, nil, &x509.Certificate{Subject: pkix.Name{CommonName: "server"}})
+	options := TlsOptions{
+		CertPath:   writeTempFile(t, "server.pem", server.certPem),
+		KeyPath:    writeTempFile(t, "server.key", server.keyPem),
+		ClientAuth: ClientAuthRequired,
+	}
+	_, err = NewServerTlsConfig(options)
+	assert.EqualError(t, err, "client ca is required to verify client certificates")
+
+	options.ClientAuth = "other"
+	_, err = NewServerTlsConfig(options)
+	assert.EqualError(t, err, "invalid client auth: other")
+
+	_, err = NewCertificateAuthenticator("other")
+	assert.EqualError(t, err, "invalid certificate role source: other")
+}
+
+type testCertificate struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPem []byte
+	keyPem  []byte
+}
+
+// newTestCertificate creates a certificate signed by the parent or a self-signed one when parent is nil
+func newTestCertificate(t *testing.T, parent *testCertificate, template *x509.Certificate) *testCertificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+	template.SerialNumber = serial
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+
+	parentCert, parentKey := template, key
+	if parent != nil {
+		parentCert, parentKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parentCert, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return &testCertificate{
+		cert:    cert,
+		key:     key,
+		certPem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPem:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}),
+	}
+}
@@ -0,0 +1,429 @@
+package auth
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	algHS256 = "HS256"
+	algRS256 = "RS256"
+	algES256 = "ES256"
+)
+
+const DefaultJwtRoleClaim = "role"
+
+// JwtOptions contains the settings used to validate JSON Web Tokens
+type JwtOptions struct {
+	// JwksPath is the path of a JSON Web Key Set file containing the keys used to validate the tokens
+	JwksPath string
+	// Secret is the shared secret used to validate HS256 tokens
+	Secret string
+	// PublicKeyPaths are paths of PEM encoded RSA or ECDSA public keys (or certificates) used to validate RS256 and
+	// ES256 tokens
+	PublicKeyPaths []string
+	// Issuer is the expected "iss" claim, when empty the issuer is not validated
+	Issuer string
+	// Audience is the expected "aud" claim, when empty the audience is not validated
+	Audience string
+	// RoleClaim is the name of the claim that contains the user or role, nested claims can be provided
+	// using dots, e.g. "app_metadata.role"
+	RoleClaim string
+	// Leeway is the allowed clock skew when validating the time based claims
+	Leeway time.Duration
+}
+
+type jwtKey struct {
+	id        string
+	algorithm string
+	key       interface{}
+}
+
+type jwtHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+// JwtAuthenticator is an Authenticator that validates "Authorization: Bearer" JSON Web Tokens
+type JwtAuthenticator struct {
+	keys      []jwtKey
+	issuer    string
+	audience  string
+	roleClaim []string
+	leeway    time.Duration
+	now       func() time.Time
+}
+
+// NewJwtAuthenticator creates a new JwtAuthenticator loading the keys from the provided options
+func NewJwtAuthenticator(options JwtOptions) (*JwtAuthenticator, error) {
+	keys := make([]jwtKey, 0)
+
+	if options.Secret != "" {
+		keys = append(keys, jwtKey{algorithm: algHS256, key: []byte(options.Secret)})
+	}
+
+	for _, keyPath := range options.PublicKeyPaths {
+		key, err := loadPemPublicKey(keyPath)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+
+	if options.JwksPath != "" {
+		jwksKeys, err := loadJwks(options.JwksPath)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, jwksKeys...)
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("at least one key should be provided to validate tokens")
+	}
+
+	roleClaim := options.RoleClaim
+	if roleClaim == "" {
+		roleClaim = DefaultJwtRoleClaim
+	}
+
+	return &JwtAuthenticator{
+		keys:      keys,
+		issuer:    options.Issuer,
+		audience:  options.Audience,
+		roleClaim: strings.Split(roleClaim, "."),
+		leeway:    options.Leeway,
+		now:       time.Now,
+	}, nil
+}
+
+// Authenticate validates the bearer token of the request and returns the value of the role claim
+func (a *JwtAuthenticator) Authenticate(r *http.Request) (string, error) {
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return "", ErrNoCredentials
+	}
+
+	if len(header) < 7 || !strings.EqualFold(header[:7], "bearer ") {
+		return "", ErrNoCredentials
+	}
+
+	return a.Validate(strings.TrimSpace(header[7:]))
+}
+
+// Validate validates the token signature and claims and returns the value of the role claim
+func (a *JwtAuthenticator) Validate(token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", errors.New("token is malformed")
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return "", fmt.Errorf("token header is invalid: %s", err)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", fmt.Errorf("token signature is invalid: %s", err)
+	}
+
+	if err := a.verifySignature(header, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return "", err
+	}
+
+	claims := make(map[string]interface{})
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return "", fmt.Errorf("token claims are invalid: %s", err)
+	}
+
+	if err := a.validateClaims(claims); err != nil {
+		return "", err
+	}
+
+	return a.role(claims)
+}
+
+func (a *JwtAuthenticator) verifySignature(header jwtHeader, signed []byte, signature []byte) error {
+	switch header.Algorithm {
+	case algHS256, algRS256, algES256:
+	default:
+		return fmt.Errorf("unsupported token algorithm '%s'", header.Algorithm)
+	}
+
+	digest := sha256.Sum256(signed)
+	found := false
+	for _, key := range a.keys {
+		if key.algorithm != header.Algorithm || (header.KeyID != "" && key.id != "" && key.id != header.KeyID) {
+			continue
+		}
+		found = true
+
+		switch k := key.key.(type) {
+		case []byte:
+			mac := hmac.New(sha256.New, k)
+			mac.Write(signed)
+			if hmac.Equal(signature, mac.Sum(nil)) {
+				return nil
+			}
+		case *rsa.PublicKey:
+			if rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], signature) == nil {
+				return nil
+			}
+		case *ecdsa.PublicKey:
+			if len(signature) == 64 {
+				r := new(big.Int).SetBytes(signature[:32])
+				s := new(big.Int).SetBytes(signature[32:])
+				if ecdsa.Verify(k, digest[:], r, s) {
+					return nil
+				}
+			}
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("no key found to validate token with algorithm '%s'", header.Algorithm)
+	}
+
+	return errors.New("token signature is invalid")
+}
+
+func (a *JwtAuthenticator) validateClaims(claims map[string]interface{}) error {
+	now := a.now()
+
+	exp, ok := numericClaim(claims, "exp")
+	if !ok {
+		return errors.New("token expiration is missing")
+	}
+	if now.After(time.Unix(exp, 0).Add(a.leeway)) {
+		return errors.New("token is expired")
+	}
+
+	if nbf, ok := numericClaim(claims, "nbf"); ok && now.Add(a.leeway).Before(time.Unix(nbf, 0)) {
+		return errors.New("token is not valid yet")
+	}
+
+	if a.issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != a.issuer {
+			return fmt.Errorf("token issuer '%s' is invalid", iss)
+		}
+	}
+
+	if a.audience != "" && !containsAudience(claims["aud"], a.audience) {
+		return errors.New("token audience is invalid")
+	}
+
+	return nil
+}
+
+func (a *JwtAuthenticator) role(claims map[string]interface{}) (string, error) {
+	var value interface{} = claims
+	for _, name := range a.roleClaim {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			value = nil
+			break
+		}
+		value = m[name]
+	}
+
+	switch value := value.(type) {
+	case string:
+		if value != "" {
+			return value, nil
+		}
+	case []interface{}:
+		// Use the first role when the claim contains a list of roles
+		if len(value) > 0 {
+			if role, ok := value[0].(string); ok && role != "" {
+				return role, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("token claim '%s' is missing or invalid", strings.Join(a.roleClaim, "."))
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+func numericClaim(claims map[string]interface{}, name string) (int64, bool) {
+	if value, ok := claims[name].(json.Number); ok {
+		if i, err := value.Int64(); err == nil {
+			return i, true
+		}
+		if f, err := value.Float64(); err == nil {
+			return int64(f), true
+		}
+	}
+	return 0, false
+}
+
+func containsAudience(value interface{}, audience string) bool {
+	switch value := value.(type) {
+	case string:
+		return value == audience
+	case []interface{}:
+		for _, item := range value {
+			if item == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func loadPemPublicKey(keyPath string) (*jwtKey, error) {
+	data, err := ioutil.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read public key: %s", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found in '%s'", keyPath)
+	}
+
+	var publicKey interface{}
+	switch block.Type {
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		publicKey = cert.PublicKey
+	case "RSA PUBLIC KEY":
+		if publicKey, err = x509.ParsePKCS1PublicKey(block.Bytes); err != nil {
+			return nil, err
+		}
+	default:
+		if publicKey, err = x509.ParsePKIXPublicKey(block.Bytes); err != nil {
+			return nil, err
+		}
+	}
+
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		return &jwtKey{algorithm: algRS256, key: publicKey}, nil
+	case *ecdsa.PublicKey:
+		// Only ES256 is supported, which uses the P-256 curve
+		if key.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("unsupported curve '%s' of public key in '%s'", key.Curve.Params().Name, keyPath)
+		}
+		return &jwtKey{algorithm: algES256, key: publicKey}, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type in '%s'", keyPath)
+	}
+}
+
+type jsonWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	K         string `json:"k"`
+	N         string `json:"n"`
+	E         string `json:"e"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	Y         string `json:"y"`
+}
+
+func loadJwks(jwksPath string) ([]jwtKey, error) {
+	data, err := ioutil.ReadFile(jwksPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read jwks file: %s", err)
+	}
+
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &jwks); err != nil {
+		return nil, fmt.Errorf("unable to parse jwks file: %s", err)
+	}
+
+	keys := make([]jwtKey, 0, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.toKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid key '%s' in jwks file: %s", jwk.KeyID, err)
+		}
+
+		if key != nil {
+			keys = append(keys, *key)
+		}
+	}
+
+	return keys, nil
+}
+
+func (jwk jsonWebKey) toKey() (*jwtKey, error) {
+	switch jwk.KeyType {
+	case "oct":
+		k, err := base64.RawURLEncoding.DecodeString(jwk.K)
+		if err != nil {
+			return nil, err
+		}
+		return &jwtKey{id: jwk.KeyID, algorithm: algHS256, key: k}, nil
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		return &jwtKey{id: jwk.KeyID, algorithm: algRS256, key: &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}}, nil
+	case "EC":
+		if jwk.Curve != "P-256" {
+			return nil, fmt.Errorf("unsupported curve '%s'", jwk.Curve)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &jwtKey{id: jwk.KeyID, algorithm: algES256, key: &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}}, nil
+	}
+
+	// Ignore unsupported key types
+	return nil, nil
+}
@@ -0,0 +1,223 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestJwtAuthenticator_HS256(t *testing.T) {
+	authenticator, err := NewJwtAuthenticator(JwtOptions{Secret: "secret1", Issuer: "issuer1", Audience: "aud1"})
+	require.NoError(t, err)
+
+	exp := time.Now().Add(time.Hour).Unix()
+	token := signHS256(t, "secret1", map[string]interface{}{
+		"role": "user1", "exp": exp, "iss": "issuer1", "aud": []string{"aud1", "aud2"},
+	})
+	role, err := authenticator.Validate(token)
+	assert.NoError(t, err)
+	assert.Equal(t, "user1", role)
+
+	_, err = authenticator.Validate(signHS256(t, "other", map[string]interface{}{
+		"role": "user1", "exp": exp, "iss": "issuer1", "aud": "aud1",
+	}))
+	assert.EqualError(t, err, "token signature is invalid")
+
+	_, err = authenticator.Validate(signHS256(t, "secret1", map[string]interface{}{
+		"role": "user1", "exp": time.Now().Add(-time.Minute).Unix(), "iss": "issuer1", "aud": "aud1",
+	}))
+	assert.EqualError(t, err, "token is expired")
+
+	_, err = authenticator.Validate(signHS256(t, "secret1", map[string]interface{}{
+		"role": "user1", "exp": exp, "iss": "issuer2", "aud": "aud1",
+	}))
+	assert.EqualError(t, err, "token issuer 'issuer2' is invalid")
+
+	_, err = authenticator.Validate(signHS256(t, "secret1", map[string]interface{}{
+		"role": "user1", "exp": exp, "iss": "issuer1", "aud": "aud3",
+	}))
+	assert.EqualError(t, err, "token audience is invalid")
+
+	_, err = authenticator.Validate(signHS256(t, "secret1", map[string]interface{}{
+		"exp": exp, "iss": "issuer1", "aud": "aud1",
+	}))
+	assert.EqualError(t, err, "token claim 'role' is missing or invalid")
+}
+
+func TestJwtAuthenticator_NestedRoleClaim(t *testing.T) {
+	authenticator, err := NewJwtAuthenticator(JwtOptions{Secret: "secret1", RoleClaim: "app.roles"})
+	require.NoError(t, err)
+
+	role, err := authenticator.Validate(signHS256(t, "secret1", map[string]interface{}{
+		"exp": time.Now().Add(time.Hour).Unix(),
+		"app": map[string]interface{}{"roles": []string{"role1", "role2"}},
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, "role1", role)
+}
+
+func TestJwtAuthenticator_ES256PublicKey(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	keyPath := writeTempFile(t, "key.pem", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	authenticator, err := NewJwtAuthenticator(JwtOptions{PublicKeyPaths: []string{keyPath}})
+	require.NoError(t, err)
+
+	token := sign(t, algES256, "", map[string]interface{}{"role": "user2", "exp": time.Now().Add(time.Hour).Unix()},
+		func(digest []byte) []byte {
+			r, s, err := ecdsa.Sign(rand.Reader, key, digest)
+			require.NoError(t, err)
+			signature := make([]byte, 64)
+			rBytes, sBytes := r.Bytes(), s.Bytes()
+			copy(signature[32-len(rBytes):32], rBytes)
+			copy(signature[64-len(sBytes):], sBytes)
+			return signature
+		})
+
+	role, err := authenticator.Validate(token)
+	assert.NoError(t, err)
+	assert.Equal(t, "user2", role)
+}
+
+func TestJwtAuthenticator_UnsupportedCurvePublicKey(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	keyPath := writeTempFile(t, "key.pem", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	_, err = NewJwtAuthenticator(JwtOptions{PublicKeyPaths: []string{keyPath}})
+	assert.EqualError(t, err, fmt.Sprintf("unsupported curve 'P-384' of public key in '%s'", keyPath))
+}
+
+func TestJwtAuthenticator_RS256Jwks(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	jwks := fmt.Sprintf(`{"keys":[{"kty":"RSA","kid":"key1","use":"sig","n":"%s","e":"%s"}]}`,
+		base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()))
+	jwksPath := writeTempFile(t, "jwks.json", []byte(jwks))
+
+	authenticator, err := NewJwtAuthenticator(JwtOptions{JwksPath: jwksPath})
+	require.NoError(t, err)
+
+	signer := func(digest []byte) []byte {
+		signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest)
+		require.NoError(t, err)
+		return signature
+	}
+	claims := map[string]interface{}{"role": "user3", "exp": time.Now().Add(time.Hour).Unix()}
+
+	role, err := authenticator.Validate(sign(t, algRS256, "key1", claims, signer))
+	assert.NoError(t, err)
+	assert.Equal(t, "user3", role)
+
+	_, err = authenticator.Validate(sign(t, algRS256, "key2", claims, signer))
+	assert.EqualError(t, err, "no key found to validate token with algorithm 'RS256'")
+
+	_, err = authenticator.Validate(sign(t, "none", "", claims, func([]byte) []byte { return nil }))
+	assert.EqualError(t, err, "unsupported token algorithm 'none'")
+}
+
+func TestAuthHandler(t *testing.T) {
+	authenticator, err := NewJwtAuthenticator(JwtOptions{Secret: "secret1"})
+	require.NoError(t, err)
+
+	var userOrRole string
+	handler := NewAuthHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userOrRole = ContextUserOrRole(r.Context())
+	}), authenticator, nopLogger{})
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer abc.def.ghi")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer "+signHS256(t, "secret1", map[string]interface{}{
+		"role": "user1", "exp": time.Now().Add(time.Hour).Unix(),
+	}))
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "user1", userOrRole)
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...interface{}) {}
+func (nopLogger) Info(string, ...interface{})  {}
+func (nopLogger) Warn(string, ...interface{})  {}
+func (nopLogger) Error(string, ...interface{}) {}
+func (nopLogger) Fatal(string, ...interface{}) {}
+
+func signHS256(t *testing.T, secret string, claims map[string]interface{}) string {
+	return sign(t, algHS256, "", claims, nil, secret)
+}
+
+func sign(
+	t *testing.T, alg string, kid string, claims map[string]interface{}, signer func([]byte) []byte, secret ...string,
+) string {
+	header := map[string]string{"alg": alg, "typ": "JWT"}
+	if kid != "" {
+		header["kid"] = kid
+	}
+	headerJson, err := json.Marshal(header)
+	require.NoError(t, err)
+	claimsJson, err := json.Marshal(claims)
+	require.NoError(t, err)
+
+	signed := base64.RawURLEncoding.EncodeToString(headerJson) + "." + base64.RawURLEncoding.EncodeToString(claimsJson)
+
+	var signature []byte
+	if signer == nil {
+		mac := hmac.New(sha256.New, []byte(secret[0]))
+		mac.Write([]byte(signed))
+		signature = mac.Sum(nil)
+	} else {
+		digest := sha256.Sum256([]byte(signed))
+		signature = signer(digest[:])
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func writeTempFile(t *testing.T, name string, data []byte) string {
+	dir, err := ioutil.TempDir("", "auth")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	filePath := path.Join(dir, name)
+	require.NoError(t, ioutil.WriteFile(filePath, data, 0600))
+	return filePath
+}
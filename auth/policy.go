@@ -0,0 +1,344 @@
+package auth
+
+import (
+	"fmt"
+	e "github.com/datastax/cassandra-data-apis/errors"
+	"github.com/gocql/gocql"
+	"github.com/spf13/viper"
+	"strings"
+)
+
+type Permissions int
+
+const (
+	PermissionRead Permissions = 1 << iota
+	PermissionInsert
+	PermissionUpdate
+	PermissionDelete
+	PermissionSchema
+)
+
+const AllPermissions = PermissionRead | PermissionInsert | PermissionUpdate | PermissionDelete | PermissionSchema
+
+// AnyName can be used as role, keyspace or table name in a policy to match all the roles, keyspaces or tables
+const AnyName = "*"
+
+func Perms(perms ...string) (Permissions, error) {
+	var p Permissions
+	for _, perm := range perms {
+		switch strings.ToLower(perm) {
+		case "read":
+			p |= PermissionRead
+		case "insert":
+			p |= PermissionInsert
+		case "update":
+			p |= PermissionUpdate
+		case "delete":
+			p |= PermissionDelete
+		case "schema":
+			p |= PermissionSchema
+		case "all":
+			p |= AllPermissions
+		default:
+			return 0, fmt.Errorf("invalid permission: %s", perm)
+		}
+	}
+	return p, nil
+}
+
+func (p Permissions) IsGranted(perms Permissions) bool { return p&perms != 0 }
+
+func (p Permissions) String() string {
+	names := make([]string, 0)
+	for _, item := range []struct {
+		perm Permissions
+		name string
+	}{
+		{PermissionRead, "read"},
+		{PermissionInsert, "insert"},
+		{PermissionUpdate, "update"},
+		{PermissionDelete, "delete"},
+		{PermissionSchema, "schema"},
+	} {
+		if p&item.perm != 0 {
+			names = append(names, item.name)
+		}
+	}
+	return strings.Join(names, "/")
+}
+
+// PolicyConfig is the declarative representation of a policy, as read from the policy file
+type PolicyConfig struct {
+	Roles []RolePolicyConfig `mapstructure:"roles"`
+}
+
+type RolePolicyConfig struct {
+	// Name is the user or role the grants apply to, "*" applies them to every user or role
+	Name   string        `mapstructure:"name"`
+	Grants []GrantConfig `mapstructure:"grants"`
+}
+
+type GrantConfig struct {
+	Keyspace string `mapstructure:"keyspace"`
+	// Table is the table name, when empty (or "*") the grant applies to all tables in the keyspace
+	Table       string   `mapstructure:"table"`
+	Permissions []string `mapstructure:"permissions"`
+	// Columns restricts the grant to the provided regular and static columns, primary key columns are available
+	// whenever the table is. When empty, the grant applies to all the columns.
+	Columns []string `mapstructure:"columns"`
+}
+
+type grant struct {
+	keyspace    string
+	table       string
+	permissions Permissions
+	columns     map[string]bool
+}
+
+// Policy grants per-role permissions on keyspaces, tables and columns.
+// A nil Policy allows every operation.
+type Policy struct {
+	grants map[string][]grant
+}
+
+// LoadPolicy reads a policy file in any of the formats supported by viper (YAML, JSON, ...)
+func LoadPolicy(policyPath string) (*Policy, error) {
+	v := viper.New()
+	v.SetConfigFile(policyPath)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("unable to read policy file: %s", err)
+	}
+
+	var cfg PolicyConfig
+	if err := v.Unmarshal(&cfg); err != nil {
+		return nil, fmt.Errorf("unable to parse policy file: %s", err)
+	}
+
+	return NewPolicy(cfg)
+}
+
+func NewPolicy(cfg PolicyConfig) (*Policy, error) {
+	grants := make(map[string][]grant, len(cfg.Roles))
+	for _, role := range cfg.Roles {
+		if role.Name == "" {
+			return nil, fmt.Errorf("role name is required in policy")
+		}
+
+		for _, g := range role.Grants {
+			if g.Keyspace == "" {
+				return nil, fmt.Errorf("keyspace is required in policy grants for role '%s'", role.Name)
+			}
+
+			permissions, err := Perms(g.Permissions...)
+			if err != nil {
+				return nil, fmt.Errorf("invalid grant for role '%s': %s", role.Name, err)
+			}
+
+			var columns map[string]bool
+			if len(g.Columns) > 0 {
+				columns = make(map[string]bool, len(g.Columns))
+				for _, column := range g.Columns {
+					columns[column] = true
+				}
+			}
+
+			table := g.Table
+			if table == AnyName {
+				table = ""
+			}
+
+			grants[role.Name] = append(grants[role.Name], grant{
+				keyspace:    g.Keyspace,
+				table:       table,
+				permissions: permissions,
+				columns:     columns,
+			})
+		}
+	}
+
+	return &Policy{grants: grants}, nil
+}
+
+// IsAllowed determines whether the user or role was granted any of the provided permissions on the table.
+// When table is empty, the permissions must be granted for the whole keyspace.
+func (p *Policy) IsAllowed(userOrRole string, keyspace string, table string, perms Permissions) bool {
+	if p == nil {
+		return true
+	}
+
+	return p.anyGrant(userOrRole, func(g grant) bool {
+		return g.permissions.IsGranted(perms) && g.matchesKeyspace(keyspace) &&
+			(g.table == "" || (table != "" && g.table == table))
+	})
+}
+
+// IsKeyspaceVisible determines whether the user or role was granted any permission on the keyspace or on any of its
+// tables
+func (p *Policy) IsKeyspaceVisible(userOrRole string, keyspace string) bool {
+	if p == nil {
+		return true
+	}
+
+	return p.anyGrant(userOrRole, func(g grant) bool {
+		return g.permissions != 0 && g.matchesKeyspace(keyspace)
+	})
+}
+
+// IsTableVisible determines whether any user or role was granted a permission on the table
+func (p *Policy) IsTableVisible(keyspace string, table string) bool {
+	if p == nil {
+		return true
+	}
+
+	for _, grants := range p.grants {
+		for _, g := range grants {
+			if g.permissions != 0 && g.matches(keyspace, table) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// IsColumnAllowed determines whether the user or role was granted any of the provided permissions on the column
+func (p *Policy) IsColumnAllowed(
+	userOrRole string,
+	table *gocql.TableMetadata,
+	column string,
+	perms Permissions,
+) bool {
+	if p == nil {
+		return true
+	}
+
+	isKey := isPrimaryKeyColumn(table, column)
+	return p.anyGrant(userOrRole, func(g grant) bool {
+		return g.permissions.IsGranted(perms) && g.matches(table.Keyspace, table.Name) &&
+			(isKey || g.columns == nil || g.columns[column])
+	})
+}
+
+// IsColumnVisible determines whether any user or role was granted a permission on the column
+func (p *Policy) IsColumnVisible(table *gocql.TableMetadata, column string) bool {
+	if p == nil {
+		return true
+	}
+
+	isKey := isPrimaryKeyColumn(table, column)
+	for _, grants := range p.grants {
+		for _, g := range grants {
+			if g.permissions != 0 && g.matches(table.Keyspace, table.Name) &&
+				(isKey || g.columns == nil || g.columns[column]) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// CheckTable returns a ForbiddenError when the user or role was not granted the permissions on the table
+func (p *Policy) CheckTable(userOrRole string, keyspace string, table string, perms Permissions) error {
+	if p.IsAllowed(userOrRole, keyspace, table, perms) {
+		return nil
+	}
+
+	if table == "" {
+		return e.NewForbiddenError(fmt.Sprintf(
+			"%s permission on keyspace '%s' not granted to '%s'", perms, keyspace, userOrRole))
+	}
+
+	return e.NewForbiddenError(fmt.Sprintf(
+		"%s permission on table '%s.%s' not granted to '%s'", perms, keyspace, table, userOrRole))
+}
+
+// CheckColumns returns a ForbiddenError when the user or role was not granted the permissions on the table or any of
+// the columns
+func (p *Policy) CheckColumns(
+	userOrRole string,
+	table *gocql.TableMetadata,
+	perms Permissions,
+	columns []string,
+) error {
+	if p == nil {
+		return nil
+	}
+
+	if err := p.CheckTable(userOrRole, table.Keyspace, table.Name, perms); err != nil {
+		return err
+	}
+
+	for _, column := range columns {
+		if !p.IsColumnAllowed(userOrRole, table, column, perms) {
+			return e.NewForbiddenError(fmt.Sprintf("%s permission on column '%s' of table '%s.%s' not granted to '%s'",
+				perms, column, table.Keyspace, table.Name, userOrRole))
+		}
+	}
+
+	return nil
+}
+
+// FilterRows removes the values of the columns that the user or role is not allowed to read.
+// The rows are modified in place.
+func (p *Policy) FilterRows(
+	userOrRole string,
+	table *gocql.TableMetadata,
+	rows []map[string]interface{},
+) []map[string]interface{} {
+	if p == nil {
+		return rows
+	}
+
+	allowed := make(map[string]bool)
+	for _, row := range rows {
+		for column := range row {
+			isAllowed, found := allowed[column]
+			if !found {
+				_, isColumn := table.Columns[column]
+				// Ignore values that are not columns, like "[applied]"
+				isAllowed = !isColumn || p.IsColumnAllowed(userOrRole, table, column, PermissionRead)
+				allowed[column] = isAllowed
+			}
+
+			if !isAllowed {
+				delete(row, column)
+			}
+		}
+	}
+
+	return rows
+}
+
+func (p *Policy) anyGrant(userOrRole string, fn func(g grant) bool) bool {
+	for _, g := range p.grants[userOrRole] {
+		if fn(g) {
+			return true
+		}
+	}
+
+	if userOrRole != AnyName {
+		for _, g := range p.grants[AnyName] {
+			if fn(g) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (g grant) matchesKeyspace(keyspace string) bool {
+	return g.keyspace == AnyName || g.keyspace == keyspace
+}
+
+func (g grant) matches(keyspace string, table string) bool {
+	return g.matchesKeyspace(keyspace) && (g.table == "" || g.table == table)
+}
+
+func isPrimaryKeyColumn(table *gocql.TableMetadata, column string) bool {
+	if c, ok := table.Columns[column]; ok {
+		return c.Kind == gocql.ColumnPartitionKey || c.Kind == gocql.ColumnClusteringKey
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package auth
+
+import (
+	e "github.com/datastax/cassandra-data-apis/errors"
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+const policyYaml = `
+roles:
+  - name: reader
+    grants:
+      - keyspace: store
+        permissions: [read]
+  - name: editor
+    grants:
+      - keyspace: store
+        table: books
+        permissions: [read, insert, update]
+        columns: [pages]
+  - name: "*"
+    grants:
+      - keyspace: catalog
+        table: "*"
+        permissions: [read]
+`
+
+func TestLoadPolicy(t *testing.T) {
+	policy, err := LoadPolicy(writeTempFile(t, "policy.yaml", []byte(policyYaml)))
+	require.NoError(t, err)
+
+	assert.True(t, policy.IsAllowed("reader", "store", "books", PermissionRead))
+	assert.True(t, policy.IsAllowed("reader", "store", "", PermissionRead))
+	assert.False(t, policy.IsAllowed("reader", "store", "books", PermissionInsert))
+	assert.True(t, policy.IsAllowed("editor", "store", "books", PermissionInsert))
+	assert.False(t, policy.IsAllowed("editor", "store", "authors", PermissionRead))
+	assert.False(t, policy.IsAllowed("editor", "store", "", PermissionRead))
+	assert.True(t, policy.IsAllowed("other", "catalog", "products", PermissionRead))
+	assert.False(t, policy.IsAllowed("other", "store", "books", PermissionRead))
+
+	assert.True(t, policy.IsKeyspaceVisible("editor", "store"))
+	assert.True(t, policy.IsKeyspaceVisible("editor", "catalog"))
+	assert.False(t, policy.IsKeyspaceVisible("other", "store"))
+}
+
+func TestNewPolicy_Invalid(t *testing.T) {
+	_, err := NewPolicy(PolicyConfig{Roles: []RolePolicyConfig{{Name: "role1", Grants: []GrantConfig{
+		{Keyspace: "ks1", Permissions: []string{"read", "truncate"}},
+	}}}})
+	assert.EqualError(t, err, "invalid grant for role 'role1': invalid permission: truncate")
+
+	_, err = NewPolicy(PolicyConfig{Roles: []RolePolicyConfig{{Name: "role1", Grants: []GrantConfig{
+		{Permissions: []string{"read"}},
+	}}}})
+	assert.EqualError(t, err, "keyspace is required in policy grants for role 'role1'")
+}
+
+func TestPolicy_Columns(t *testing.T) {
+	policy, err := LoadPolicy(writeTempFile(t, "policy.yaml", []byte(policyYaml)))
+	require.NoError(t, err)
+
+	table := booksTable()
+
+	assert.True(t, policy.IsColumnAllowed("editor", table, "pages", PermissionUpdate))
+	assert.True(t, policy.IsColumnAllowed("editor", table, "title", PermissionUpdate))
+	assert.False(t, policy.IsColumnAllowed("editor", table, "author", PermissionRead))
+	assert.True(t, policy.IsColumnAllowed("reader", table, "author", PermissionRead))
+
+	assert.True(t, policy.IsColumnVisible(table, "author"))
+	assert.True(t, policy.IsTableVisible("store", "books"))
+	assert.False(t, policy.IsTableVisible("other", "books"))
+
+	assert.NoError(t, policy.CheckColumns("editor", table, PermissionInsert, []string{"title", "pages"}))
+	err = policy.CheckColumns("editor", table, PermissionInsert, []string{"title", "author"})
+	assert.IsType(t, &e.ForbiddenError{}, err)
+	assert.EqualError(t, err, "insert permission on column 'author' of table 'store.books' not granted to 'editor'")
+	assert.EqualError(t, policy.CheckColumns("reader", table, PermissionDelete, nil),
+		"delete permission on table 'store.books' not granted to 'reader'")
+
+	rows := policy.FilterRows("editor", table, []map[string]interface{}{
+		{"title": "a", "pages": 1, "author": "b", "[applied]": false},
+	})
+	assert.Equal(t, []map[string]interface{}{{"title": "a", "pages": 1, "[applied]": false}}, rows)
+}
+
+func TestPolicy_Nil(t *testing.T) {
+	var policy *Policy
+	table := booksTable()
+
+	assert.True(t, policy.IsAllowed("any", "store", "books", PermissionSchema))
+	assert.True(t, policy.IsColumnVisible(table, "author"))
+	assert.NoError(t, policy.CheckColumns("", table, PermissionDelete, []string{"author"}))
+}
+
+func booksTable() *gocql.TableMetadata {
+	return &gocql.TableMetadata{
+		Keyspace: "store",
+		Name:     "books",
+		Columns: map[string]*gocql.ColumnMetadata{
+			"title":  {Name: "title", Kind: gocql.ColumnPartitionKey},
+			"pages":  {Name: "pages", Kind: gocql.ColumnRegular},
+			"author": {Name: "author", Kind: gocql.ColumnRegular},
+		},
+	}
+}
@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const DefaultTokenTTL = 30 * time.Minute
+
+const tokenSize = 32
+
+type tokenEntry struct {
+	userOrRole string
+	expires    time.Time
+}
+
+// TokenStore is an Authenticator that issues opaque tokens for a user or role and validates the token provided in the
+// "X-Cassandra-Token" header. Tokens expire after the ttl.
+type TokenStore struct {
+	mutex  sync.Mutex
+	ttl    time.Duration
+	tokens map[string]tokenEntry
+	now    func() time.Time
+}
+
+// NewTokenStore creates a new TokenStore issuing tokens with the provided time to live
+func NewTokenStore(ttl time.Duration) *TokenStore {
+	return &TokenStore{
+		ttl:    ttl,
+		tokens: make(map[string]tokenEntry),
+		now:    time.Now,
+	}
+}
+
+// Create issues a new token for the user or role
+func (s *TokenStore) Create(userOrRole string) (string, error) {
+	buf := make([]byte, tokenSize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(buf)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := s.now()
+	// Remove the expired tokens as new ones are issued to keep the store bounded
+	for key, entry := range s.tokens {
+		if now.After(entry.expires) {
+			delete(s.tokens, key)
+		}
+	}
+
+	s.tokens[token] = tokenEntry{userOrRole: userOrRole, expires: now.Add(s.ttl)}
+	return token, nil
+}
+
+// Authenticate returns the user or role the token of the request was issued for
+func (s *TokenStore) Authenticate(r *http.Request) (string, error) {
+	token := r.Header.Get(ApiKeyHeader)
+	if token == "" {
+		return "", ErrNoCredentials
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entry, ok := s.tokens[token]
+	if !ok {
+		return "", errors.New("invalid token")
+	}
+
+	if s.now().After(entry.expires) {
+		delete(s.tokens, token)
+		return "", errors.New("token expired")
+	}
+
+	return entry.userOrRole, nil
+}
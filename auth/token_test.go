@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTokenStore(t *testing.T) {
+	store := NewTokenStore(time.Minute)
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	token1, err := store.Create("role1")
+	require.NoError(t, err)
+	token2, err := store.Create("role2")
+	require.NoError(t, err)
+	assert.NotEqual(t, token1, token2)
+
+	role, err := store.Authenticate(requestWithApiKey(token1))
+	assert.NoError(t, err)
+	assert.Equal(t, "role1", role)
+
+	_, err = store.Authenticate(requestWithApiKey("unknown"))
+	assert.EqualError(t, err, "invalid token")
+
+	_, err = store.Authenticate(httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, ErrNoCredentials, err)
+
+	now = now.Add(2 * time.Minute)
+	_, err = store.Authenticate(requestWithApiKey(token2))
+	assert.EqualError(t, err, "token expired")
+
+	// Expired tokens are removed when creating new ones
+	_, err = store.Create("role3")
+	require.NoError(t, err)
+	assert.Len(t, store.tokens, 1)
+}
+
+func TestChainAuthenticator_SharedHeader(t *testing.T) {
+	apiKeyAuthenticator, err := NewApiKeyAuthenticator(time.Minute, nopLogger{},
+		ApiKeyStoreFunc(func() (map[string]string, error) { return map[string]string{"key1": "role1"}, nil }))
+	require.NoError(t, err)
+	store := NewTokenStore(time.Minute)
+	token, err := store.Create("user1")
+	require.NoError(t, err)
+
+	authenticator := NewChainAuthenticator(apiKeyAuthenticator, store)
+
+	role, err := authenticator.Authenticate(requestWithApiKey(token))
+	assert.NoError(t, err)
+	assert.Equal(t, "user1", role)
+
+	role, err = authenticator.Authenticate(requestWithApiKey("key1"))
+	assert.NoError(t, err)
+	assert.Equal(t, "role1", role)
+
+	_, err = authenticator.Authenticate(requestWithApiKey("other"))
+	assert.EqualError(t, err, "invalid api key")
+}
@@ -4,22 +4,29 @@ import (
 	"encoding/csv"
 	"errors"
 	"fmt"
+	"github.com/datastax/cassandra-data-apis/audit"
+	"github.com/datastax/cassandra-data-apis/auth"
 	"github.com/datastax/cassandra-data-apis/config"
 	"github.com/datastax/cassandra-data-apis/db"
 	"github.com/datastax/cassandra-data-apis/endpoint"
 	"github.com/datastax/cassandra-data-apis/graphql"
 	"github.com/datastax/cassandra-data-apis/log"
+	"github.com/datastax/cassandra-data-apis/ratelimit"
+	restEndpointV1 "github.com/datastax/cassandra-data-apis/rest/endpoint/v1"
 	"github.com/datastax/cassandra-data-apis/types"
 	"github.com/julienschmidt/httprouter"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
+	"io/ioutil"
 	log2 "log"
 	"net/http"
 	"os"
+	"os/signal"
 	"path"
 	"strings"
+	"syscall"
 )
 
 const defaultGraphQLPath = "/graphql"
@@ -33,6 +40,13 @@ const envVarPrefix = "data_api"
 var cfgFile string
 var logger log.Logger
 var cfg *endpoint.DataEndpointConfig
+var authenticator auth.Authenticator
+var tokens *auth.TokenStore
+var serverTls *auth.ServerTls
+var limiter *ratelimit.Limiter
+var auditor *audit.Auditor
+var apiKeyAuthenticator *auth.ApiKeyAuthenticator
+var fileWatchers []*config.FileWatcher
 
 var serverCmd = &cobra.Command{
 	Use:   os.Args[0] + " --hosts [HOSTS] [--start-graph|--start-rest] [OPTIONS]",
@@ -56,10 +70,29 @@ var serverCmd = &cobra.Command{
 			return errors.New("both the client certificate and private must be set")
 		}
 
+		isSetTlsCertPath := viper.GetString("tls-cert-path") != ""
+		isSetTlsKeyPath := viper.GetString("tls-key-path") != ""
+		if isSetTlsCertPath != isSetTlsKeyPath {
+			return errors.New("both the server certificate and private key must be set")
+		}
+
+		if viper.GetString("tls-client-ca-path") != "" && !isSetTlsCertPath {
+			return errors.New("the server certificate must be set to verify client certificates")
+		}
+
+		if viper.GetString("tls-client-cert-role") != "" && viper.GetString("tls-client-ca-path") == "" {
+			return errors.New("the client ca must be set to use the client certificate role")
+		}
+
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
 		endpoint := createEndpoint()
+		authenticator = createAuthenticator(endpoint.DbClient())
+		serverTls = createServerTls()
+		limiter = createLimiter()
+		watchDbFiles(endpoint.DbClient())
+		go stopOnSignal()
 
 		graphqlPort := viper.GetInt("graphql-port")
 		restPort := viper.GetInt("rest-port")
@@ -125,6 +158,8 @@ func Execute() {
 	flags.StringSliceP("hosts", "t", nil, "hosts for connecting to the database")
 	flags.StringP("username", "u", "", "connect with database username")
 	flags.StringP("password", "p", "", "database user's password")
+	flags.String("password-path", "", "path to a file containing the database user's password, it takes precedence over password")
+	flags.Duration("files-reload-interval", config.DefaultFilesReloadInterval, "interval used to check the certificate, key and password files for changes")
 
 	flags.String("keyspace", "", "only allow access to a single keyspace")
 	flags.Bool("request-logging", false, "enable request logging")
@@ -143,6 +178,51 @@ func Execute() {
 	flags.String("ssl-client-key-path", "", "SSL client private key path")
 	flags.Bool("ssl-host-verification", true, "verify the peer certificate? It is highly insecure to disable host verification")
 
+	// TLS for the http listeners
+	flags.String("tls-cert-path", "", "path to the PEM encoded certificate used to serve the endpoints over TLS")
+	flags.String("tls-key-path", "", "path to the PEM encoded private key of the TLS certificate")
+	flags.String("tls-client-ca-path", "", "path to a PEM encoded CA bundle used to verify client certificates")
+	flags.String("tls-client-auth", "", "client certificate verification: none, optional or required (defaults to required when tls-client-ca-path is set)")
+	flags.String("tls-client-cert-role", "", "use the verified client certificate to obtain the user or role: cn (subject common name) or san (subject alternative name)")
+
+	// Authentication
+	flags.Bool("jwt-enabled", false, "validate JSON Web Tokens (JWT) provided as \"Authorization: Bearer\" header and use the role claim to execute the requests")
+	flags.String("jwt-jwks-path", "", "path to a JSON Web Key Set (JWKS) file used to validate tokens")
+	flags.String("jwt-secret", "", "shared secret used to validate HS256 tokens")
+	flags.StringSlice("jwt-public-key-paths", nil, "paths to PEM encoded public keys or certificates used to validate RS256 and ES256 tokens")
+	flags.String("jwt-issuer", "", "expected token issuer (iss claim)")
+	flags.String("jwt-audience", "", "expected token audience (aud claim)")
+	flags.String("jwt-role-claim", auth.DefaultJwtRoleClaim, "token claim containing the user or role used to execute the requests, use dots for nested claims")
+	flags.Duration("jwt-leeway", 0, "allowed clock skew when validating the token expiration and not before claims")
+	flags.String("api-keys-path", "", "path to a file (YAML or JSON) containing the API keys accepted in the \"X-Cassandra-Token\" header and the role of each key")
+	flags.String("api-keys-env", "", "name of an environment variable containing a comma separated list of key:role API keys")
+	flags.String("api-keys-table", "", "table containing the API keys, as keyspace.table, with \"key\" and \"role\" text columns")
+	flags.Duration("api-keys-reload-interval", auth.DefaultApiKeysReloadInterval, "interval used to reload the API keys")
+	flags.Bool("auth-tokens-enabled", false, "expose a REST route that validates database credentials and issues tokens accepted in the \"X-Cassandra-Token\" header")
+	flags.Duration("auth-token-ttl", auth.DefaultTokenTTL, "time to live of the issued auth tokens")
+
+	// Authorization
+	flags.String("policy-path", "", "path to a policy file (YAML or JSON) granting per-role permissions on keyspaces, tables and columns")
+
+	// Rate limiting
+	flags.Float64("rate-limit-reads", 0, "maximum read requests per second for each rate limit key, 0 disables the limit")
+	flags.Int("rate-limit-reads-burst", 100, "maximum burst of read requests")
+	flags.Float64("rate-limit-writes", 0, "maximum write requests per second for each rate limit key, 0 disables the limit")
+	flags.Int("rate-limit-writes-burst", 100, "maximum burst of write requests")
+	flags.Float64("rate-limit-schema", 0, "maximum schema change requests per second for each rate limit key, 0 disables the limit")
+	flags.Int("rate-limit-schema-burst", 1, "maximum burst of schema change requests")
+	flags.StringSlice("rate-limit-key", []string{ratelimit.KeyRole, ratelimit.KeyIp, ratelimit.KeyKeyspace}, "request attributes each rate limit budget is tracked by. options: role,ip,keyspace")
+
+	// Audit log
+	flags.Bool("audit-stdout", false, "write the audit events of mutations and schema changes to stdout as JSON lines")
+	flags.String("audit-path", "", "path to a file where the audit events are appended as JSON lines")
+	flags.String("audit-table", "", "table where the audit events are inserted, using the format: keyspace.table")
+
+	// Filtering
+	flags.Bool("allow-filtering", false, "allow queries to use ALLOW FILTERING on all the tables, the queries must restrict the partition key or use a limit")
+	flags.StringSlice("allow-filtering-tables", nil, "tables that allow queries to use ALLOW FILTERING, using the format: keyspace.table")
+	flags.Int("allow-filtering-max-limit", config.DefaultAllowFilteringMaxLimit, "maximum limit of the queries using ALLOW FILTERING that don't restrict the partition key")
+
 	// GraphQL specific flags
 	flags.Bool("start-graphql", true, "start the GraphQL endpoint")
 	flags.String("graphql-path", defaultGraphQLPath, "GraphQL endpoint path")
@@ -150,6 +230,12 @@ func Execute() {
 	flags.Bool("graphql-playground", true, "expose a GraphQL playground route")
 	flags.String("graphql-playground-path", defaultGraphQLPlaygroundPath, "path for the GraphQL playground static file")
 	flags.Int("graphql-port", 8080, "GraphQL endpoint port")
+	flags.Bool("graphql-role-schemas", false, "build a GraphQL schema per role only containing the tables the role can access (requires authentication)")
+	flags.Int("graphql-role-schema-cache-size", endpoint.DefaultRoleSchemaCacheSize, "maximum number of role GraphQL schemas kept in memory")
+	flags.Int("graphql-max-depth", 0, "maximum selection depth of GraphQL queries, 0 disables the limit")
+	flags.Int("graphql-max-aliases", 0, "maximum number of aliased fields in GraphQL queries, 0 disables the limit")
+	flags.Int("graphql-max-page-size", 0, "maximum number of rows a GraphQL query field can request using pageSize or limit, 0 disables the limit")
+	flags.Int("graphql-max-total-page-size", 0, "maximum number of rows requested by all the fields of a GraphQL query, 0 disables the limit")
 
 	// REST specific flags
 	flags.Bool("start-rest", true, "start the REST endpoint")
@@ -186,6 +272,52 @@ func createEndpoint() *endpoint.DataEndpoint {
 		updateInterval = endpoint.DefaultSchemaUpdateDuration
 	}
 
+	dbConfig, err := createDbConfig()
+	if err != nil {
+		logger.Fatal("invalid database settings", "error", err)
+	}
+
+	auditor = createAuditor()
+
+	cfg.
+		WithDbConfig(dbConfig).
+		WithExcludedKeyspaces(getStringSlice("excluded-keyspaces")).
+		WithSchemaUpdateInterval(updateInterval).
+		WithUseUserOrRoleAuth(isAuthEnabled()).
+		WithPolicy(loadPolicy()).
+		WithRoleSchemas(viper.GetBool("graphql-role-schemas")).
+		WithRoleSchemaCacheSize(viper.GetInt("graphql-role-schema-cache-size")).
+		WithGraphQLLimits(config.GraphQLLimits{
+			MaxDepth:         viper.GetInt("graphql-max-depth"),
+			MaxAliases:       viper.GetInt("graphql-max-aliases"),
+			MaxPageSize:      viper.GetInt("graphql-max-page-size"),
+			MaxTotalPageSize: viper.GetInt("graphql-max-total-page-size"),
+		}).
+		WithAuditor(auditor).
+		WithAllowFiltering(config.AllowFilteringSettings{
+			AllTables: viper.GetBool("allow-filtering"),
+			Tables:    getStringSlice("allow-filtering-tables"),
+			MaxLimit:  viper.GetInt("allow-filtering-max-limit"),
+		})
+
+	dataEndpoint, err := cfg.NewEndpoint()
+	if err != nil {
+		logger.Fatal("unable create new dataEndpoint",
+			"error", err)
+	}
+
+	if auditTable := viper.GetString("audit-table"); auditTable != "" {
+		parts := strings.Split(auditTable, ".")
+		if len(parts) != 2 {
+			logger.Fatal("invalid audit table, expected keyspace.table", "table", auditTable)
+		}
+		auditor.AddSink(dataEndpoint.DbClient().AuditSink(parts[0], parts[1]))
+	}
+
+	return dataEndpoint
+}
+
+func createDbConfig() (db.Config, error) {
 	var sslOptions *db.SslOptions
 	if viper.GetBool("ssl-enabled") {
 		sslOptions = &db.SslOptions{
@@ -196,22 +328,103 @@ func createEndpoint() *endpoint.DataEndpoint {
 		}
 	}
 
-	cfg.
-		WithDbConfig(db.Config{
-			Username:   viper.GetString("username"),
-			Password:   viper.GetString("password"),
-			SslOptions: sslOptions,
-		}).
-		WithExcludedKeyspaces(getStringSlice("excluded-keyspaces")).
-		WithSchemaUpdateInterval(updateInterval)
+	password := viper.GetString("password")
+	if passwordPath := viper.GetString("password-path"); passwordPath != "" {
+		data, err := ioutil.ReadFile(passwordPath)
+		if err != nil {
+			return db.Config{}, fmt.Errorf("unable to read password file: %s", err)
+		}
+		password = strings.TrimSpace(string(data))
+	}
 
-	dataEndpoint, err := cfg.NewEndpoint()
+	return db.Config{
+		Username:   viper.GetString("username"),
+		Password:   password,
+		SslOptions: sslOptions,
+	}, nil
+}
+
+// watchDbFiles reconnects to the database when the client certificates or the password file change
+func watchDbFiles(dbClient *db.Db) {
+	paths := make([]string, 0)
+	if viper.GetBool("ssl-enabled") {
+		paths = appendNonEmpty(paths, viper.GetString("ssl-ca-cert-path"), viper.GetString("ssl-client-cert-path"),
+			viper.GetString("ssl-client-key-path"))
+	}
+	paths = appendNonEmpty(paths, viper.GetString("password-path"))
+
+	startFileWatcher(func() error {
+		dbConfig, err := createDbConfig()
+		if err != nil {
+			return err
+		}
+		return dbClient.Reload(dbConfig)
+	}, paths...)
+}
+
+func createServerTls() *auth.ServerTls {
+	certPath := viper.GetString("tls-cert-path")
+	if certPath == "" {
+		return nil
+	}
+
+	options := auth.TlsOptions{
+		CertPath:     certPath,
+		KeyPath:      viper.GetString("tls-key-path"),
+		ClientCaPath: viper.GetString("tls-client-ca-path"),
+		ClientAuth:   viper.GetString("tls-client-auth"),
+	}
+
+	result, err := auth.NewServerTls(options)
 	if err != nil {
-		logger.Fatal("unable create new dataEndpoint",
-			"error", err)
+		logger.Fatal("invalid tls settings", "error", err)
 	}
 
-	return dataEndpoint
+	// New connections use the reloaded certificates
+	startFileWatcher(result.Reload, appendNonEmpty(nil, options.CertPath, options.KeyPath, options.ClientCaPath)...)
+
+	return result
+}
+
+func startFileWatcher(onChange func() error, paths ...string) {
+	if len(paths) == 0 {
+		return
+	}
+
+	interval := viper.GetDuration("files-reload-interval")
+	if interval <= 0 {
+		interval = config.DefaultFilesReloadInterval
+	}
+
+	// The watchers are stopped on shutdown
+	watcher := config.NewFileWatcher(interval, logger, onChange, paths...)
+	fileWatchers = append(fileWatchers, watcher)
+	go watcher.Start()
+}
+
+// stopOnSignal stops the background tasks when the process is interrupted or terminated
+func stopOnSignal() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	received := <-signals
+	logger.Info("shutting down", "signal", received.String())
+
+	if apiKeyAuthenticator != nil {
+		apiKeyAuthenticator.Stop()
+	}
+	for _, watcher := range fileWatchers {
+		watcher.Stop()
+	}
+	os.Exit(0)
+}
+
+func appendNonEmpty(slice []string, values ...string) []string {
+	for _, value := range values {
+		if value != "" {
+			slice = append(slice, value)
+		}
+	}
+	return slice
 }
 
 func addGraphQLRoutes(router *httprouter.Router, endpoint *endpoint.DataEndpoint, ops config.SchemaOperations) {
@@ -232,8 +445,9 @@ func addGraphQLRoutes(router *httprouter.Router, endpoint *endpoint.DataEndpoint
 			"error", err)
 	}
 
+	classifier := graphql.RateLimitClassifier(rootPath, singleKeyspace, false)
 	for _, route := range routes {
-		router.Handler(route.Method, route.Pattern, route.Handler)
+		router.Handler(route.Method, route.Pattern, maybeAddAuth(maybeAddRateLimit(route.Handler, classifier)))
 	}
 
 	if singleKeyspace != "" {
@@ -268,8 +482,9 @@ func addGraphQLRoutes(router *httprouter.Router, endpoint *endpoint.DataEndpoint
 		router.GET(playgroundPath, graphql.GetPlaygroundHandle(defaultEndpointUrl))
 	}
 
+	classifier = graphql.RateLimitClassifier(viper.GetString("graphql-schema-path"), singleKeyspace, true)
 	for _, route := range routes {
-		router.Handler(route.Method, route.Pattern, route.Handler)
+		router.Handler(route.Method, route.Pattern, maybeAddAuth(maybeAddRateLimit(route.Handler, classifier)))
 	}
 }
 
@@ -278,11 +493,192 @@ func addRESTRoutes(router *httprouter.Router, endpoint *endpoint.DataEndpoint, o
 	rootPath := viper.GetString("rest-path")
 	routes := endpoint.RoutesRest(rootPath, ops, singleKeyspace)
 
+	classifier := restEndpointV1.RateLimitClassifier(cfg.RouterInfo().UrlParams())
 	for _, route := range routes {
-		router.Handler(route.Method, route.Pattern, route.Handler)
+		router.Handler(route.Method, route.Pattern, maybeAddAuth(maybeAddRateLimit(route.Handler, classifier)))
+	}
+
+	if tokens != nil {
+		// The auth route validates the credentials itself, each attempt is rate limited as it opens a session
+		for _, route := range endpoint.RoutesRestAuth(rootPath, tokens) {
+			router.Handler(route.Method, route.Pattern, maybeAddRateLimit(route.Handler, classifier))
+		}
 	}
 }
 
+func isAuthEnabled() bool {
+	return viper.GetBool("jwt-enabled") || viper.GetString("api-keys-path") != "" ||
+		viper.GetString("api-keys-env") != "" || viper.GetString("api-keys-table") != "" ||
+		viper.GetBool("auth-tokens-enabled") || viper.GetString("tls-client-cert-role") != ""
+}
+
+func createAuthenticator(dbClient *db.Db) auth.Authenticator {
+	authenticators := make([]auth.Authenticator, 0)
+
+	if viper.GetBool("jwt-enabled") {
+		jwtAuthenticator, err := auth.NewJwtAuthenticator(auth.JwtOptions{
+			JwksPath:       viper.GetString("jwt-jwks-path"),
+			Secret:         viper.GetString("jwt-secret"),
+			PublicKeyPaths: getStringSlice("jwt-public-key-paths"),
+			Issuer:         viper.GetString("jwt-issuer"),
+			Audience:       viper.GetString("jwt-audience"),
+			RoleClaim:      viper.GetString("jwt-role-claim"),
+			Leeway:         viper.GetDuration("jwt-leeway"),
+		})
+		if err != nil {
+			logger.Fatal("unable to create jwt authenticator", "error", err)
+		}
+		authenticators = append(authenticators, jwtAuthenticator)
+	}
+
+	if keyAuthenticator := createApiKeyAuthenticator(dbClient); keyAuthenticator != nil {
+		authenticators = append(authenticators, keyAuthenticator)
+	}
+
+	if roleSource := viper.GetString("tls-client-cert-role"); roleSource != "" {
+		certAuthenticator, err := auth.NewCertificateAuthenticator(roleSource)
+		if err != nil {
+			logger.Fatal("unable to create client certificate authenticator", "error", err)
+		}
+		authenticators = append(authenticators, certAuthenticator)
+	}
+
+	if viper.GetBool("auth-tokens-enabled") {
+		ttl := viper.GetDuration("auth-token-ttl")
+		if ttl <= 0 {
+			ttl = auth.DefaultTokenTTL
+		}
+		tokens = auth.NewTokenStore(ttl)
+		authenticators = append(authenticators, tokens)
+	}
+
+	if len(authenticators) == 0 {
+		return nil
+	}
+
+	return auth.NewChainAuthenticator(authenticators...)
+}
+
+func createApiKeyAuthenticator(dbClient *db.Db) *auth.ApiKeyAuthenticator {
+	stores := make([]auth.ApiKeyStore, 0)
+
+	if keysPath := viper.GetString("api-keys-path"); keysPath != "" {
+		stores = append(stores, auth.NewFileApiKeyStore(keysPath))
+	}
+
+	if keysEnv := viper.GetString("api-keys-env"); keysEnv != "" {
+		stores = append(stores, auth.NewEnvApiKeyStore(keysEnv))
+	}
+
+	if keysTable := viper.GetString("api-keys-table"); keysTable != "" {
+		parts := strings.Split(keysTable, ".")
+		if len(parts) != 2 {
+			logger.Fatal("invalid api keys table, expected keyspace.table", "table", keysTable)
+		}
+		stores = append(stores, auth.ApiKeyStoreFunc(func() (map[string]string, error) {
+			return dbClient.ApiKeys(parts[0], parts[1])
+		}))
+	}
+
+	if len(stores) == 0 {
+		return nil
+	}
+
+	reloadInterval := viper.GetDuration("api-keys-reload-interval")
+	if reloadInterval <= 0 {
+		reloadInterval = auth.DefaultApiKeysReloadInterval
+	}
+
+	result, err := auth.NewApiKeyAuthenticator(reloadInterval, logger, stores...)
+	if err != nil {
+		logger.Fatal("unable to create api key authenticator", "error", err)
+	}
+
+	// The authenticator is stopped on shutdown
+	apiKeyAuthenticator = result
+	go result.Start()
+
+	return result
+}
+
+func loadPolicy() *auth.Policy {
+	policyPath := viper.GetString("policy-path")
+	if policyPath == "" {
+		return nil
+	}
+
+	policy, err := auth.LoadPolicy(policyPath)
+	if err != nil {
+		logger.Fatal("unable to load policy", "path", policyPath, "error", err)
+	}
+
+	return policy
+}
+
+func maybeAddAuth(handler http.Handler) http.Handler {
+	if authenticator != nil {
+		return auth.NewAuthHandler(handler, authenticator, logger)
+	}
+	return handler
+}
+
+func createLimiter() *ratelimit.Limiter {
+	options := ratelimit.Options{
+		Read:   ratelimit.Limit{Rate: viper.GetFloat64("rate-limit-reads"), Burst: viper.GetInt("rate-limit-reads-burst")},
+		Write:  ratelimit.Limit{Rate: viper.GetFloat64("rate-limit-writes"), Burst: viper.GetInt("rate-limit-writes-burst")},
+		Schema: ratelimit.Limit{Rate: viper.GetFloat64("rate-limit-schema"), Burst: viper.GetInt("rate-limit-schema-burst")},
+		KeyBy:  getStringSlice("rate-limit-key"),
+	}
+
+	if options.Read.Rate == 0 && options.Write.Rate == 0 && options.Schema.Rate == 0 {
+		return nil
+	}
+
+	result, err := ratelimit.NewLimiter(options)
+	if err != nil {
+		logger.Fatal("invalid rate limit settings", "error", err)
+	}
+
+	return result
+}
+
+func maybeAddRateLimit(handler http.Handler, classifier ratelimit.Classifier) http.Handler {
+	if limiter != nil {
+		return ratelimit.NewHandler(handler, limiter, classifier, logger)
+	}
+	return handler
+}
+
+func createAuditor() *audit.Auditor {
+	sinks := make([]audit.Sink, 0)
+
+	if viper.GetBool("audit-stdout") {
+		sinks = append(sinks, audit.NewWriterSink(os.Stdout))
+	}
+
+	if auditPath := viper.GetString("audit-path"); auditPath != "" {
+		fileSink, err := audit.NewFileSink(auditPath)
+		if err != nil {
+			logger.Fatal("unable to open audit file", "path", auditPath, "error", err)
+		}
+		sinks = append(sinks, fileSink)
+	}
+
+	if len(sinks) == 0 && viper.GetString("audit-table") == "" {
+		return nil
+	}
+
+	// The table sink is added once the database client is created
+	return audit.NewAuditor(logger, sinks...)
+}
+
+func maybeAddAudit(handler http.Handler) http.Handler {
+	if auditor != nil {
+		return audit.NewHandler(handler)
+	}
+	return handler
+}
+
 func maybeAddRequestLogging(handler http.Handler) http.Handler {
 	if viper.GetBool("request-logging") {
 		handler = log.NewLoggingHandler(handler, logger)
@@ -331,8 +727,18 @@ func listenAndServe(handler http.Handler, port int, endpointNames string) {
 	logger.Info("server listening",
 		"port", port,
 		"type", endpointNames)
-	handler = maybeAddCORS(maybeAddRequestLogging(handler))
-	err := http.ListenAndServe(fmt.Sprintf(":%d", port), handler)
+	handler = maybeAddCORS(maybeAddRequestLogging(maybeAddAudit(handler)))
+	addr := fmt.Sprintf(":%d", port)
+
+	var err error
+	if serverTls != nil {
+		server := &http.Server{Addr: addr, Handler: handler, TLSConfig: serverTls.Config()}
+		// Certificates are already loaded in the tls config
+		err = server.ListenAndServeTLS("", "")
+	} else {
+		err = http.ListenAndServe(addr, handler)
+	}
+
 	if err != nil {
 		logger.Fatal("unable to start server",
 			"port", port,
@@ -2,6 +2,8 @@ package config
 
 import (
 	"fmt"
+	"github.com/datastax/cassandra-data-apis/audit"
+	"github.com/datastax/cassandra-data-apis/auth"
 	"github.com/datastax/cassandra-data-apis/log"
 	"github.com/gocql/gocql"
 	"github.com/julienschmidt/httprouter"
@@ -22,6 +24,58 @@ type Config interface {
 	UseUserOrRoleAuth() bool
 	Logger() log.Logger
 	RouterInfo() HttpRouterInfo
+	Policy() *auth.Policy
+	UseRoleSchemas() bool
+	RoleSchemaCacheSize() int
+	GraphQLLimits() GraphQLLimits
+	Auditor() *audit.Auditor
+	AllowFiltering() AllowFilteringSettings
+}
+
+// GraphQLLimits restricts the cost of the GraphQL queries, the queries exceeding any of the limits are rejected before
+// being executed. A zero value disables the limit.
+type GraphQLLimits struct {
+	// MaxDepth is the maximum nesting of selection sets
+	MaxDepth int
+	// MaxAliases is the maximum number of aliased fields
+	MaxAliases int
+	// MaxPageSize is the maximum number of rows that a single query field can request using pageSize or limit
+	MaxPageSize int
+	// MaxTotalPageSize is the maximum number of rows requested by all the query fields
+	MaxTotalPageSize int
+}
+
+// AllowFilteringSettings determines the tables that accept queries using ALLOW FILTERING, which are rejected by
+// default. The queries must still restrict the partition key or use a limit no greater than MaxLimit.
+type AllowFilteringSettings struct {
+	// AllTables allows filtering on all the tables
+	AllTables bool
+	// Tables contains the tables that allow filtering, using the format keyspace.table
+	Tables []string
+	// MaxLimit is the maximum limit of the queries that don't restrict the partition key, when not set
+	// DefaultAllowFilteringMaxLimit is used
+	MaxLimit int
+}
+
+// Limit returns the maximum limit of the queries that don't restrict the partition key
+func (s AllowFilteringSettings) Limit() int {
+	if s.MaxLimit <= 0 {
+		return DefaultAllowFilteringMaxLimit
+	}
+	return s.MaxLimit
+}
+
+// IsAllowed returns whether the queries on the table can use ALLOW FILTERING
+func (s AllowFilteringSettings) IsAllowed(keyspace string, table string) bool {
+	if s.AllTables {
+		return true
+	}
+	for _, name := range s.Tables {
+		if name == keyspace+"."+table {
+			return true
+		}
+	}
+	return false
 }
 
 type UrlParamGetter func(*http.Request, string) string
@@ -39,6 +93,7 @@ const (
 	DefaultPageSize               = 100
 	DefaultConsistencyLevel       = gocql.LocalQuorum
 	DefaultSerialConsistencyLevel = gocql.Serial
+	DefaultAllowFilteringMaxLimit = 1000
 )
 
 const (
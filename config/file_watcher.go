@@ -0,0 +1,102 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"github.com/datastax/cassandra-data-apis/log"
+	"io/ioutil"
+	"time"
+)
+
+const DefaultFilesReloadInterval = 10 * time.Second
+
+// FileWatcher periodically checks the contents of a set of files, like certificates and keys, and invokes a callback
+// when any of them changes. Comparing the contents allows detecting files that are replaced using symbolic links,
+// as done for mounted secrets.
+type FileWatcher struct {
+	ctx      context.Context
+	cancel   context.CancelFunc
+	paths    []string
+	hashes   map[string][sha256.Size]byte
+	interval time.Duration
+	onChange func() error
+	logger   log.Logger
+}
+
+// NewFileWatcher creates a FileWatcher using the current contents of the files as baseline
+func NewFileWatcher(interval time.Duration, logger log.Logger, onChange func() error, paths ...string) *FileWatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &FileWatcher{
+		ctx:      ctx,
+		cancel:   cancel,
+		paths:    paths,
+		interval: interval,
+		onChange: onChange,
+		logger:   logger,
+	}
+	w.hashes, _ = w.readHashes()
+	return w
+}
+
+// Check invokes the callback when the contents of any of the files changed since the last successful check. When the
+// callback fails, it's invoked again on the next check.
+func (w *FileWatcher) Check() (bool, error) {
+	hashes, err := w.readHashes()
+	if err != nil {
+		// Files might be in the process of being replaced
+		return false, err
+	}
+
+	changed := false
+	for filePath, hash := range hashes {
+		if w.hashes[filePath] != hash {
+			changed = true
+			break
+		}
+	}
+
+	if !changed {
+		return false, nil
+	}
+
+	if err := w.onChange(); err != nil {
+		return false, err
+	}
+
+	w.hashes = hashes
+	return true, nil
+}
+
+// Start checks the files periodically until stopped
+func (w *FileWatcher) Start() {
+	for {
+		select {
+		case <-time.After(w.interval):
+			changed, err := w.Check()
+			if err != nil {
+				w.logger.Error("unable to reload files", "paths", w.paths, "error", err)
+			} else if changed {
+				w.logger.Info("files reloaded", "paths", w.paths)
+			}
+		case <-w.ctx.Done():
+			return
+		}
+	}
+}
+
+// Stop stops checking the files, it can be called before Start or more than once
+func (w *FileWatcher) Stop() {
+	w.cancel()
+}
+
+func (w *FileWatcher) readHashes() (map[string][sha256.Size]byte, error) {
+	hashes := make(map[string][sha256.Size]byte, len(w.paths))
+	for _, filePath := range w.paths {
+		data, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+		hashes[filePath] = sha256.Sum256(data)
+	}
+	return hashes, nil
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"errors"
+	"github.com/datastax/cassandra-data-apis/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestFileWatcher(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	certPath := path.Join(dir, "cert.pem")
+	keyPath := path.Join(dir, "key.pem")
+	require.NoError(t, ioutil.WriteFile(certPath, []byte("cert1"), 0600))
+	require.NoError(t, ioutil.WriteFile(keyPath, []byte("key1"), 0600))
+
+	calls := 0
+	var reloadErr error
+	watcher := NewFileWatcher(time.Second, log.NewZapLogger(zap.NewNop()), func() error {
+		calls++
+		return reloadErr
+	}, certPath, keyPath)
+
+	changed, err := watcher.Check()
+	assert.NoError(t, err)
+	assert.False(t, changed)
+
+	require.NoError(t, ioutil.WriteFile(certPath, []byte("cert2"), 0600))
+	reloadErr = errors.New("key does not match")
+	_, err = watcher.Check()
+	assert.EqualError(t, err, "key does not match")
+
+	// Retried until it succeeds
+	require.NoError(t, ioutil.WriteFile(keyPath, []byte("key2"), 0600))
+	reloadErr = nil
+	changed, err = watcher.Check()
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, 2, calls)
+
+	changed, err = watcher.Check()
+	assert.NoError(t, err)
+	assert.False(t, changed)
+
+	require.NoError(t, os.Remove(keyPath))
+	_, err = watcher.Check()
+	assert.Error(t, err)
+	assert.Equal(t, 2, calls)
+}
+
+func TestFileWatcher_Stop(t *testing.T) {
+	watcher := NewFileWatcher(time.Millisecond, log.NewZapLogger(zap.NewNop()), func() error { return nil })
+
+	// Stopping before starting doesn't panic and the checks stop as soon as they start
+	watcher.Stop()
+	stopped := make(chan bool)
+	go func() {
+		watcher.Start()
+		stopped <- true
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		assert.Fail(t, "expected the watcher to be stopped")
+	}
+	watcher.Stop()
+}
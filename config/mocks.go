@@ -1,6 +1,8 @@
 package config
 
 import (
+	"github.com/datastax/cassandra-data-apis/audit"
+	"github.com/datastax/cassandra-data-apis/auth"
 	"github.com/datastax/cassandra-data-apis/log"
 	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap"
@@ -21,6 +23,12 @@ func (o *ConfigMock) Default() *ConfigMock {
 	o.On("Naming").Return(NamingConventionFn(NewDefaultNaming))
 	o.On("UseUserOrRoleAuth").Return(false)
 	o.On("Logger").Return(log.NewZapLogger(zap.NewExample()))
+	o.On("Policy").Return((*auth.Policy)(nil))
+	o.On("UseRoleSchemas").Return(false)
+	o.On("RoleSchemaCacheSize").Return(100)
+	o.On("GraphQLLimits").Return(GraphQLLimits{})
+	o.On("Auditor").Return((*audit.Auditor)(nil))
+	o.On("AllowFiltering").Return(AllowFilteringSettings{})
 	return o
 }
 
@@ -54,6 +62,36 @@ func (o *ConfigMock) RouterInfo() HttpRouterInfo {
 	return args.Get(0).(HttpRouterInfo)
 }
 
+func (o *ConfigMock) Policy() *auth.Policy {
+	args := o.Called()
+	return args.Get(0).(*auth.Policy)
+}
+
+func (o *ConfigMock) UseRoleSchemas() bool {
+	args := o.Called()
+	return args.Get(0).(bool)
+}
+
+func (o *ConfigMock) RoleSchemaCacheSize() int {
+	args := o.Called()
+	return args.Get(0).(int)
+}
+
+func (o *ConfigMock) GraphQLLimits() GraphQLLimits {
+	args := o.Called()
+	return args.Get(0).(GraphQLLimits)
+}
+
+func (o *ConfigMock) Auditor() *audit.Auditor {
+	args := o.Called()
+	return args.Get(0).(*audit.Auditor)
+}
+
+func (o *ConfigMock) AllowFiltering() AllowFilteringSettings {
+	args := o.Called()
+	return args.Get(0).(AllowFilteringSettings)
+}
+
 type KeyspaceNamingInfoMock struct {
 	mock.Mock
 }
@@ -0,0 +1,353 @@
+package db
+
+import (
+	"fmt"
+	"github.com/gocql/gocql"
+	"gopkg.in/inf.v0"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// summableTypes are the CQL types of the columns whose sums and averages can be merged across token ranges
+var summableTypes = map[gocql.Type]bool{
+	gocql.TypeInt:      true,
+	gocql.TypeTinyInt:  true,
+	gocql.TypeSmallInt: true,
+	gocql.TypeBigInt:   true,
+	gocql.TypeCounter:  true,
+	gocql.TypeFloat:    true,
+	gocql.TypeDouble:   true,
+	gocql.TypeDecimal:  true,
+	gocql.TypeVarint:   true,
+}
+
+// comparableTypes are the CQL types of the columns whose minimums and maximums can be merged across token ranges, the
+// values of the rest of the types (e.g. uuids) are not ordered the same way by the server and the client
+var comparableTypes = map[gocql.Type]bool{
+	gocql.TypeText:      true,
+	gocql.TypeVarchar:   true,
+	gocql.TypeAscii:     true,
+	gocql.TypeTimestamp: true,
+	gocql.TypeTime:      true,
+}
+
+func init() {
+	for t := range summableTypes {
+		comparableTypes[t] = true
+	}
+}
+
+// CanScanAggregates determines whether the results of the aggregates over the token ranges can be merged using
+// ScanAggregates
+func CanScanAggregates(table *gocql.TableMetadata, aggregates []Aggregate) bool {
+	for _, aggregate := range aggregates {
+		if aggregate.Column == "" {
+			continue
+		}
+
+		column, ok := table.Columns[aggregate.Column]
+		if !ok {
+			return false
+		}
+
+		switch aggregate.Function {
+		case AggregateCount:
+		case AggregateMin, AggregateMax:
+			if !comparableTypes[column.Type.Type()] {
+				return false
+			}
+		case AggregateSum, AggregateAvg:
+			if !summableTypes[column.Type.Type()] {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// ScanAggregates computes the aggregates over all the rows of the table by querying the token ranges of the ring
+// concurrently, using up to concurrency queries in flight. The counts and sums of the ranges are added, the minimums
+// and maximums are compared and the averages are computed from the sums and counts. The result contains the values of
+// the aggregates by alias, like the rows of an aggregate select.
+func (db *Db) ScanAggregates(
+	keyspace string,
+	table string,
+	aggregates []Aggregate,
+	options *QueryOptions,
+	concurrency int,
+) (map[string]interface{}, error) {
+	// Averages are computed from the sum and the count of the values of each range
+	partials := make([]Aggregate, 0, len(aggregates))
+	for i, aggregate := range aggregates {
+		alias := fmt.Sprintf("partial%d", i)
+		if aggregate.Function != AggregateAvg {
+			partials = append(partials, Aggregate{Function: aggregate.Function, Column: aggregate.Column, Alias: alias})
+			continue
+		}
+		partials = append(partials,
+			Aggregate{Function: AggregateSum, Column: aggregate.Column, Alias: alias},
+			Aggregate{Function: AggregateCount, Column: aggregate.Column, Alias: alias + "_count"})
+	}
+
+	tableMetadata, err := db.Table(keyspace, table)
+	if err != nil {
+		return nil, err
+	}
+
+	var mutex sync.Mutex
+	merged := make(map[string]interface{}, len(partials))
+	err = db.scanTokenRanges(keyspace, table, concurrency,
+		func(_ *gocql.TableMetadata, tokenRange TokenRange) error {
+			condition, conditionValues := tokenCondition(tableMetadata, tokenRange)
+			statement, err := selectStatement(&SelectInfo{
+				Keyspace:   keyspace,
+				Table:      table,
+				Aggregates: partials,
+			}, condition, conditionValues...)
+			if err != nil {
+				return err
+			}
+
+			rs, err := db.session.ExecuteIter(statement.Query, scanOptions(options, nil), statement.Values...)
+			if err != nil {
+				return err
+			}
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			for _, row := range rs.Values() {
+				for _, partial := range partials {
+					var columnType gocql.Type
+					if partial.Column != "" && partial.Function != AggregateCount {
+						columnType = tableMetadata.Columns[partial.Column].Type.Type()
+					}
+					value, err := mergeAggregate(partial.Function, columnType, merged[partial.Alias], row[partial.Alias])
+					if err != nil {
+						return err
+					}
+					merged[partial.Alias] = value
+				}
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]interface{}, len(aggregates))
+	for i, aggregate := range aggregates {
+		alias := fmt.Sprintf("partial%d", i)
+		value := merged[alias]
+		if aggregate.Function == AggregateAvg {
+			value, err = averageValue(tableMetadata.Columns[aggregate.Column].Type.Type(), value, merged[alias+"_count"])
+			if err != nil {
+				return nil, err
+			}
+		}
+		result[aggregate.Alias] = value
+	}
+
+	return result, nil
+}
+
+// mergeAggregate returns the result of the aggregate function over two token ranges
+func mergeAggregate(function AggregateFunction, columnType gocql.Type, a, b interface{}) (interface{}, error) {
+	if isNullValue(a) {
+		return b, nil
+	}
+	if isNullValue(b) {
+		return a, nil
+	}
+
+	switch function {
+	case AggregateCount:
+		return addValues(gocql.TypeBigInt, a, b)
+	case AggregateSum:
+		return addValues(columnType, a, b)
+	case AggregateMin, AggregateMax:
+		result, err := compareValues(columnType, a, b)
+		if err != nil {
+			return nil, err
+		}
+		if (function == AggregateMin) == (result <= 0) {
+			return a, nil
+		}
+		return b, nil
+	}
+
+	return nil, fmt.Errorf("aggregate function %s can not be merged", function)
+}
+
+// addValues returns the sum of two non-null values of the type
+func addValues(columnType gocql.Type, a, b interface{}) (interface{}, error) {
+	switch x := a.(type) {
+	case *int:
+		value := *x + *b.(*int)
+		return &value, nil
+	case *int16:
+		value := *x + *b.(*int16)
+		return &value, nil
+	case *int8:
+		value := *x + *b.(*int8)
+		return &value, nil
+	case *float32:
+		value := *x + *b.(*float32)
+		return &value, nil
+	case *float64:
+		value := *x + *b.(*float64)
+		return &value, nil
+	case *inf.Dec:
+		return new(inf.Dec).Add(x, b.(*inf.Dec)), nil
+	case *big.Int:
+		return new(big.Int).Add(x, b.(*big.Int)), nil
+	case *string:
+		if columnType == gocql.TypeBigInt || columnType == gocql.TypeCounter {
+			first, second, err := parseBigInts(x, b.(*string))
+			if err != nil {
+				return nil, err
+			}
+			value := strconv.FormatInt(first+second, 10)
+			return &value, nil
+		}
+	}
+
+	return nil, fmt.Errorf("values of type %T can not be added", a)
+}
+
+// compareValues returns -1, 0 or +1 depending on whether the first non-null value is less than, equal to or greater
+// than the second one
+func compareValues(columnType gocql.Type, a, b interface{}) (int, error) {
+	switch x := a.(type) {
+	case *int:
+		return compareInts(int64(*x), int64(*b.(*int))), nil
+	case *int16:
+		return compareInts(int64(*x), int64(*b.(*int16))), nil
+	case *int8:
+		return compareInts(int64(*x), int64(*b.(*int8))), nil
+	case *float32:
+		return compareFloats(float64(*x), float64(*b.(*float32))), nil
+	case *float64:
+		return compareFloats(*x, *b.(*float64)), nil
+	case *inf.Dec:
+		return x.Cmp(b.(*inf.Dec)), nil
+	case *big.Int:
+		return x.Cmp(b.(*big.Int)), nil
+	case *time.Time:
+		if y := b.(*time.Time); !x.Equal(*y) {
+			if x.Before(*y) {
+				return -1, nil
+			}
+			return 1, nil
+		}
+		return 0, nil
+	case *time.Duration:
+		return compareInts(int64(*x), int64(*b.(*time.Duration))), nil
+	case *string:
+		if columnType == gocql.TypeBigInt || columnType == gocql.TypeCounter {
+			first, second, err := parseBigInts(x, b.(*string))
+			if err != nil {
+				return 0, err
+			}
+			return compareInts(first, second), nil
+		}
+		if comparableTypes[columnType] {
+			// Text is compared using the bytes of its UTF-8 representation, like the server does
+			return strings.Compare(*x, *b.(*string)), nil
+		}
+	}
+
+	return 0, fmt.Errorf("values of type %T can not be compared", a)
+}
+
+// averageValue returns the average of the values using their sum and count, using the same arithmetic as the server:
+// the averages of integer types are truncated and the averages of decimals use the scale of the sum
+func averageValue(columnType gocql.Type, sum interface{}, count interface{}) (interface{}, error) {
+	if isNullValue(sum) || isNullValue(count) {
+		return sum, nil
+	}
+
+	n, err := strconv.ParseInt(*count.(*string), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		// The sum is zero when there are no values
+		return sum, nil
+	}
+
+	switch x := sum.(type) {
+	case *int:
+		value := int(int64(*x) / n)
+		return &value, nil
+	case *int16:
+		value := int16(int64(*x) / n)
+		return &value, nil
+	case *int8:
+		value := int8(int64(*x) / n)
+		return &value, nil
+	case *float32:
+		value := *x / float32(n)
+		return &value, nil
+	case *float64:
+		value := *x / float64(n)
+		return &value, nil
+	case *inf.Dec:
+		return new(inf.Dec).QuoRound(x, inf.NewDec(n, 0), x.Scale(), inf.RoundHalfEven), nil
+	case *big.Int:
+		return new(big.Int).Quo(x, big.NewInt(n)), nil
+	case *string:
+		if columnType == gocql.TypeBigInt || columnType == gocql.TypeCounter {
+			total, err := strconv.ParseInt(*x, 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			value := strconv.FormatInt(total/n, 10)
+			return &value, nil
+		}
+	}
+
+	return nil, fmt.Errorf("the average of values of type %T can not be computed", sum)
+}
+
+func isNullValue(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	rv := reflect.ValueOf(value)
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
+}
+
+func parseBigInts(a, b *string) (int64, int64, error) {
+	first, err := strconv.ParseInt(*a, 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	second, err := strconv.ParseInt(*b, 10, 64)
+	return first, second, err
+}
+
+func compareInts(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
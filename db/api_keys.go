@@ -0,0 +1,30 @@
+package db
+
+import "fmt"
+
+// ApiKeys reads all the API keys from a table containing "key" and "role" text columns
+func (db *Db) ApiKeys(keyspace string, table string) (map[string]string, error) {
+	query := fmt.Sprintf(`SELECT key, role FROM "%s"."%s"`, keyspace, table)
+	keys := make(map[string]string)
+	var pageState []byte
+
+	for {
+		rs, err := db.session.ExecuteIter(query, NewQueryOptions().WithPageSize(1000).WithPageState(pageState))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, row := range rs.Values() {
+			key, _ := row["key"].(*string)
+			role, _ := row["role"].(*string)
+			if key != nil && role != nil && *key != "" && *role != "" {
+				keys[*key] = *role
+			}
+		}
+
+		pageState = rs.PageState()
+		if len(pageState) == 0 {
+			return keys, nil
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/datastax/cassandra-data-apis/audit"
+	"github.com/datastax/cassandra-data-apis/config"
+	"github.com/gocql/gocql"
+)
+
+// AuditSink creates a sink that inserts the audit events in a table with the following schema:
+//
+//	CREATE TABLE <keyspace>.<table> (
+//	  day text, id timeuuid, user_or_role text, client_ip text, source text, operation text,
+//	  keyspace_name text, table_name text, primary_key text, applied boolean, error text,
+//	  PRIMARY KEY (day, id))
+//
+// The primary key of the modified row is stored as JSON text.
+func (db *Db) AuditSink(keyspace string, table string) audit.Sink {
+	query := fmt.Sprintf(`INSERT INTO "%s"."%s" (day, id, user_or_role, client_ip, source, operation, `+
+		`keyspace_name, table_name, primary_key, applied, error) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		keyspace, table)
+
+	return audit.SinkFunc(func(event audit.Event) error {
+		primaryKey := ""
+		if len(event.PrimaryKey) > 0 {
+			data, err := json.Marshal(event.PrimaryKey)
+			if err != nil {
+				return err
+			}
+			primaryKey = string(data)
+		}
+
+		return db.session.Execute(query, NewQueryOptions().WithConsistency(config.DefaultConsistencyLevel),
+			event.Time.Format("2006-01-02"), gocql.UUIDFromTime(event.Time), event.UserOrRole, event.ClientIp,
+			event.Source, event.Operation, event.Keyspace, event.Table, primaryKey, event.Applied, event.Error)
+	})
+}
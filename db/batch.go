@@ -0,0 +1,14 @@
+package db
+
+import "github.com/gocql/gocql"
+
+// Statement is a CQL query and its parameters
+type Statement struct {
+	Query  string
+	Values []interface{}
+}
+
+// ExecuteBatch executes the statements atomically as a single CQL BATCH
+func (db *Db) ExecuteBatch(batchType gocql.BatchType, statements []Statement, options *QueryOptions) error {
+	return db.session.ExecuteBatch(batchType, statements, options)
+}
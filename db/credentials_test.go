@@ -0,0 +1,43 @@
+package db
+
+import (
+	"errors"
+	"github.com/gocql/gocql"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type requestErrorMock struct {
+	code    int
+	message string
+}
+
+func (e requestErrorMock) Code() int {
+	return e.code
+}
+
+func (e requestErrorMock) Message() string {
+	return e.message
+}
+
+func (e requestErrorMock) Error() string {
+	return e.message
+}
+
+var _ = Describe("credentialsObserver", func() {
+	It("Should only record the authentication failures", func() {
+		observer := &credentialsObserver{}
+		observer.ObserveConnect(gocql.ObservedConnect{Err: errors.New("connection refused")})
+		observer.ObserveConnect(gocql.ObservedConnect{Err: requestErrorMock{0x1001, "overloaded"}})
+		Expect(observer.error()).To(BeNil())
+
+		observer.ObserveConnect(gocql.ObservedConnect{Err: requestErrorMock{0x0100, "password is incorrect"}})
+		Expect(observer.error()).To(BeAssignableToTypeOf(&InvalidCredentials{}))
+		Expect(observer.error()).To(MatchError("password is incorrect"))
+	})
+
+	It("Should reject empty credentials", func() {
+		db := &Db{hosts: []string{"127.0.0.1"}}
+		Expect(db.ValidateCredentials("user1", "")).To(BeAssignableToTypeOf(&InvalidCredentials{}))
+	})
+})
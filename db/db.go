@@ -1,10 +1,12 @@
 package db
 
 import (
+	"errors"
 	"fmt"
 	"github.com/datastax/cassandra-data-apis/config"
 	e "github.com/datastax/cassandra-data-apis/errors"
 	"github.com/gocql/gocql"
+	"sync"
 	"time"
 )
 
@@ -25,6 +27,13 @@ func ifExistsStr(ifExists bool) string {
 // Db represents a connection to a db
 type Db struct {
 	session Session
+	mutex   sync.RWMutex
+	config  Config
+	hosts   []string
+
+	// The keyspace metadata which column types have been resolved, by keyspace name
+	resolvedKeyspaces map[string]*gocql.KeyspaceMetadata
+	columnTypesMutex  sync.Mutex
 }
 
 type SslOptions struct {
@@ -42,9 +51,28 @@ type Config struct {
 
 // NewDb Gets a pointer to a db
 func NewDb(config Config, hosts ...string) (*Db, error) {
+	cluster := newCluster(config, hosts...)
+
+	var (
+		session *gocql.Session
+		err     error
+	)
+
+	if session, err = cluster.CreateSession(); err != nil {
+		return nil, err
+	}
+
+	// The session can be replaced when the certificates or credentials change
+	db := NewDbWithSession(newReloadableSession(newGoCqlSession(session, cluster), session.Close))
+	db.config = config
+	db.hosts = hosts
+	return db, nil
+}
+
+func newCluster(config Config, hosts ...string) *gocql.ClusterConfig {
 	cluster := gocql.NewCluster(hosts...)
 	cluster.PoolConfig = gocql.PoolConfig{
-		HostSelectionPolicy: NewDefaultHostSelectionPolicy(),
+		HostSelectionPolicy: newTokenRingPolicy(NewDefaultHostSelectionPolicy()),
 	}
 
 	// Match DataStax drivers settings
@@ -67,15 +95,71 @@ func NewDb(config Config, hosts ...string) (*Db, error) {
 		}
 	}
 
-	var (
-		session *gocql.Session
-		err     error
-	)
+	return cluster
+}
 
-	if session, err = cluster.CreateSession(); err != nil {
-		return nil, err
+// ValidateCredentials checks the username and password against the cluster by opening a short-lived session with
+// the same settings used by the db
+func (db *Db) ValidateCredentials(username string, password string) error {
+	if len(db.hosts) == 0 {
+		return errors.New("credentials validation is not supported by the db")
+	}
+
+	if username == "" || password == "" {
+		return &InvalidCredentials{"username and password are required"}
+	}
+
+	db.mutex.RLock()
+	config := db.config
+	db.mutex.RUnlock()
+	config.Username = username
+	config.Password = password
+	cluster := newCluster(config, db.hosts...)
+	// The session is only used to authenticate
+	cluster.NumConns = 1
+	cluster.DisableInitialHostLookup = true
+	cluster.Events.DisableNodeStatusEvents = true
+	cluster.Events.DisableTopologyEvents = true
+	cluster.Events.DisableSchemaEvents = true
+	// The session error doesn't retain the type of the connection errors
+	observer := &credentialsObserver{}
+	cluster.ConnectObserver = observer
+
+	session, err := cluster.CreateSession()
+	if err != nil {
+		if credentialsErr := observer.error(); credentialsErr != nil {
+			return credentialsErr
+		}
+		return err
+	}
+	session.Close()
+	return nil
+}
+
+// errCodeCredentials is the protocol error code of the authentication failures
+const errCodeCredentials = 0x0100
+
+// credentialsObserver records the authentication failures of the connections
+type credentialsObserver struct {
+	mutex sync.Mutex
+	err   *InvalidCredentials
+}
+
+func (o *credentialsObserver) ObserveConnect(connect gocql.ObservedConnect) {
+	if requestErr, ok := connect.Err.(gocql.RequestError); ok && requestErr.Code() == errCodeCredentials {
+		o.mutex.Lock()
+		o.err = &InvalidCredentials{requestErr.Message()}
+		o.mutex.Unlock()
 	}
-	return NewDbWithSession(&GoCqlSession{ref: session}), nil
+}
+
+func (o *credentialsObserver) error() error {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	if o.err == nil {
+		return nil
+	}
+	return o.err
 }
 
 func NewDbWithSession(session Session) *Db {
@@ -96,6 +180,12 @@ func (db *Db) Keyspace(keyspace string) (*gocql.KeyspaceMetadata, error) {
 		return nil, &DbObjectNotFound{"keyspace", keyspace}
 	}
 
+	if err == nil {
+		if err = db.resolveColumnTypes(ks); err != nil {
+			return nil, err
+		}
+	}
+
 	return ks, err
 }
 
@@ -220,6 +310,15 @@ func (db *Db) DescribeTables(keyspace, username string) ([]string, error) {
 	return tables, nil
 }
 
+// InvalidCredentials is returned when the cluster rejects the username and password
+type InvalidCredentials struct {
+	message string
+}
+
+func (e *InvalidCredentials) Error() string {
+	return e.message
+}
+
 type DbObjectNotFound struct {
 	objectType string
 	keyspace   string
@@ -20,6 +20,16 @@ func (o *SessionMock) ExecuteIter(query string, options *QueryOptions, values ..
 	return args.Get(0).(ResultSet), args.Error(1)
 }
 
+func (o *SessionMock) ExecuteBatch(batchType gocql.BatchType, statements []Statement, options *QueryOptions) error {
+	args := o.Called(batchType, statements, options)
+	return args.Error(0)
+}
+
+func (o *SessionMock) TokenRanges() ([]TokenRange, error) {
+	args := o.Called()
+	return args.Get(0).([]TokenRange), args.Error(1)
+}
+
 func (o *SessionMock) ChangeSchema(query string, options *QueryOptions) error {
 	args := o.Called(query, options)
 	return args.Error(0)
@@ -137,6 +147,86 @@ func (o *SessionMock) AddViews(views []string) *mock.Call {
 		Return(viewsResultMock, nil)
 }
 
+// UserTypeMock contains the definition of a user-defined type, as represented in the schema tables
+type UserTypeMock struct {
+	Name       string
+	FieldNames []string
+	FieldTypes []string
+}
+
+func (o *SessionMock) AddUserTypes(userTypes []UserTypeMock) *mock.Call {
+	values := make([]map[string]interface{}, 0, len(userTypes))
+	for _, userType := range userTypes {
+		userType := userType
+		values = append(values, map[string]interface{}{
+			"type_name":   &userType.Name,
+			"field_names": &userType.FieldNames,
+			"field_types": &userType.FieldTypes,
+		})
+	}
+	userTypesResultMock := &ResultMock{}
+	userTypesResultMock.
+		On("Values").Return(values, nil)
+
+	return o.On("ExecuteIter", userTypesQuery, mock.Anything, mock.Anything).
+		Return(userTypesResultMock, nil)
+}
+
+// IndexMock contains the definition of an index, as represented in the schema tables
+type IndexMock struct {
+	Table   string
+	Name    string
+	Options map[string]string
+}
+
+func (o *SessionMock) AddIndexes(indexes []IndexMock) *mock.Call {
+	values := make([]map[string]interface{}, 0, len(indexes))
+	for _, index := range indexes {
+		index := index
+		values = append(values, map[string]interface{}{
+			"table_name": &index.Table,
+			"index_name": &index.Name,
+			"options":    &index.Options,
+		})
+	}
+	indexesResultMock := &ResultMock{}
+	indexesResultMock.
+		On("Values").Return(values, nil)
+
+	return o.On("ExecuteIter", vectorIndexesQuery, mock.Anything, mock.Anything).
+		Return(indexesResultMock, nil)
+}
+
+func (o *SessionMock) AddRolePermissions(
+	role string,
+	superuser bool,
+	memberOf []string,
+	permissions map[string][]string,
+) *SessionMock {
+	roleResultMock := &ResultMock{}
+	roleResultMock.
+		On("Values").Return([]map[string]interface{}{
+		{"is_superuser": &superuser, "member_of": &memberOf},
+	}, nil)
+	o.On("ExecuteIter", "SELECT is_superuser, member_of FROM system_auth.roles WHERE role = ?",
+		mock.Anything, []interface{}{role}).
+		Return(roleResultMock, nil)
+
+	values := make([]map[string]interface{}, 0, len(permissions))
+	for resource, resourcePermissions := range permissions {
+		resource, resourcePermissions := resource, resourcePermissions
+		values = append(values, map[string]interface{}{"resource": &resource, "permissions": &resourcePermissions})
+	}
+	permissionsResultMock := &ResultMock{}
+	permissionsResultMock.
+		On("Values").Return(values, nil)
+	o.On("ExecuteIter", "SELECT resource, permissions FROM system_auth.role_permissions WHERE role = ?",
+		mock.Anything, []interface{}{role}).
+		Return(permissionsResultMock, nil)
+
+	return o
+}
+
 func (o *SessionMock) AddKeyspace(keyspace *gocql.KeyspaceMetadata) *mock.Call {
 	return o.On("KeyspaceMetadata", keyspace.Name).Return(keyspace, nil)
 }
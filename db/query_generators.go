@@ -5,15 +5,75 @@ import (
 	"fmt"
 	"github.com/datastax/cassandra-data-apis/types"
 	"github.com/gocql/gocql"
+	"strings"
 )
 
 type SelectInfo struct {
-	Keyspace string
-	Table    string
-	Columns  []string
-	Where    []types.ConditionItem
-	Options  *types.QueryOptions
-	OrderBy  []ColumnOrder
+	Keyspace   string
+	Table      string
+	Columns    []string
+	Aggregates []Aggregate
+	GroupBy    []string
+	Where      []types.ConditionItem
+	Options    *types.QueryOptions
+	OrderBy    []ColumnOrder
+	// AnnOf orders the rows by similarity to a vector, it can't be combined with OrderBy
+	AnnOf *AnnOrder
+	// WriteTime contains the columns to select the write time of, named using the WriteTimePrefix
+	WriteTime []string
+	// TTL contains the columns to select the remaining time-to-live of, named using the TTLPrefix
+	TTL []string
+	// Similarity is the similarity function used to select the score of the rows ordered by similarity, named using
+	// the SimilarityColumn. The score is not selected when empty.
+	Similarity string
+	// AllowFiltering appends ALLOW FILTERING to the statement, it should be validated using ValidateAllowFiltering
+	AllowFiltering bool
+}
+
+const (
+	// WriteTimePrefix is the prefix of the name of the write time of a column in the rows returned by Select
+	WriteTimePrefix = "_writetime_"
+	// TTLPrefix is the prefix of the name of the time-to-live of a column in the rows returned by Select
+	TTLPrefix = "_ttl_"
+)
+
+// SupportsCellMetadata determines whether the write time and ttl of the column values can be selected
+func SupportsCellMetadata(column *gocql.ColumnMetadata) bool {
+	if column.Kind != gocql.ColumnRegular {
+		return false
+	}
+
+	switch column.Type.Type() {
+	case gocql.TypeCounter, gocql.TypeList, gocql.TypeSet, gocql.TypeMap:
+		return false
+	}
+	return true
+}
+
+type AggregateFunction string
+
+const (
+	AggregateCount AggregateFunction = "COUNT"
+	AggregateMin   AggregateFunction = "MIN"
+	AggregateMax   AggregateFunction = "MAX"
+	AggregateSum   AggregateFunction = "SUM"
+	AggregateAvg   AggregateFunction = "AVG"
+)
+
+var aggregateFunctions = map[AggregateFunction]bool{
+	AggregateCount: true,
+	AggregateMin:   true,
+	AggregateMax:   true,
+	AggregateSum:   true,
+	AggregateAvg:   true,
+}
+
+// Aggregate is a function applied to the values of a column, the result is retrieved using the alias
+type Aggregate struct {
+	Function AggregateFunction
+	// Column is the argument of the function, when empty COUNT uses all the rows
+	Column string
+	Alias  string
 }
 
 type InsertInfo struct {
@@ -23,6 +83,8 @@ type InsertInfo struct {
 	QueryParams []interface{}
 	IfNotExists bool
 	TTL         int
+	// Timestamp is the write time of the mutation in microseconds, when nil the server time is used
+	Timestamp *int64
 }
 
 type DeleteInfo struct {
@@ -32,6 +94,77 @@ type DeleteInfo struct {
 	QueryParams []interface{}
 	IfCondition []types.ConditionItem
 	IfExists    bool
+	Timestamp   *int64
+	// Targets contains the columns and collection elements to delete, the whole row is deleted when empty
+	Targets []DeleteTarget
+}
+
+// DeleteTarget is a column or, when the key is provided, the element of a map or list column to delete
+type DeleteTarget struct {
+	Column string
+	// Key is the map key or the list index of the element to delete
+	Key interface{}
+}
+
+// ValidateDeleteTargets checks that the targets can be deleted from the rows selected by the key columns: regular
+// columns require the full primary key while static columns only use the partition key
+func ValidateDeleteTargets(table *gocql.TableMetadata, keyColumns []string, targets []DeleteTarget) error {
+	if len(targets) == 0 {
+		return nil
+	}
+
+	onlyStatic := true
+	for _, target := range targets {
+		column, ok := table.Columns[target.Column]
+		if !ok {
+			return fmt.Errorf("column '%s' not found", target.Column)
+		}
+		if column.Kind != gocql.ColumnRegular && column.Kind != gocql.ColumnStatic {
+			return fmt.Errorf("primary key column '%s' can't be deleted", target.Column)
+		}
+		if target.Key != nil && column.Type.Type() != gocql.TypeMap && column.Type.Type() != gocql.TypeList {
+			return fmt.Errorf("elements of column '%s' can't be deleted", target.Column)
+		}
+		onlyStatic = onlyStatic && column.Kind == gocql.ColumnStatic
+	}
+
+	clusteringColumns := 0
+	for _, name := range keyColumns {
+		if column, ok := table.Columns[name]; ok && column.Kind == gocql.ColumnClusteringKey {
+			clusteringColumns++
+		}
+	}
+
+	if onlyStatic && clusteringColumns > 0 {
+		return errors.New("static columns must be deleted using only the partition key")
+	}
+	if !onlyStatic && clusteringColumns < len(table.ClusteringColumns) {
+		return errors.New("regular columns must be deleted using the full primary key")
+	}
+	return nil
+}
+
+// ValidateAllowFiltering checks that a query using ALLOW FILTERING is bounded: it must restrict all the partition key
+// columns using equality or IN, or use a limit no greater than maxLimit
+func ValidateAllowFiltering(table *gocql.TableMetadata, where []types.ConditionItem, limit int, maxLimit int) error {
+	if limit > 0 && limit <= maxLimit {
+		return nil
+	}
+
+	restricted := make(map[string]bool, len(where))
+	for _, item := range where {
+		if item.Operator == "=" || item.Operator == "IN" {
+			restricted[item.Column] = true
+		}
+	}
+
+	for _, column := range table.PartitionKey {
+		if !restricted[column.Name] {
+			return fmt.Errorf(
+				"queries using allow filtering must restrict the partition key or use a limit of at most %d", maxLimit)
+		}
+	}
+	return nil
 }
 
 type UpdateInfo struct {
@@ -42,6 +175,74 @@ type UpdateInfo struct {
 	IfCondition []types.ConditionItem
 	IfExists    bool
 	TTL         int
+	Timestamp   *int64
+	// CollectionUpdates contains the operations on the elements of list, set and map columns
+	CollectionUpdates []CollectionUpdate
+}
+
+// CollectionOperator is an operation that modifies the elements of a list, set or map column instead of replacing
+// the whole value
+type CollectionOperator string
+
+const (
+	// CollectionAppend adds the elements at the end of a list or to a set
+	CollectionAppend CollectionOperator = "APPEND"
+	// CollectionPrepend adds the elements at the beginning of a list
+	CollectionPrepend CollectionOperator = "PREPEND"
+	// CollectionRemove removes the elements from a list or a set, or the keys from a map
+	CollectionRemove CollectionOperator = "REMOVE"
+	// CollectionPut sets the value of a map key or of a list index
+	CollectionPut CollectionOperator = "PUT"
+)
+
+// collectionOperatorTypes contains the column types supported by each collection operator
+var collectionOperatorTypes = map[CollectionOperator]map[gocql.Type]bool{
+	CollectionAppend:  {gocql.TypeList: true, gocql.TypeSet: true},
+	CollectionPrepend: {gocql.TypeList: true},
+	CollectionRemove:  {gocql.TypeList: true, gocql.TypeSet: true, gocql.TypeMap: true},
+	CollectionPut:     {gocql.TypeList: true, gocql.TypeMap: true},
+}
+
+// SupportsCollectionOperator determines whether the operator can be applied to the elements of the column
+func SupportsCollectionOperator(column *gocql.ColumnMetadata, operator CollectionOperator) bool {
+	return column.Kind == gocql.ColumnRegular && collectionOperatorTypes[operator][column.Type.Type()]
+}
+
+// SupportsLikeOperator determines whether the rows can be filtered by a pattern of the column value using LIKE
+func SupportsLikeOperator(column *gocql.ColumnMetadata) bool {
+	switch column.Type.Type() {
+	case gocql.TypeText, gocql.TypeVarchar, gocql.TypeAscii:
+		return true
+	}
+	return false
+}
+
+type CollectionUpdate struct {
+	Column   string
+	Operator CollectionOperator
+	// Key is the map key or the list index of the value, only used by CollectionPut
+	Key interface{}
+	// Value contains the elements to add or remove, the keys to remove from a map or the value to put
+	Value interface{}
+}
+
+// IncrementInfo contains the primary key of the row along with the amount to add to the counter columns
+type IncrementInfo struct {
+	Keyspace string
+	Table    *gocql.TableMetadata
+	// Columns contains the primary key and the counter columns, the parameters of the counter columns are the deltas
+	Columns     []string
+	QueryParams []interface{}
+}
+
+// IsCounterTable determines whether the regular columns of the table are counters, which can only be incremented
+func IsCounterTable(table *gocql.TableMetadata) bool {
+	for _, column := range table.Columns {
+		if column.Kind == gocql.ColumnRegular && column.Type.Type() == gocql.TypeCounter {
+			return true
+		}
+	}
+	return false
 }
 
 type ColumnOrder struct {
@@ -49,24 +250,105 @@ type ColumnOrder struct {
 	Order  string
 }
 
+// AnnOrder orders the rows by similarity to the vector, using the approximate nearest neighbor search of the column
+// index
+type AnnOrder struct {
+	Column string
+	Vector interface{}
+}
+
 func (db *Db) Select(info *SelectInfo, options *QueryOptions) (ResultSet, error) {
-	values := make([]interface{}, 0, len(info.Where))
+	statement, err := selectStatement(info, "")
+	if err != nil {
+		return nil, err
+	}
+	return db.session.ExecuteIter(statement.Query, options, statement.Values...)
+}
+
+// selectStatement builds the SELECT statement, the additional condition is appended to the where clause
+func selectStatement(info *SelectInfo, condition string, conditionValues ...interface{}) (Statement, error) {
+	if info.AnnOf != nil && len(info.OrderBy) > 0 {
+		return Statement{}, errors.New("ordering by similarity can not be combined with other orderings")
+	}
+	if info.AnnOf != nil && (info.Options == nil || info.Options.Limit <= 0) {
+		return Statement{}, errors.New("ordering by similarity requires a limit")
+	}
+
+	values := make([]interface{}, 0, len(info.Where)+len(conditionValues))
+	// The values of the selectors precede the values of the where clause
+	var selectorValues []interface{}
 	whereClause := buildCondition(info.Where, &values)
 	columns := "  *"
 
-	if len(info.Columns) > 0 {
+	if len(info.Columns) == 0 && len(info.WriteTime)+len(info.TTL) > 0 {
+		return Statement{}, errors.New("columns must be selected along with the write time or ttl")
+	}
+
+	if info.Similarity != "" {
+		if len(info.Columns) == 0 || info.AnnOf == nil {
+			return Statement{}, errors.New("the similarity can only be selected along with the columns when " +
+				"ordering by similarity")
+		}
+		if similarityFunctions[info.Similarity] == "" {
+			return Statement{}, fmt.Errorf("similarity function %s not supported", info.Similarity)
+		}
+	}
+
+	if len(info.Columns) > 0 || len(info.Aggregates) > 0 {
 		columns = ""
 		for _, columnName := range info.Columns {
 			columns += fmt.Sprintf(`, "%s"`, columnName)
 		}
+		for _, aggregate := range info.Aggregates {
+			if !aggregateFunctions[aggregate.Function] {
+				return Statement{}, fmt.Errorf("aggregate function %s not supported", aggregate.Function)
+			}
+
+			argument := "*"
+			if aggregate.Column != "" {
+				argument = fmt.Sprintf(`"%s"`, aggregate.Column)
+			} else if aggregate.Function != AggregateCount {
+				return Statement{}, fmt.Errorf("aggregate function %s requires a column", aggregate.Function)
+			}
+			columns += fmt.Sprintf(`, %s(%s) AS "%s"`, aggregate.Function, argument, aggregate.Alias)
+		}
+		for _, columnName := range info.WriteTime {
+			columns += fmt.Sprintf(`, WRITETIME("%s") AS "%s%s"`, columnName, WriteTimePrefix, columnName)
+		}
+		for _, columnName := range info.TTL {
+			columns += fmt.Sprintf(`, TTL("%s") AS "%s%s"`, columnName, TTLPrefix, columnName)
+		}
+		if info.Similarity != "" {
+			columns += fmt.Sprintf(`, %s("%s", ?) AS "%s"`,
+				similarityFunctions[info.Similarity], info.AnnOf.Column, SimilarityColumn)
+			selectorValues = append(selectorValues, info.AnnOf.Vector)
+		}
 	}
 
 	query := fmt.Sprintf(`SELECT %s FROM "%s"."%s"`, columns[2:], info.Keyspace, info.Table)
 
+	if condition != "" {
+		if whereClause != "" {
+			whereClause += " AND "
+		}
+		whereClause += condition
+		values = append(values, conditionValues...)
+	}
+
 	if whereClause != "" {
 		query += fmt.Sprintf(" WHERE %s", whereClause)
 	}
 
+	if len(info.GroupBy) > 0 {
+		query += " GROUP BY "
+		for i, columnName := range info.GroupBy {
+			if i > 0 {
+				query += ", "
+			}
+			query += fmt.Sprintf(`"%s"`, columnName)
+		}
+	}
+
 	if len(info.OrderBy) > 0 {
 		query += " ORDER BY "
 		for i, order := range info.OrderBy {
@@ -77,15 +359,34 @@ func (db *Db) Select(info *SelectInfo, options *QueryOptions) (ResultSet, error)
 		}
 	}
 
+	if info.AnnOf != nil {
+		query += fmt.Sprintf(` ORDER BY "%s" ANN OF ?`, info.AnnOf.Column)
+		values = append(values, info.AnnOf.Vector)
+	}
+
 	if info.Options != nil && info.Options.Limit > 0 {
 		query += " LIMIT ?"
 		values = append(values, info.Options.Limit)
 	}
 
-	return db.session.ExecuteIter(query, options, values...)
+	if info.AllowFiltering {
+		query += " ALLOW FILTERING"
+	}
+
+	if len(selectorValues) > 0 {
+		values = append(selectorValues, values...)
+	}
+
+	return Statement{Query: query, Values: values}, nil
 }
 
 func (db *Db) Insert(info *InsertInfo, options *QueryOptions) (ResultSet, error) {
+	statement := InsertStatement(info)
+	return db.session.ExecuteIter(statement.Query, options, statement.Values...)
+}
+
+// InsertStatement builds the INSERT statement without executing it
+func InsertStatement(info *InsertInfo) Statement {
 	placeholders := ""
 	columns := ""
 	for _, columnName := range info.Columns {
@@ -102,19 +403,39 @@ func (db *Db) Insert(info *InsertInfo, options *QueryOptions) (ResultSet, error)
 		query += " IF NOT EXISTS"
 	}
 
-	if info.TTL >= 0 {
-		query += " USING TTL ?"
-		info.QueryParams = append(info.QueryParams, info.TTL)
-	}
+	query += usingClause(info.TTL, info.Timestamp, &info.QueryParams)
 
-	return db.session.ExecuteIter(query, options, info.QueryParams...)
+	return Statement{Query: query, Values: info.QueryParams}
 }
 
 func (db *Db) Delete(info *DeleteInfo, options *QueryOptions) (ResultSet, error) {
+	statement := DeleteStatement(info)
+	return db.session.ExecuteIter(statement.Query, options, statement.Values...)
+}
+
+// DeleteStatement builds the DELETE statement without executing it
+func DeleteStatement(info *DeleteInfo) Statement {
 	whereClause := buildWhereClause(info.Columns)
-	query := fmt.Sprintf(`DELETE FROM "%s"."%s" WHERE %s`, info.Keyspace, info.Table, whereClause)
-	queryParameters := make([]interface{}, len(info.QueryParams))
-	copy(queryParameters, info.QueryParams)
+	queryParameters := make([]interface{}, 0, len(info.QueryParams)+len(info.Targets)+1)
+
+	targets := ""
+	for _, target := range info.Targets {
+		if target.Key == nil {
+			targets += fmt.Sprintf(`, "%s"`, target.Column)
+		} else {
+			targets += fmt.Sprintf(`, "%s"[?]`, target.Column)
+			queryParameters = append(queryParameters, target.Key)
+		}
+	}
+	if targets != "" {
+		// Remove the initial ", " token
+		targets = " " + targets[2:]
+	}
+
+	using := usingClause(-1, info.Timestamp, &queryParameters)
+	queryParameters = append(queryParameters, info.QueryParams...)
+	query := fmt.Sprintf(
+		`DELETE%s FROM "%s"."%s"%s WHERE %s`, targets, info.Keyspace, info.Table, using, whereClause)
 
 	if info.IfExists {
 		query += " IF EXISTS"
@@ -122,10 +443,19 @@ func (db *Db) Delete(info *DeleteInfo, options *QueryOptions) (ResultSet, error)
 		query += " IF " + buildCondition(info.IfCondition, &queryParameters)
 	}
 
-	return db.session.ExecuteIter(query, options, queryParameters...)
+	return Statement{Query: query, Values: queryParameters}
 }
 
 func (db *Db) Update(info *UpdateInfo, options *QueryOptions) (ResultSet, error) {
+	statement, err := UpdateStatement(info)
+	if err != nil {
+		return nil, err
+	}
+	return db.session.ExecuteIter(statement.Query, options, statement.Values...)
+}
+
+// UpdateStatement builds the UPDATE statement without executing it
+func UpdateStatement(info *UpdateInfo) (Statement, error) {
 	// We have to differentiate between WHERE and SET clauses
 	setClause := ""
 	whereClause := ""
@@ -143,20 +473,24 @@ func (db *Db) Update(info *UpdateInfo, options *QueryOptions) (ResultSet, error)
 		}
 	}
 
+	for _, update := range info.CollectionUpdates {
+		clause, err := collectionUpdateClause(info.Table, update, &setParameters)
+		if err != nil {
+			return Statement{}, err
+		}
+		setClause += ", " + clause
+	}
+
 	if len(whereClause) == 0 {
-		return nil, errors.New("Partition and clustering keys must be included in query")
+		return Statement{}, errors.New("Partition and clustering keys must be included in query")
 	}
 	if len(setClause) == 0 {
-		return nil, errors.New("Query must include columns to update")
+		return Statement{}, errors.New("Query must include columns to update")
 	}
 
 	queryParameters := make([]interface{}, 0, len(info.QueryParams))
 
-	ttl := ""
-	if info.TTL >= 0 {
-		ttl = " USING TTL ?"
-		queryParameters = append(queryParameters, info.TTL)
-	}
+	using := usingClause(info.TTL, info.Timestamp, &queryParameters)
 
 	for _, v := range setParameters {
 		queryParameters = append(queryParameters, v)
@@ -171,7 +505,7 @@ func (db *Db) Update(info *UpdateInfo, options *QueryOptions) (ResultSet, error)
 	setClause = setClause[2:]
 
 	query := fmt.Sprintf(
-		`UPDATE "%s"."%s"%s SET %s WHERE %s`, info.Keyspace, info.Table.Name, ttl, setClause, whereClause)
+		`UPDATE "%s"."%s"%s SET %s WHERE %s`, info.Keyspace, info.Table.Name, using, setClause, whereClause)
 
 	if info.IfExists {
 		query += " IF EXISTS"
@@ -179,7 +513,103 @@ func (db *Db) Update(info *UpdateInfo, options *QueryOptions) (ResultSet, error)
 		query += " IF " + buildCondition(info.IfCondition, &queryParameters)
 	}
 
-	return db.session.ExecuteIter(query, options, queryParameters...)
+	return Statement{Query: query, Values: queryParameters}, nil
+}
+
+func (db *Db) Increment(info *IncrementInfo, options *QueryOptions) (ResultSet, error) {
+	statement, err := IncrementStatement(info)
+	if err != nil {
+		return nil, err
+	}
+	return db.session.ExecuteIter(statement.Query, options, statement.Values...)
+}
+
+// IncrementStatement builds the UPDATE statement that adds the deltas to the counter columns without executing it
+func IncrementStatement(info *IncrementInfo) (Statement, error) {
+	setClause := ""
+	whereClause := ""
+	setParameters := make([]interface{}, 0, len(info.QueryParams))
+	whereParameters := make([]interface{}, 0, len(info.QueryParams))
+
+	for i, columnName := range info.Columns {
+		column, ok := info.Table.Columns[columnName]
+		if !ok {
+			return Statement{}, fmt.Errorf("column '%s' not found", columnName)
+		}
+
+		switch {
+		case column.Kind == gocql.ColumnPartitionKey || column.Kind == gocql.ColumnClusteringKey:
+			whereClause += fmt.Sprintf(` AND "%s" = ?`, columnName)
+			whereParameters = append(whereParameters, info.QueryParams[i])
+		case column.Type.Type() == gocql.TypeCounter:
+			setClause += fmt.Sprintf(`, "%s" = "%s" + ?`, columnName, columnName)
+			setParameters = append(setParameters, info.QueryParams[i])
+		default:
+			return Statement{}, fmt.Errorf("column '%s' is not a counter", columnName)
+		}
+	}
+
+	if len(whereClause) == 0 {
+		return Statement{}, errors.New("Partition and clustering keys must be included in query")
+	}
+	if len(setClause) == 0 {
+		return Statement{}, errors.New("Query must include counter columns to increment")
+	}
+
+	query := fmt.Sprintf(
+		`UPDATE "%s"."%s" SET %s WHERE %s`, info.Keyspace, info.Table.Name, setClause[2:], whereClause[5:])
+	return Statement{Query: query, Values: append(setParameters, whereParameters...)}, nil
+}
+
+// collectionUpdateClause returns the assignment of the SET clause that applies the operation to the collection column,
+// appending its values to the parameters
+func collectionUpdateClause(
+	table *gocql.TableMetadata,
+	update CollectionUpdate,
+	queryParameters *[]interface{},
+) (string, error) {
+	column, ok := table.Columns[update.Column]
+	if !ok {
+		return "", fmt.Errorf("column '%s' not found", update.Column)
+	}
+	if !SupportsCollectionOperator(column, update.Operator) {
+		return "", fmt.Errorf("operation %s not supported for column '%s'", update.Operator, update.Column)
+	}
+
+	switch update.Operator {
+	case CollectionAppend:
+		*queryParameters = append(*queryParameters, update.Value)
+		return fmt.Sprintf(`"%s" = "%s" + ?`, update.Column, update.Column), nil
+	case CollectionPrepend:
+		*queryParameters = append(*queryParameters, update.Value)
+		return fmt.Sprintf(`"%s" = ? + "%s"`, update.Column, update.Column), nil
+	case CollectionRemove:
+		*queryParameters = append(*queryParameters, update.Value)
+		return fmt.Sprintf(`"%s" = "%s" - ?`, update.Column, update.Column), nil
+	}
+
+	// CollectionPut
+	*queryParameters = append(*queryParameters, update.Key, update.Value)
+	return fmt.Sprintf(`"%s"[?] = ?`, update.Column), nil
+}
+
+// usingClause returns the USING clause of a mutation, appending the ttl and timestamp values to the parameters. A
+// negative ttl and a nil timestamp are not included.
+func usingClause(ttl int, timestamp *int64, queryParameters *[]interface{}) string {
+	options := make([]string, 0, 2)
+	if ttl >= 0 {
+		options = append(options, "TTL ?")
+		*queryParameters = append(*queryParameters, ttl)
+	}
+	if timestamp != nil {
+		options = append(options, "TIMESTAMP ?")
+		*queryParameters = append(*queryParameters, *timestamp)
+	}
+
+	if len(options) == 0 {
+		return ""
+	}
+	return " USING " + strings.Join(options, " AND ")
 }
 
 func buildWhereClause(columnNames []string) string {
@@ -199,6 +629,11 @@ func buildCondition(condition []types.ConditionItem, queryParameters *[]interfac
 
 	conditionClause := ""
 	for _, item := range condition {
+		if entry, ok := item.Value.(types.MapEntry); ok && item.Operator == types.ContainsEntryOperator {
+			conditionClause += fmt.Sprintf(` AND "%s"[?] = ?`, item.Column)
+			*queryParameters = append(*queryParameters, entry.Key, entry.Value)
+			continue
+		}
 		conditionClause += fmt.Sprintf(` AND "%s" %s ?`, item.Column, item.Operator)
 		*queryParameters = append(*queryParameters, item.Value)
 	}
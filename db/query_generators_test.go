@@ -198,6 +198,286 @@ var _ = Describe("db", func() {
 		}
 	})
 
+	Describe("Mutations with timestamp", func() {
+		var sessionMock *SessionMock
+		var db *Db
+		timestamp := int64(1600000000000000)
+
+		BeforeEach(func() {
+			sessionMock = &SessionMock{}
+			sessionMock.On("ExecuteIter", mock.Anything, mock.Anything, mock.Anything).Return(ResultMock{}, nil)
+			db = &Db{
+				session: sessionMock,
+			}
+		})
+
+		It("Should generate INSERT statement with TTL and TIMESTAMP", func() {
+			_, err := db.Insert(&InsertInfo{
+				Keyspace:    "ks1",
+				Table:       "tbl1",
+				Columns:     []string{"a", "b"},
+				QueryParams: []interface{}{1, 2},
+				TTL:         3600,
+				Timestamp:   &timestamp,
+			}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			sessionMock.AssertCalled(GinkgoT(), "ExecuteIter",
+				`INSERT INTO "ks1"."tbl1" ("a", "b") VALUES (?, ?) USING TTL ? AND TIMESTAMP ?`, mock.Anything,
+				[]interface{}{1, 2, 3600, timestamp})
+		})
+
+		It("Should generate UPDATE statement with TIMESTAMP", func() {
+			table := &gocql.TableMetadata{
+				Name: "tbl1",
+				Columns: map[string]*gocql.ColumnMetadata{
+					"pk1": {Name: "pk1", Kind: gocql.ColumnPartitionKey},
+				},
+			}
+			_, err := db.Update(&UpdateInfo{
+				Keyspace:    "ks1",
+				Table:       table,
+				Columns:     []string{"a", "pk1"},
+				QueryParams: []interface{}{1, 2},
+				TTL:         -1,
+				Timestamp:   &timestamp,
+			}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			sessionMock.AssertCalled(GinkgoT(), "ExecuteIter",
+				`UPDATE "ks1"."tbl1" USING TIMESTAMP ? SET "a" = ? WHERE "pk1" = ?`, mock.Anything,
+				[]interface{}{timestamp, 1, 2})
+		})
+
+		It("Should generate DELETE statement with TIMESTAMP", func() {
+			_, err := db.Delete(&DeleteInfo{
+				Keyspace:    "ks1",
+				Table:       "tbl1",
+				Columns:     []string{"a"},
+				QueryParams: []interface{}{1},
+				Timestamp:   &timestamp,
+			}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			sessionMock.AssertCalled(GinkgoT(), "ExecuteIter",
+				`DELETE FROM "ks1"."tbl1" USING TIMESTAMP ? WHERE "a" = ?`, mock.Anything,
+				[]interface{}{timestamp, 1})
+		})
+	})
+
+	Describe("Update with collection operations", func() {
+		var sessionMock *SessionMock
+		var db *Db
+		collectionType := func(t gocql.Type) gocql.TypeInfo {
+			return gocql.CollectionType{NativeType: gocql.NewNativeType(0, t, "")}
+		}
+		table := &gocql.TableMetadata{
+			Name: "tbl1",
+			Columns: map[string]*gocql.ColumnMetadata{
+				"pk1": {Name: "pk1", Kind: gocql.ColumnPartitionKey},
+				"l":   {Name: "l", Kind: gocql.ColumnRegular, Type: collectionType(gocql.TypeList)},
+				"s":   {Name: "s", Kind: gocql.ColumnRegular, Type: collectionType(gocql.TypeSet)},
+				"m":   {Name: "m", Kind: gocql.ColumnRegular, Type: collectionType(gocql.TypeMap)},
+				"a":   {Name: "a", Kind: gocql.ColumnRegular, Type: gocql.NewNativeType(0, gocql.TypeInt, "")},
+			},
+		}
+
+		BeforeEach(func() {
+			sessionMock = &SessionMock{}
+			sessionMock.On("ExecuteIter", mock.Anything, mock.Anything, mock.Anything).Return(ResultMock{}, nil)
+			db = &Db{
+				session: sessionMock,
+			}
+		})
+
+		items := []struct {
+			description string
+			updates     []CollectionUpdate
+			query       string
+			values      []interface{}
+		}{
+			{"append to a list", []CollectionUpdate{{Column: "l", Operator: CollectionAppend, Value: []int{1}}},
+				`UPDATE "ks1"."tbl1" SET "a" = ?, "l" = "l" + ? WHERE "pk1" = ?`,
+				[]interface{}{1, []int{1}, 2}},
+			{"prepend to a list", []CollectionUpdate{{Column: "l", Operator: CollectionPrepend, Value: []int{1}}},
+				`UPDATE "ks1"."tbl1" SET "a" = ?, "l" = ? + "l" WHERE "pk1" = ?`,
+				[]interface{}{1, []int{1}, 2}},
+			{"set a list index", []CollectionUpdate{{Column: "l", Operator: CollectionPut, Key: 0, Value: 10}},
+				`UPDATE "ks1"."tbl1" SET "a" = ?, "l"[?] = ? WHERE "pk1" = ?`,
+				[]interface{}{1, 0, 10, 2}},
+			{"add to and remove from a set", []CollectionUpdate{
+				{Column: "s", Operator: CollectionAppend, Value: []string{"x"}},
+				{Column: "s", Operator: CollectionRemove, Value: []string{"y"}}},
+				`UPDATE "ks1"."tbl1" SET "a" = ?, "s" = "s" + ?, "s" = "s" - ? WHERE "pk1" = ?`,
+				[]interface{}{1, []string{"x"}, []string{"y"}, 2}},
+			{"put and remove map keys", []CollectionUpdate{
+				{Column: "m", Operator: CollectionPut, Key: "k1", Value: "v1"},
+				{Column: "m", Operator: CollectionRemove, Value: []string{"k2"}}},
+				`UPDATE "ks1"."tbl1" SET "a" = ?, "m"[?] = ?, "m" = "m" - ? WHERE "pk1" = ?`,
+				[]interface{}{1, "k1", "v1", []string{"k2"}, 2}},
+		}
+
+		for i := range items {
+			item := items[i]
+			It("Should generate UPDATE statement to "+item.description, func() {
+				_, err := db.Update(&UpdateInfo{
+					Keyspace:          "ks1",
+					Table:             table,
+					Columns:           []string{"a", "pk1"},
+					QueryParams:       []interface{}{1, 2},
+					TTL:               -1,
+					CollectionUpdates: item.updates,
+				}, nil)
+				Expect(err).NotTo(HaveOccurred())
+				sessionMock.AssertCalled(GinkgoT(), "ExecuteIter", item.query, mock.Anything, item.values)
+			})
+		}
+
+		It("Should generate UPDATE statement with only collection operations", func() {
+			_, err := db.Update(&UpdateInfo{
+				Keyspace:          "ks1",
+				Table:             table,
+				Columns:           []string{"pk1"},
+				QueryParams:       []interface{}{2},
+				TTL:               -1,
+				CollectionUpdates: []CollectionUpdate{{Column: "l", Operator: CollectionAppend, Value: []int{1}}},
+			}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			sessionMock.AssertCalled(GinkgoT(), "ExecuteIter",
+				`UPDATE "ks1"."tbl1" SET "l" = "l" + ? WHERE "pk1" = ?`, mock.Anything, []interface{}{[]int{1}, 2})
+		})
+
+		It("Should return an error when the operation is not supported by the column", func() {
+			for _, update := range []CollectionUpdate{
+				{Column: "s", Operator: CollectionPrepend, Value: []string{"x"}},
+				{Column: "m", Operator: CollectionAppend, Value: map[string]string{}},
+				{Column: "a", Operator: CollectionAppend, Value: 1},
+				{Column: "z", Operator: CollectionAppend, Value: 1},
+			} {
+				_, err := db.Update(&UpdateInfo{
+					Keyspace:          "ks1",
+					Table:             table,
+					Columns:           []string{"pk1"},
+					QueryParams:       []interface{}{2},
+					TTL:               -1,
+					CollectionUpdates: []CollectionUpdate{update},
+				}, nil)
+				Expect(err).To(HaveOccurred())
+			}
+			sessionMock.AssertNotCalled(GinkgoT(), "ExecuteIter", mock.Anything, mock.Anything, mock.Anything)
+		})
+	})
+
+	Describe("Increment", func() {
+		var sessionMock *SessionMock
+		var db *Db
+		table := &gocql.TableMetadata{
+			Name: "tbl1",
+			Columns: map[string]*gocql.ColumnMetadata{
+				"pk1": {Name: "pk1", Kind: gocql.ColumnPartitionKey, Type: gocql.NewNativeType(0, gocql.TypeInt, "")},
+				"ck1": {Name: "ck1", Kind: gocql.ColumnClusteringKey, Type: gocql.NewNativeType(0, gocql.TypeInt, "")},
+				"c1":  {Name: "c1", Kind: gocql.ColumnRegular, Type: gocql.NewNativeType(0, gocql.TypeCounter, "")},
+				"c2":  {Name: "c2", Kind: gocql.ColumnRegular, Type: gocql.NewNativeType(0, gocql.TypeCounter, "")},
+				"a":   {Name: "a", Kind: gocql.ColumnRegular, Type: gocql.NewNativeType(0, gocql.TypeInt, "")},
+			},
+		}
+
+		BeforeEach(func() {
+			sessionMock = &SessionMock{}
+			sessionMock.On("ExecuteIter", mock.Anything, mock.Anything, mock.Anything).Return(ResultMock{}, nil)
+			db = &Db{
+				session: sessionMock,
+			}
+		})
+
+		It("Should generate UPDATE statement adding the deltas to the counters", func() {
+			_, err := db.Increment(&IncrementInfo{
+				Keyspace:    "ks1",
+				Table:       table,
+				Columns:     []string{"c1", "pk1", "c2", "ck1"},
+				QueryParams: []interface{}{int64(1), 2, int64(-3), 4},
+			}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			sessionMock.AssertCalled(GinkgoT(), "ExecuteIter",
+				`UPDATE "ks1"."tbl1" SET "c1" = "c1" + ?, "c2" = "c2" + ? WHERE "pk1" = ? AND "ck1" = ?`,
+				mock.Anything, []interface{}{int64(1), int64(-3), 2, 4})
+		})
+
+		It("Should return an error when the columns are not counters or no counter is provided", func() {
+			for _, columns := range [][]string{{"a", "pk1", "ck1"}, {"pk1", "ck1"}, {"c1"}} {
+				values := make([]interface{}, len(columns))
+				_, err := db.Increment(&IncrementInfo{
+					Keyspace:    "ks1",
+					Table:       table,
+					Columns:     columns,
+					QueryParams: values,
+				}, nil)
+				Expect(err).To(HaveOccurred())
+			}
+			sessionMock.AssertNotCalled(GinkgoT(), "ExecuteIter", mock.Anything, mock.Anything, mock.Anything)
+		})
+
+		It("Should determine whether the table is a counter table", func() {
+			Expect(IsCounterTable(table)).To(BeTrue())
+			Expect(IsCounterTable(&gocql.TableMetadata{Columns: map[string]*gocql.ColumnMetadata{
+				"pk1": {Name: "pk1", Kind: gocql.ColumnPartitionKey, Type: gocql.NewNativeType(0, gocql.TypeInt, "")},
+			}})).To(BeFalse())
+		})
+	})
+
+	Describe("Delete columns", func() {
+		var sessionMock *SessionMock
+		var db *Db
+		timestamp := int64(1000)
+		table := &gocql.TableMetadata{
+			Name: "tbl1",
+			Columns: map[string]*gocql.ColumnMetadata{
+				"pk1": {Name: "pk1", Kind: gocql.ColumnPartitionKey, Type: gocql.NewNativeType(0, gocql.TypeInt, "")},
+				"ck1": {Name: "ck1", Kind: gocql.ColumnClusteringKey, Type: gocql.NewNativeType(0, gocql.TypeInt, "")},
+				"a":   {Name: "a", Kind: gocql.ColumnRegular, Type: gocql.NewNativeType(0, gocql.TypeInt, "")},
+				"m":   {Name: "m", Kind: gocql.ColumnRegular, Type: gocql.NewNativeType(0, gocql.TypeMap, "")},
+				"st":  {Name: "st", Kind: gocql.ColumnStatic, Type: gocql.NewNativeType(0, gocql.TypeInt, "")},
+			},
+			ClusteringColumns: []*gocql.ColumnMetadata{{Name: "ck1", Kind: gocql.ColumnClusteringKey}},
+		}
+
+		BeforeEach(func() {
+			sessionMock = &SessionMock{}
+			sessionMock.On("ExecuteIter", mock.Anything, mock.Anything, mock.Anything).Return(ResultMock{}, nil)
+			db = &Db{
+				session: sessionMock,
+			}
+		})
+
+		It("Should generate DELETE statement of columns and elements", func() {
+			_, err := db.Delete(&DeleteInfo{
+				Keyspace:    "ks1",
+				Table:       "tbl1",
+				Columns:     []string{"pk1", "ck1"},
+				QueryParams: []interface{}{1, 2},
+				Timestamp:   &timestamp,
+				Targets:     []DeleteTarget{{Column: "a"}, {Column: "m", Key: "k1"}},
+			}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			sessionMock.AssertCalled(GinkgoT(), "ExecuteIter",
+				`DELETE "a", "m"[?] FROM "ks1"."tbl1" USING TIMESTAMP ? WHERE "pk1" = ? AND "ck1" = ?`,
+				mock.Anything, []interface{}{"k1", timestamp, 1, 2})
+		})
+
+		It("Should validate the targets using the primary key", func() {
+			Expect(ValidateDeleteTargets(table, []string{"pk1", "ck1"}, nil)).To(Succeed())
+			Expect(ValidateDeleteTargets(table, []string{"pk1"}, []DeleteTarget{{Column: "st"}})).To(Succeed())
+			Expect(ValidateDeleteTargets(table, []string{"pk1", "ck1"},
+				[]DeleteTarget{{Column: "a"}, {Column: "m", Key: "k1"}, {Column: "st"}})).To(Succeed())
+
+			Expect(ValidateDeleteTargets(table, []string{"pk1"}, []DeleteTarget{{Column: "a"}})).
+				To(MatchError("regular columns must be deleted using the full primary key"))
+			Expect(ValidateDeleteTargets(table, []string{"pk1", "ck1"}, []DeleteTarget{{Column: "st"}})).
+				To(MatchError("static columns must be deleted using only the partition key"))
+			Expect(ValidateDeleteTargets(table, []string{"pk1", "ck1"}, []DeleteTarget{{Column: "ck1"}})).
+				To(MatchError("primary key column 'ck1' can't be deleted"))
+			Expect(ValidateDeleteTargets(table, []string{"pk1", "ck1"}, []DeleteTarget{{Column: "a", Key: 1}})).
+				To(MatchError("elements of column 'a' can't be deleted"))
+		})
+	})
+
 	Describe("Select", func() {
 		items := []struct {
 			description string
@@ -229,6 +509,14 @@ var _ = Describe("db", func() {
 			{"order and limit", []types.ConditionItem{{"ABC", "=", "z"}}, &types.QueryOptions{Limit: 1},
 				[]ColumnOrder{{"DEF", "ASC"}}, nil,
 				`SELECT * FROM "ks1"."tbl1" WHERE "ABC" = ? ORDER BY "DEF" ASC LIMIT ?`},
+			{"collection and text operators",
+				[]types.ConditionItem{
+					{Column: "a", Operator: "CONTAINS", Value: 1},
+					{Column: "b", Operator: "CONTAINS KEY", Value: "x"},
+					{Column: "c", Operator: "LIKE", Value: "z%"},
+				},
+				&types.QueryOptions{}, nil, nil,
+				`SELECT * FROM "ks1"."tbl1" WHERE "a" CONTAINS ? AND "b" CONTAINS KEY ? AND "c" LIKE ?`},
 		}
 
 		for i := 0; i < len(items); i++ {
@@ -268,6 +556,258 @@ var _ = Describe("db", func() {
 				sessionMock.AssertExpectations(GinkgoT())
 			})
 		}
+
+		It("Should generate SELECT statement with a map entry condition", func() {
+			sessionMock := SessionMock{}
+			sessionMock.On("ExecuteIter", mock.Anything, mock.Anything, mock.Anything).Return(&ResultMock{}, nil)
+			db := &Db{
+				session: &sessionMock,
+			}
+
+			_, err := db.Select(&SelectInfo{
+				Keyspace: "ks1",
+				Table:    "tbl1",
+				Where: []types.ConditionItem{
+					{Column: "a", Operator: "=", Value: 1},
+					{Column: "m", Operator: types.ContainsEntryOperator, Value: types.MapEntry{Key: "x", Value: 2}},
+				},
+			}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			sessionMock.AssertCalled(GinkgoT(), "ExecuteIter",
+				`SELECT * FROM "ks1"."tbl1" WHERE "a" = ? AND "m"[?] = ?`, mock.Anything, []interface{}{1, "x", 2})
+		})
+	})
+	Describe("Select with like", func() {
+		It("Should only support like on text columns", func() {
+			for _, t := range []gocql.Type{gocql.TypeText, gocql.TypeVarchar, gocql.TypeAscii} {
+				column := &gocql.ColumnMetadata{Name: "a", Type: gocql.NewNativeType(0, t, "")}
+				Expect(SupportsLikeOperator(column)).To(BeTrue())
+			}
+			for _, t := range []gocql.Type{gocql.TypeInt, gocql.TypeUUID, gocql.TypeBlob} {
+				column := &gocql.ColumnMetadata{Name: "a", Type: gocql.NewNativeType(0, t, "")}
+				Expect(SupportsLikeOperator(column)).To(BeFalse())
+			}
+		})
+	})
+
+	Describe("Select with aggregates", func() {
+		items := []struct {
+			description string
+			where       []types.ConditionItem
+			columns     []string
+			aggregates  []Aggregate
+			groupBy     []string
+			query       string
+		}{
+			{"count of all rows", nil, nil, []Aggregate{{AggregateCount, "", "count"}}, nil,
+				`SELECT COUNT(*) AS "count" FROM "ks1"."tbl1"`},
+			{"multiple functions", []types.ConditionItem{{Column: "a", Operator: "=", Value: 1}}, nil,
+				[]Aggregate{{AggregateMin, "b", "min_b"}, {AggregateAvg, "c", "avg_c"}}, nil,
+				`SELECT MIN("b") AS "min_b", AVG("c") AS "avg_c" FROM "ks1"."tbl1" WHERE "a" = ?`},
+			{"group by", nil, []string{"a", "b"},
+				[]Aggregate{{AggregateCount, "", "count"}, {AggregateSum, "c", "sum_c"}}, []string{"a", "b"},
+				`SELECT "a", "b", COUNT(*) AS "count", SUM("c") AS "sum_c" FROM "ks1"."tbl1" GROUP BY "a", "b"`},
+			{"where and group by", []types.ConditionItem{{Column: "a", Operator: "=", Value: 1}}, []string{"b"},
+				[]Aggregate{{AggregateMax, "c", "max_c"}}, []string{"a", "b"},
+				`SELECT "b", MAX("c") AS "max_c" FROM "ks1"."tbl1" WHERE "a" = ? GROUP BY "a", "b"`},
+		}
+
+		for i := 0; i < len(items); i++ {
+			// Capture the item in the closure
+			item := items[i]
+
+			It("Should generate SELECT statement with "+item.description, func() {
+				sessionMock := SessionMock{}
+				sessionMock.On("ExecuteIter", mock.Anything, mock.Anything, mock.Anything).Return(&ResultMock{}, nil)
+				db := &Db{
+					session: &sessionMock,
+				}
+
+				queryParams := make([]interface{}, 0)
+				for _, v := range item.where {
+					queryParams = append(queryParams, v.Value)
+				}
+
+				_, err := db.Select(&SelectInfo{
+					Keyspace:   "ks1",
+					Table:      "tbl1",
+					Columns:    item.columns,
+					Aggregates: item.aggregates,
+					GroupBy:    item.groupBy,
+					Where:      item.where,
+					Options:    &types.QueryOptions{},
+				}, nil)
+				Expect(err).NotTo(HaveOccurred())
+				sessionMock.AssertCalled(GinkgoT(), "ExecuteIter", item.query, mock.Anything, queryParams)
+			})
+		}
+
+		It("Should return an error when the aggregate function is not supported", func() {
+			sessionMock := SessionMock{}
+			db := &Db{
+				session: &sessionMock,
+			}
+
+			_, err := db.Select(&SelectInfo{
+				Keyspace:   "ks1",
+				Table:      "tbl1",
+				Aggregates: []Aggregate{{"MEDIAN", "a", "median_a"}},
+			}, nil)
+			Expect(err).To(HaveOccurred())
+			sessionMock.AssertNotCalled(GinkgoT(), "ExecuteIter", mock.Anything, mock.Anything, mock.Anything)
+		})
+	})
+
+	Describe("Select with write time and ttl", func() {
+		It("Should generate SELECT statement with WRITETIME and TTL selectors", func() {
+			sessionMock := SessionMock{}
+			sessionMock.On("ExecuteIter", mock.Anything, mock.Anything, mock.Anything).Return(&ResultMock{}, nil)
+			db := &Db{
+				session: &sessionMock,
+			}
+
+			_, err := db.Select(&SelectInfo{
+				Keyspace:  "ks1",
+				Table:     "tbl1",
+				Columns:   []string{"a", "b", "c"},
+				WriteTime: []string{"b", "c"},
+				TTL:       []string{"c"},
+				Where:     []types.ConditionItem{{Column: "a", Operator: "=", Value: 1}},
+			}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			sessionMock.AssertCalled(GinkgoT(), "ExecuteIter",
+				`SELECT "a", "b", "c", WRITETIME("b") AS "_writetime_b", WRITETIME("c") AS "_writetime_c", `+
+					`TTL("c") AS "_ttl_c" FROM "ks1"."tbl1" WHERE "a" = ?`, mock.Anything, []interface{}{1})
+		})
+
+		It("Should return an error when the columns are not selected", func() {
+			sessionMock := SessionMock{}
+			db := &Db{
+				session: &sessionMock,
+			}
+
+			_, err := db.Select(&SelectInfo{
+				Keyspace:  "ks1",
+				Table:     "tbl1",
+				WriteTime: []string{"b"},
+			}, nil)
+			Expect(err).To(HaveOccurred())
+			sessionMock.AssertNotCalled(GinkgoT(), "ExecuteIter", mock.Anything, mock.Anything, mock.Anything)
+		})
+	})
+
+	Describe("Select ordered by similarity", func() {
+		It("Should generate SELECT statement with ANN OF and the similarity selector", func() {
+			sessionMock := SessionMock{}
+			sessionMock.On("ExecuteIter", mock.Anything, mock.Anything, mock.Anything).Return(&ResultMock{}, nil)
+			db := &Db{
+				session: &sessionMock,
+			}
+
+			vector := types.VectorParameter{0.5, 1}
+			_, err := db.Select(&SelectInfo{
+				Keyspace:   "ks1",
+				Table:      "tbl1",
+				Columns:    []string{"a", "b"},
+				Where:      []types.ConditionItem{{Column: "a", Operator: "=", Value: 1}},
+				AnnOf:      &AnnOrder{Column: "b", Vector: vector},
+				Similarity: "dot_product",
+				Options:    &types.QueryOptions{Limit: 10},
+			}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			sessionMock.AssertCalled(GinkgoT(), "ExecuteIter",
+				`SELECT "a", "b", SIMILARITY_DOT_PRODUCT("b", ?) AS "_similarity" FROM "ks1"."tbl1" `+
+					`WHERE "a" = ? ORDER BY "b" ANN OF ? LIMIT ?`, mock.Anything, []interface{}{vector, 1, vector, 10})
+		})
+
+		It("Should return an error when the query is not supported", func() {
+			items := []*SelectInfo{
+				// Without a limit
+				{AnnOf: &AnnOrder{Column: "b", Vector: types.VectorParameter{1}}},
+				// Along with other orderings
+				{
+					AnnOf:   &AnnOrder{Column: "b", Vector: types.VectorParameter{1}},
+					OrderBy: []ColumnOrder{{Column: "c", Order: "ASC"}},
+					Options: &types.QueryOptions{Limit: 1},
+				},
+				// Selecting the similarity without ordering by similarity
+				{Columns: []string{"a"}, Similarity: "cosine"},
+				// Selecting the similarity without columns
+				{
+					AnnOf:      &AnnOrder{Column: "b", Vector: types.VectorParameter{1}},
+					Similarity: "cosine",
+					Options:    &types.QueryOptions{Limit: 1},
+				},
+			}
+
+			for _, item := range items {
+				sessionMock := SessionMock{}
+				db := &Db{
+					session: &sessionMock,
+				}
+				item.Keyspace = "ks1"
+				item.Table = "tbl1"
+				_, err := db.Select(item, nil)
+				Expect(err).To(HaveOccurred())
+				sessionMock.AssertNotCalled(GinkgoT(), "ExecuteIter", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	})
+
+	Describe("Select with allow filtering", func() {
+		table := &gocql.TableMetadata{
+			Name: "tbl1",
+			PartitionKey: []*gocql.ColumnMetadata{
+				{Name: "pk1", Kind: gocql.ColumnPartitionKey},
+				{Name: "pk2", Kind: gocql.ColumnPartitionKey},
+			},
+		}
+
+		It("Should generate SELECT statement with ALLOW FILTERING", func() {
+			sessionMock := SessionMock{}
+			sessionMock.On("ExecuteIter", mock.Anything, mock.Anything, mock.Anything).Return(&ResultMock{}, nil)
+			db := &Db{
+				session: &sessionMock,
+			}
+
+			_, err := db.Select(&SelectInfo{
+				Keyspace:       "ks1",
+				Table:          "tbl1",
+				Where:          []types.ConditionItem{{Column: "a", Operator: ">", Value: 1}},
+				Options:        &types.QueryOptions{Limit: 10},
+				AllowFiltering: true,
+			}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			sessionMock.AssertCalled(GinkgoT(), "ExecuteIter",
+				`SELECT * FROM "ks1"."tbl1" WHERE "a" > ? LIMIT ? ALLOW FILTERING`, mock.Anything, []interface{}{1, 10})
+		})
+
+		It("Should validate that the partition key is restricted or a limit is used", func() {
+			Expect(ValidateAllowFiltering(table, nil, 10, 100)).To(Succeed())
+			Expect(ValidateAllowFiltering(table, []types.ConditionItem{
+				{Column: "pk1", Operator: "=", Value: 1},
+				{Column: "pk2", Operator: "IN", Value: []interface{}{1, 2}},
+				{Column: "a", Operator: ">", Value: 1},
+			}, 0, 100)).To(Succeed())
+			Expect(ValidateAllowFiltering(table, nil, 0, 100)).
+				To(MatchError("queries using allow filtering must restrict the partition key or use a limit of at most 100"))
+			Expect(ValidateAllowFiltering(table, []types.ConditionItem{
+				{Column: "pk1", Operator: "=", Value: 1},
+				{Column: "pk2", Operator: ">", Value: 1},
+			}, 0, 100)).To(HaveOccurred())
+		})
+
+		It("Should validate that the limit is no greater than the maximum", func() {
+			Expect(ValidateAllowFiltering(table, nil, 100, 100)).To(Succeed())
+			Expect(ValidateAllowFiltering(table, nil, 101, 100)).
+				To(MatchError("queries using allow filtering must restrict the partition key or use a limit of at most 100"))
+
+			// The limit is not bounded when the partition key is restricted
+			Expect(ValidateAllowFiltering(table, []types.ConditionItem{
+				{Column: "pk1", Operator: "=", Value: 1},
+				{Column: "pk2", Operator: "=", Value: 2},
+			}, 101, 100)).To(Succeed())
+		})
 	})
 })
 
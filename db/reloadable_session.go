@@ -0,0 +1,114 @@
+package db
+
+import (
+	"errors"
+	"github.com/gocql/gocql"
+	"sync"
+)
+
+// reloadableSession is a Session that allows replacing the underlying session, the replaced session is closed once
+// the requests that were using it complete
+type reloadableSession struct {
+	mutex   sync.RWMutex
+	current *sessionRef
+}
+
+type sessionRef struct {
+	session Session
+	close   func()
+	pending sync.WaitGroup
+}
+
+func newReloadableSession(session Session, close func()) *reloadableSession {
+	return &reloadableSession{current: &sessionRef{session: session, close: close}}
+}
+
+// acquire returns the current session, release must be called once the request completes
+func (s *reloadableSession) acquire() *sessionRef {
+	s.mutex.RLock()
+	ref := s.current
+	ref.pending.Add(1)
+	s.mutex.RUnlock()
+	return ref
+}
+
+func (ref *sessionRef) release() {
+	ref.pending.Done()
+}
+
+func (s *reloadableSession) swap(session Session, close func()) {
+	s.mutex.Lock()
+	previous := s.current
+	s.current = &sessionRef{session: session, close: close}
+	s.mutex.Unlock()
+
+	// No new requests can use the previous session at this point
+	go func() {
+		previous.pending.Wait()
+		if previous.close != nil {
+			previous.close()
+		}
+	}()
+}
+
+func (s *reloadableSession) Execute(query string, options *QueryOptions, values ...interface{}) error {
+	ref := s.acquire()
+	defer ref.release()
+	return ref.session.Execute(query, options, values...)
+}
+
+func (s *reloadableSession) ExecuteIter(query string, options *QueryOptions, values ...interface{}) (ResultSet, error) {
+	ref := s.acquire()
+	defer ref.release()
+	return ref.session.ExecuteIter(query, options, values...)
+}
+
+func (s *reloadableSession) ExecuteBatch(
+	batchType gocql.BatchType,
+	statements []Statement,
+	options *QueryOptions,
+) error {
+	ref := s.acquire()
+	defer ref.release()
+	return ref.session.ExecuteBatch(batchType, statements, options)
+}
+
+func (s *reloadableSession) TokenRanges() ([]TokenRange, error) {
+	ref := s.acquire()
+	defer ref.release()
+	return ref.session.TokenRanges()
+}
+
+func (s *reloadableSession) ChangeSchema(query string, options *QueryOptions) error {
+	ref := s.acquire()
+	defer ref.release()
+	return ref.session.ChangeSchema(query, options)
+}
+
+func (s *reloadableSession) KeyspaceMetadata(keyspaceName string) (*gocql.KeyspaceMetadata, error) {
+	ref := s.acquire()
+	defer ref.release()
+	return ref.session.KeyspaceMetadata(keyspaceName)
+}
+
+// Reload connects to the cluster using the provided config, e.g. with renewed certificates or credentials, and
+// replaces the current session. Requests in progress complete using the previous session.
+func (db *Db) Reload(config Config) error {
+	session, ok := db.session.(*reloadableSession)
+	if !ok || len(db.hosts) == 0 {
+		return errors.New("reload is not supported by the db")
+	}
+
+	cluster := newCluster(config, db.hosts...)
+	ref, err := cluster.CreateSession()
+	if err != nil {
+		return err
+	}
+
+	db.mutex.Lock()
+	db.config = config
+	db.mutex.Unlock()
+
+	session.swap(newGoCqlSession(ref, cluster), ref.Close)
+	return nil
+}
@@ -0,0 +1,40 @@
+package db
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("reloadableSession", func() {
+	It("Should use the new session and close the previous one after the pending requests", func() {
+		previous := NewSessionMock()
+		previous.On("ExecuteIter", "SELECT 1", mock.Anything, mock.Anything).Return(&ResultMock{}, nil)
+		closed := make(chan bool, 1)
+		session := newReloadableSession(previous, func() { closed <- true })
+
+		_, err := session.ExecuteIter("SELECT 1", nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		// Simulate a request in progress
+		ref := session.acquire()
+
+		next := NewSessionMock()
+		next.On("ExecuteIter", "SELECT 2", mock.Anything, mock.Anything).Return(&ResultMock{}, nil)
+		session.swap(next, nil)
+
+		_, err = session.ExecuteIter("SELECT 2", nil)
+		Expect(err).NotTo(HaveOccurred())
+		Consistently(closed).ShouldNot(Receive())
+
+		ref.release()
+		Eventually(closed).Should(Receive())
+		previous.AssertExpectations(GinkgoT())
+		next.AssertExpectations(GinkgoT())
+	})
+
+	It("Should not support reloading a db created with a session", func() {
+		db := NewDbWithSession(NewSessionMock())
+		Expect(db.Reload(Config{})).To(MatchError("reload is not supported by the db"))
+	})
+})
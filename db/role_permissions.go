@@ -0,0 +1,104 @@
+package db
+
+const (
+	PermissionSelect = "SELECT"
+	PermissionModify = "MODIFY"
+)
+
+// RolePermissions contains the permissions on data resources granted to a role, including the ones inherited from
+// the roles it's a member of.
+// A nil RolePermissions grants all the permissions.
+type RolePermissions struct {
+	superuser bool
+	// Permissions by resource name, e.g. "data/ks1/table1"
+	resources map[string]map[string]bool
+}
+
+// RolePermissions reads the permissions granted to the role from the system_auth keyspace
+func (db *Db) RolePermissions(role string) (*RolePermissions, error) {
+	result := &RolePermissions{resources: make(map[string]map[string]bool)}
+	visited := make(map[string]bool)
+	pending := []string{role}
+
+	for len(pending) > 0 {
+		name := pending[0]
+		pending = pending[1:]
+		if visited[name] {
+			continue
+		}
+		visited[name] = true
+
+		rs, err := db.session.ExecuteIter("SELECT is_superuser, member_of FROM system_auth.roles WHERE role = ?",
+			nil, name)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(rs.Values()) == 0 {
+			// Role not found
+			continue
+		}
+
+		row := rs.Values()[0]
+		if superuser, ok := row["is_superuser"].(*bool); ok && superuser != nil && *superuser {
+			result.superuser = true
+			return result, nil
+		}
+
+		if memberOf, ok := row["member_of"].(*[]string); ok && memberOf != nil {
+			pending = append(pending, *memberOf...)
+		}
+
+		rs, err = db.session.ExecuteIter(
+			"SELECT resource, permissions FROM system_auth.role_permissions WHERE role = ?", nil, name)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, row := range rs.Values() {
+			resource, ok := row["resource"].(*string)
+			if !ok || resource == nil {
+				continue
+			}
+
+			permissions := result.resources[*resource]
+			if permissions == nil {
+				permissions = make(map[string]bool)
+				result.resources[*resource] = permissions
+			}
+
+			if values, ok := row["permissions"].(*[]string); ok && values != nil {
+				for _, permission := range *values {
+					permissions[permission] = true
+				}
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// CanSelect determines whether the role can read the table data
+func (p *RolePermissions) CanSelect(keyspace string, table string) bool {
+	return p.isGranted(PermissionSelect, keyspace, table)
+}
+
+// CanModify determines whether the role can insert, update or delete the table data
+func (p *RolePermissions) CanModify(keyspace string, table string) bool {
+	return p.isGranted(PermissionModify, keyspace, table)
+}
+
+func (p *RolePermissions) isGranted(permission string, keyspace string, table string) bool {
+	if p == nil || p.superuser {
+		return true
+	}
+
+	// Permissions are inherited from the keyspace and the root data resource
+	for _, resource := range []string{"data", "data/" + keyspace, "data/" + keyspace + "/" + table} {
+		if p.resources[resource][permission] {
+			return true
+		}
+	}
+
+	return false
+}
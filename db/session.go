@@ -61,6 +61,12 @@ type Session interface {
 	// ExecuteIterSimple executes a statement and returns iterator to the result set
 	ExecuteIter(query string, options *QueryOptions, values ...interface{}) (ResultSet, error)
 
+	// ExecuteBatch executes the statements as a single batch of the provided type
+	ExecuteBatch(batchType gocql.BatchType, statements []Statement, options *QueryOptions) error
+
+	// TokenRanges returns the ranges of the token ring, split using the tokens of the hosts
+	TokenRanges() ([]TokenRange, error)
+
 	// ChangeSchema executes a schema change query and waits for schema agreement
 	ChangeSchema(query string, options *QueryOptions) error
 
@@ -111,7 +117,13 @@ func newResultIterator(iter *gocql.Iter) (*goCqlResultIterator, error) {
 }
 
 type GoCqlSession struct {
-	ref *gocql.Session
+	ref  *gocql.Session
+	ring *tokenRingPolicy
+}
+
+func newGoCqlSession(ref *gocql.Session, cluster *gocql.ClusterConfig) *GoCqlSession {
+	ring, _ := cluster.PoolConfig.HostSelectionPolicy.(*tokenRingPolicy)
+	return &GoCqlSession{ref: ref, ring: ring}
 }
 
 func (db *Db) Execute(query string, options *QueryOptions, values ...interface{}) (ResultSet, error) {
@@ -171,6 +183,39 @@ func (session *GoCqlSession) ExecuteIter(query string, options *QueryOptions, va
 	return newResultIterator(q.Iter())
 }
 
+func (session *GoCqlSession) ExecuteBatch(
+	batchType gocql.BatchType,
+	statements []Statement,
+	options *QueryOptions,
+) error {
+	batch := session.ref.NewBatch(batchType)
+	for _, statement := range statements {
+		batch.Query(statement.Query, statement.Values...)
+	}
+
+	if options != nil {
+		batch.SetConsistency(options.Consistency)
+
+		if options.SerialConsistency != gocql.Serial && options.SerialConsistency != gocql.LocalSerial {
+			return errors.New("Invalid serial consistency")
+		}
+
+		batch.SerialConsistency(options.SerialConsistency)
+
+		if options.UserOrRole != "" {
+			batch.CustomPayload = map[string][]byte{
+				"ProxyExecute": []byte(options.UserOrRole),
+			}
+		}
+
+		if options.Context != nil {
+			batch = batch.WithContext(options.Context)
+		}
+	}
+
+	return session.ref.ExecuteBatch(batch)
+}
+
 func (session *GoCqlSession) KeyspaceMetadata(keyspaceName string) (*gocql.KeyspaceMetadata, error) {
 	return session.ref.KeyspaceMetadata(keyspaceName)
 }
@@ -2,6 +2,7 @@ package db
 
 import (
 	"fmt"
+	"github.com/datastax/cassandra-data-apis/types"
 	"github.com/gocql/gocql"
 )
 
@@ -45,6 +46,9 @@ func toTypeString(info gocql.TypeInfo) string {
 				coll.Type().String(), toTypeString(coll.Key), toTypeString(coll.Elem))
 		}
 	}
+	if vectorType, ok := types.AsVectorType(info); ok {
+		return vectorType.String()
+	}
 	return info.Type().String()
 }
 
@@ -0,0 +1,353 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gocql/gocql"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultScanConcurrency is the default maximum number of token range queries in flight for a scan
+const DefaultScanConcurrency = 8
+
+const (
+	murmur3Partitioner = "org.apache.cassandra.dht.Murmur3Partitioner"
+	randomPartitioner  = "org.apache.cassandra.dht.RandomPartitioner"
+)
+
+// TokenRange is a range of the token ring, the start is exclusive and the end is inclusive. A nil Start or End means
+// that the range is unbounded on that side.
+type TokenRange struct {
+	Start interface{}
+	End   interface{}
+}
+
+// ScanFn is invoked with the rows of each page retrieved by a scan
+type ScanFn func(rows []map[string]interface{}) error
+
+// tokenRingPolicy wraps a host selection policy, keeping track of the tokens of each host and the partitioner of the
+// cluster
+type tokenRingPolicy struct {
+	gocql.HostSelectionPolicy
+	mutex       sync.RWMutex
+	partitioner string
+	hosts       map[string]*gocql.HostInfo
+}
+
+func newTokenRingPolicy(policy gocql.HostSelectionPolicy) *tokenRingPolicy {
+	return &tokenRingPolicy{
+		HostSelectionPolicy: policy,
+		hosts:               make(map[string]*gocql.HostInfo),
+	}
+}
+
+func (p *tokenRingPolicy) AddHost(host *gocql.HostInfo) {
+	p.setHost(host)
+	p.HostSelectionPolicy.AddHost(host)
+}
+
+// AddHosts is used by the session to add the initial hosts at once, when supported by the child policy
+func (p *tokenRingPolicy) AddHosts(hosts []*gocql.HostInfo) {
+	for _, host := range hosts {
+		p.setHost(host)
+	}
+
+	if bulkPolicy, ok := p.HostSelectionPolicy.(interface{ AddHosts([]*gocql.HostInfo) }); ok {
+		bulkPolicy.AddHosts(hosts)
+		return
+	}
+
+	for _, host := range hosts {
+		p.HostSelectionPolicy.AddHost(host)
+	}
+}
+
+func (p *tokenRingPolicy) HostUp(host *gocql.HostInfo) {
+	p.setHost(host)
+	p.HostSelectionPolicy.HostUp(host)
+}
+
+func (p *tokenRingPolicy) RemoveHost(host *gocql.HostInfo) {
+	p.mutex.Lock()
+	delete(p.hosts, host.HostID())
+	p.mutex.Unlock()
+	p.HostSelectionPolicy.RemoveHost(host)
+}
+
+func (p *tokenRingPolicy) SetPartitioner(partitioner string) {
+	p.mutex.Lock()
+	p.partitioner = partitioner
+	p.mutex.Unlock()
+	p.HostSelectionPolicy.SetPartitioner(partitioner)
+}
+
+func (p *tokenRingPolicy) setHost(host *gocql.HostInfo) {
+	p.mutex.Lock()
+	p.hosts[host.HostID()] = host
+	p.mutex.Unlock()
+}
+
+// tokenRanges splits the ring using the tokens of the hosts
+func (p *tokenRingPolicy) tokenRanges() ([]TokenRange, error) {
+	p.mutex.RLock()
+	partitioner := p.partitioner
+	tokens := make([]string, 0, len(p.hosts))
+	for _, host := range p.hosts {
+		if partitioner == "" {
+			partitioner = host.Partitioner()
+		}
+		tokens = append(tokens, host.Tokens()...)
+	}
+	p.mutex.RUnlock()
+
+	return ringRanges(partitioner, tokens)
+}
+
+// ringRanges returns the ranges between consecutive tokens of the ring, including the ranges before the first token
+// and after the last one
+func ringRanges(partitioner string, tokens []string) ([]TokenRange, error) {
+	if len(tokens) == 0 {
+		// Without token metadata, the ring is a single range
+		return []TokenRange{{}}, nil
+	}
+
+	var sorted []interface{}
+	switch partitioner {
+	case murmur3Partitioner:
+		values := make([]int64, 0, len(tokens))
+		for _, token := range tokens {
+			value, err := strconv.ParseInt(token, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid token '%s': %s", token, err)
+			}
+			values = append(values, value)
+		}
+		sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+		for i, value := range values {
+			if i == 0 || value != values[i-1] {
+				sorted = append(sorted, value)
+			}
+		}
+	case randomPartitioner:
+		values := make([]*big.Int, 0, len(tokens))
+		for _, token := range tokens {
+			value, ok := new(big.Int).SetString(token, 10)
+			if !ok {
+				return nil, fmt.Errorf("invalid token '%s'", token)
+			}
+			values = append(values, value)
+		}
+		sort.Slice(values, func(i, j int) bool { return values[i].Cmp(values[j]) < 0 })
+		for i, value := range values {
+			if i == 0 || value.Cmp(values[i-1]) != 0 {
+				sorted = append(sorted, value)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("token range scans are not supported with partitioner '%s'", partitioner)
+	}
+
+	ranges := make([]TokenRange, 0, len(sorted)+1)
+	var start interface{}
+	for _, token := range sorted {
+		ranges = append(ranges, TokenRange{Start: start, End: token})
+		start = token
+	}
+
+	if value, ok := start.(int64); !ok || value != math.MaxInt64 {
+		ranges = append(ranges, TokenRange{Start: start})
+	}
+
+	return ranges, nil
+}
+
+// tokenCondition returns the condition restricting the token of the partition key to the range, along with the values
+func tokenCondition(table *gocql.TableMetadata, tokenRange TokenRange) (string, []interface{}) {
+	columns := make([]string, len(table.PartitionKey))
+	for i, column := range table.PartitionKey {
+		columns[i] = fmt.Sprintf(`"%s"`, column.Name)
+	}
+	token := fmt.Sprintf("token(%s)", strings.Join(columns, ", "))
+
+	conditions := make([]string, 0, 2)
+	values := make([]interface{}, 0, 2)
+	if tokenRange.Start != nil {
+		conditions = append(conditions, token+" > ?")
+		values = append(values, tokenRange.Start)
+	}
+	if tokenRange.End != nil {
+		conditions = append(conditions, token+" <= ?")
+		values = append(values, tokenRange.End)
+	}
+
+	return strings.Join(conditions, " AND "), values
+}
+
+// Scan reads the rows of the table matching the select info by querying the token ranges of the ring concurrently,
+// using up to concurrency queries in flight. fn is invoked with the rows of each page, one page at a time, in no
+// particular order. Ordering and limits are not supported.
+func (db *Db) Scan(info *SelectInfo, options *QueryOptions, concurrency int, fn ScanFn) error {
+	if len(info.OrderBy) > 0 || (info.Options != nil && info.Options.Limit > 0) {
+		return errors.New("order by and limit are not supported in token range scans")
+	}
+
+	var mutex sync.Mutex
+	return db.scanTokenRanges(info.Keyspace, info.Table, concurrency,
+		func(table *gocql.TableMetadata, tokenRange TokenRange) error {
+			condition, conditionValues := tokenCondition(table, tokenRange)
+			statement, err := selectStatement(info, condition, conditionValues...)
+			if err != nil {
+				return err
+			}
+
+			var pageState []byte
+			for {
+				rs, err := db.session.ExecuteIter(
+					statement.Query, scanOptions(options, pageState), statement.Values...)
+				if err != nil {
+					return err
+				}
+
+				mutex.Lock()
+				err = fn(rs.Values())
+				mutex.Unlock()
+				if err != nil {
+					return err
+				}
+
+				pageState = rs.PageState()
+				if len(pageState) == 0 {
+					return nil
+				}
+
+				if options != nil && options.Context != nil && options.Context.Err() != nil {
+					return options.Context.Err()
+				}
+			}
+		})
+}
+
+// Count counts the rows of the table by querying the token ranges of the ring concurrently, using up to concurrency
+// queries in flight
+func (db *Db) Count(keyspace string, table string, options *QueryOptions, concurrency int) (int64, error) {
+	var mutex sync.Mutex
+	var count int64
+	err := db.scanTokenRanges(keyspace, table, concurrency,
+		func(tableMetadata *gocql.TableMetadata, tokenRange TokenRange) error {
+			query := fmt.Sprintf(`SELECT COUNT(*) FROM "%s"."%s"`, keyspace, table)
+			condition, values := tokenCondition(tableMetadata, tokenRange)
+			if condition != "" {
+				query += " WHERE " + condition
+			}
+
+			rs, err := db.session.ExecuteIter(query, scanOptions(options, nil), values...)
+			if err != nil {
+				return err
+			}
+
+			for _, row := range rs.Values() {
+				if value, ok := row["count"].(*string); ok && value != nil {
+					rangeCount, err := strconv.ParseInt(*value, 10, 64)
+					if err != nil {
+						return err
+					}
+					mutex.Lock()
+					count += rangeCount
+					mutex.Unlock()
+				}
+			}
+			return nil
+		})
+
+	return count, err
+}
+
+// scanTokenRanges invokes fn for each token range of the ring using a bounded pool of workers, it stops at the first
+// error
+func (db *Db) scanTokenRanges(
+	keyspace string,
+	table string,
+	concurrency int,
+	fn func(table *gocql.TableMetadata, tokenRange TokenRange) error,
+) error {
+	tableMetadata, err := db.Table(keyspace, table)
+	if err != nil {
+		return err
+	}
+
+	tokenRanges, err := db.session.TokenRanges()
+	if err != nil {
+		return err
+	}
+
+	if concurrency <= 0 {
+		concurrency = DefaultScanConcurrency
+	}
+	if concurrency > len(tokenRanges) {
+		concurrency = len(tokenRanges)
+	}
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	ranges := make(chan TokenRange)
+	done := make(chan struct{})
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for tokenRange := range ranges {
+				select {
+				case <-done:
+					// Skip the remaining ranges after an error
+					continue
+				default:
+				}
+
+				if err := fn(tableMetadata, tokenRange); err != nil {
+					errOnce.Do(func() {
+						firstErr = err
+						close(done)
+					})
+				}
+			}
+		}()
+	}
+
+send:
+	for _, tokenRange := range tokenRanges {
+		select {
+		case ranges <- tokenRange:
+		case <-done:
+			break send
+		}
+	}
+	close(ranges)
+	wg.Wait()
+
+	return firstErr
+}
+
+func scanOptions(options *QueryOptions, pageState []byte) *QueryOptions {
+	result := NewQueryOptions()
+	if options != nil {
+		// Each range uses its own copy of the options
+		*result = *options
+	}
+	result.PageState = pageState
+	return result
+}
+
+func (session *GoCqlSession) TokenRanges() ([]TokenRange, error) {
+	if session.ring == nil {
+		return nil, errors.New("token metadata is not available")
+	}
+	return session.ring.tokenRanges()
+}
@@ -0,0 +1,226 @@
+package db
+
+import (
+	"errors"
+	"github.com/gocql/gocql"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+	"gopkg.in/inf.v0"
+	"math"
+	"math/big"
+	"sync"
+)
+
+var _ = Describe("ringRanges()", func() {
+	It("Should split the ring using the Murmur3 tokens", func() {
+		ranges, err := ringRanges(murmur3Partitioner, []string{"100", "-50", "100", "0"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ranges).To(Equal([]TokenRange{
+			{Start: nil, End: int64(-50)},
+			{Start: int64(-50), End: int64(0)},
+			{Start: int64(0), End: int64(100)},
+			{Start: int64(100), End: nil},
+		}))
+	})
+
+	It("Should not include an empty range after the maximum Murmur3 token", func() {
+		ranges, err := ringRanges(murmur3Partitioner, []string{"9223372036854775807"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ranges).To(Equal([]TokenRange{{Start: nil, End: int64(math.MaxInt64)}}))
+	})
+
+	It("Should split the ring using the RandomPartitioner tokens", func() {
+		ranges, err := ringRanges(randomPartitioner, []string{"85070591730234615865843651857942052864", "0"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ranges).To(HaveLen(3))
+		Expect(ranges[1].Start).To(Equal(big.NewInt(0)))
+		Expect(ranges[1].End.(*big.Int).String()).To(Equal("85070591730234615865843651857942052864"))
+	})
+
+	It("Should return a single range without tokens", func() {
+		ranges, err := ringRanges("", nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ranges).To(Equal([]TokenRange{{}}))
+	})
+
+	It("Should return an error for unsupported partitioners", func() {
+		_, err := ringRanges("org.apache.cassandra.dht.ByteOrderedPartitioner", []string{"00"})
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("mergeAggregate()", func() {
+	It("Should merge the values using the arithmetic of their types", func() {
+		first, second := "9007199254740993", "2"
+		value, err := mergeAggregate(AggregateSum, gocql.TypeBigInt, &first, &second)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*value.(*string)).To(Equal("9007199254740995"))
+
+		value, err = mergeAggregate(AggregateMax, gocql.TypeBigInt, &first, &second)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(value).To(Equal(&first))
+
+		value, err = mergeAggregate(AggregateMin, gocql.TypeVarint, big.NewInt(3), big.NewInt(-1))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(value).To(Equal(big.NewInt(-1)))
+
+		value, err = mergeAggregate(AggregateSum, gocql.TypeDecimal, inf.NewDec(15, 1), inf.NewDec(25, 2))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(value.(*inf.Dec).String()).To(Equal("1.75"))
+	})
+
+	It("Should compute the averages like the server", func() {
+		count := "4"
+		value, err := averageValue(gocql.TypeDecimal, inf.NewDec(175, 2), &count)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(value.(*inf.Dec).String()).To(Equal("0.44"))
+
+		value, err = averageValue(gocql.TypeVarint, big.NewInt(-7), &count)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(value).To(Equal(big.NewInt(-1)))
+
+		sum := 10.0
+		value, err = averageValue(gocql.TypeDouble, &sum, &count)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*value.(*float64)).To(Equal(2.5))
+
+		zero, empty := 0, "0"
+		value, err = averageValue(gocql.TypeInt, &zero, &empty)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(value).To(Equal(&zero))
+	})
+})
+
+var _ = Describe("Db", func() {
+	var sessionMock *SessionMock
+	var db *Db
+
+	BeforeEach(func() {
+		sessionMock = NewSessionMock().Default()
+		sessionMock.On("TokenRanges").Return([]TokenRange{{End: int64(0)}, {Start: int64(0)}}, nil)
+		db = NewDbWithSession(sessionMock)
+	})
+
+	Describe("Scan()", func() {
+		It("Should query each token range until the last page", func() {
+			firstPage := &ResultMock{}
+			firstPage.On("Values").Return([]map[string]interface{}{{"title": "a"}})
+			firstPage.On("PageState").Return([]byte{1})
+			lastPage := &ResultMock{}
+			lastPage.On("Values").Return([]map[string]interface{}{{"title": "b"}})
+			lastPage.On("PageState").Return([]byte{})
+
+			sessionMock.On("ExecuteIter", `SELECT "title" FROM "store"."books" WHERE token("title") <= ?`,
+				mock.MatchedBy(func(o *QueryOptions) bool { return len(o.PageState) == 0 }), []interface{}{int64(0)}).
+				Return(firstPage, nil)
+			sessionMock.On("ExecuteIter", `SELECT "title" FROM "store"."books" WHERE token("title") <= ?`,
+				mock.MatchedBy(func(o *QueryOptions) bool { return len(o.PageState) == 1 }), []interface{}{int64(0)}).
+				Return(lastPage, nil)
+			sessionMock.On("ExecuteIter", `SELECT "title" FROM "store"."books" WHERE token("title") > ?`,
+				mock.Anything, []interface{}{int64(0)}).
+				Return(lastPage, nil)
+
+			var mutex sync.Mutex
+			titles := make([]interface{}, 0)
+			err := db.Scan(&SelectInfo{Keyspace: "store", Table: "books", Columns: []string{"title"}},
+				NewQueryOptions(), 2, func(rows []map[string]interface{}) error {
+					mutex.Lock()
+					defer mutex.Unlock()
+					for _, row := range rows {
+						titles = append(titles, row["title"])
+					}
+					return nil
+				})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(titles).To(ConsistOf("a", "b", "b"))
+		})
+
+		It("Should return the first error", func() {
+			sessionMock.On("ExecuteIter", mock.Anything, mock.Anything, mock.Anything).
+				Return(&ResultMock{}, errors.New("test error"))
+
+			err := db.Scan(&SelectInfo{Keyspace: "store", Table: "books"}, nil, 0,
+				func(rows []map[string]interface{}) error {
+					return nil
+				})
+			Expect(err).To(MatchError("test error"))
+		})
+	})
+
+	Describe("Count()", func() {
+		It("Should add the counts of the token ranges", func() {
+			for i, query := range []string{
+				`SELECT COUNT(*) FROM "store"."books" WHERE token("title") <= ?`,
+				`SELECT COUNT(*) FROM "store"."books" WHERE token("title") > ?`,
+			} {
+				count := []string{"2", "5"}[i]
+				result := &ResultMock{}
+				result.On("Values").Return([]map[string]interface{}{{"count": &count}})
+				sessionMock.On("ExecuteIter", query, mock.Anything, []interface{}{int64(0)}).Return(result, nil)
+			}
+
+			count, err := db.Count("store", "books", nil, 0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(count).To(Equal(int64(7)))
+		})
+	})
+
+	Describe("ScanAggregates()", func() {
+		newResult := func(count string, min *int, max string, sum int, valueCount string) *ResultMock {
+			result := &ResultMock{}
+			result.On("Values").Return([]map[string]interface{}{{
+				"partial0":       &count,
+				"partial1":       min,
+				"partial2":       &max,
+				"partial3":       &sum,
+				"partial4":       &sum,
+				"partial4_count": &valueCount,
+			}})
+			return result
+		}
+
+		It("Should merge the aggregates of the token ranges", func() {
+			selectors := `SELECT COUNT(*) AS "partial0", MIN("pages") AS "partial1", MAX("title") AS "partial2", ` +
+				`SUM("pages") AS "partial3", SUM("pages") AS "partial4", COUNT("pages") AS "partial4_count" ` +
+				`FROM "store"."books" WHERE `
+			min := 10
+			sessionMock.On("ExecuteIter", selectors+`token("title") <= ?`, mock.Anything, []interface{}{int64(0)}).
+				Return(newResult("2", &min, "b", 30, "2"), nil)
+			sessionMock.On("ExecuteIter", selectors+`token("title") > ?`, mock.Anything, []interface{}{int64(0)}).
+				Return(newResult("4", nil, "a", 27, "1"), nil)
+
+			result, err := db.ScanAggregates("store", "books", []Aggregate{
+				{Function: AggregateCount, Alias: "count"},
+				{Function: AggregateMin, Column: "pages", Alias: "min_pages"},
+				{Function: AggregateMax, Column: "title", Alias: "max_title"},
+				{Function: AggregateSum, Column: "pages", Alias: "sum_pages"},
+				{Function: AggregateAvg, Column: "pages", Alias: "avg_pages"},
+			}, nil, 0)
+			Expect(err).NotTo(HaveOccurred())
+			count, max, sum, avg := "6", "b", 57, 19
+			Expect(result).To(Equal(map[string]interface{}{
+				"count":     &count,
+				"min_pages": &min,
+				"max_title": &max,
+				"sum_pages": &sum,
+				"avg_pages": &avg,
+			}))
+		})
+	})
+
+	Describe("CanScanAggregates()", func() {
+		It("Should only merge the aggregates of the supported types", func() {
+			table, err := db.Table("store", "books")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(CanScanAggregates(table, []Aggregate{
+				{Function: AggregateCount},
+				{Function: AggregateMax, Column: "title"},
+				{Function: AggregateAvg, Column: "pages"},
+			})).To(BeTrue())
+			Expect(CanScanAggregates(table, []Aggregate{{Function: AggregateSum, Column: "title"}})).To(BeFalse())
+			Expect(CanScanAggregates(table, []Aggregate{{Function: AggregateMin, Column: "isbn"}})).To(BeFalse())
+		})
+	})
+
+})
@@ -2,6 +2,7 @@ package db
 
 import (
 	"fmt"
+	"github.com/datastax/cassandra-data-apis/types"
 	"github.com/gocql/gocql"
 	"gopkg.in/inf.v0"
 	"math/big"
@@ -29,43 +30,86 @@ var typeForCqlType = map[gocql.Type]reflect.Type{
 	gocql.TypeTimestamp: reflect.TypeOf(new(time.Time)),
 	gocql.TypeBlob:      reflect.TypeOf(new([]byte)),
 	gocql.TypeTime:      reflect.TypeOf(new(time.Duration)),
+	gocql.TypeDate:      reflect.TypeOf("0"),
+	gocql.TypeDuration:  reflect.TypeOf(new(gocql.Duration)),
 }
 
 func mapScan(scanner gocql.Scanner, columns []gocql.ColumnInfo) (map[string]interface{}, error) {
-	values := make([]interface{}, len(columns))
+	values := make([]interface{}, 0, len(columns))
+
+	for _, column := range columns {
+		typeInfo := column.TypeInfo
+		elems := []gocql.TypeInfo{typeInfo}
+		if tuple, ok := typeInfo.(gocql.TupleTypeInfo); ok {
+			// The driver scans each element of a tuple column into a separate value
+			elems = tuple.Elems
+		}
 
-	for i := range values {
-		typeInfo := columns[i].TypeInfo
-		allocated := allocateForType(typeInfo)
-		if allocated == nil {
-			return nil, fmt.Errorf("Support for CQL type not found: %s", typeInfo.Type().String())
+		for _, elem := range elems {
+			allocated := allocateForType(elem)
+			if allocated == nil {
+				return nil, fmt.Errorf("Support for CQL type not found: %s", elem.Type().String())
+			}
+			values = append(values, allocated)
 		}
-		values[i] = allocated
 	}
 
 	if err := scanner.Scan(values...); err != nil {
 		return nil, err
 	}
 
-	mapped := make(map[string]interface{}, len(values))
-	for i, column := range columns {
-		value := values[i]
-		switch column.TypeInfo.Type() {
-		case gocql.TypeVarchar, gocql.TypeAscii, gocql.TypeInet, gocql.TypeText,
-			gocql.TypeBigInt, gocql.TypeInt, gocql.TypeSmallInt, gocql.TypeTinyInt,
-			gocql.TypeCounter, gocql.TypeBoolean,
-			gocql.TypeTimeUUID, gocql.TypeUUID,
-			gocql.TypeFloat, gocql.TypeDouble,
-			gocql.TypeDecimal, gocql.TypeVarint, gocql.TypeTimestamp, gocql.TypeBlob, gocql.TypeTime:
-			value = reflect.Indirect(reflect.ValueOf(value)).Interface()
+	mapped := make(map[string]interface{}, len(columns))
+	index := 0
+	for _, column := range columns {
+		tuple, ok := column.TypeInfo.(gocql.TupleTypeInfo)
+		if !ok {
+			mapped[column.Name] = scannedValue(column.TypeInfo, values[index])
+			index++
+			continue
 		}
 
-		mapped[column.Name] = value
+		mapped[column.Name] = tupleValue(tuple, values[index:index+len(tuple.Elems)])
+		index += len(tuple.Elems)
 	}
 
 	return mapped, nil
 }
 
+// scannedValue returns the value of a column or element using the representation of its type
+func scannedValue(info gocql.TypeInfo, value interface{}) interface{} {
+	switch info.Type() {
+	case gocql.TypeVarchar, gocql.TypeAscii, gocql.TypeInet, gocql.TypeText,
+		gocql.TypeBigInt, gocql.TypeInt, gocql.TypeSmallInt, gocql.TypeTinyInt,
+		gocql.TypeCounter, gocql.TypeBoolean,
+		gocql.TypeTimeUUID, gocql.TypeUUID,
+		gocql.TypeFloat, gocql.TypeDouble,
+		gocql.TypeDecimal, gocql.TypeVarint, gocql.TypeTimestamp, gocql.TypeBlob, gocql.TypeTime,
+		gocql.TypeDate, gocql.TypeDuration, gocql.TypeUDT, gocql.TypeTuple, gocql.TypeCustom:
+		return reflect.Indirect(reflect.ValueOf(value)).Interface()
+	}
+
+	return value
+}
+
+// tupleValue returns the value of a tuple column from the values of its elements, a tuple that only contains null
+// elements is considered null
+func tupleValue(tuple gocql.TupleTypeInfo, elements []interface{}) *TupleValue {
+	result := make(TupleValue, 0, len(elements))
+	isNull := true
+	for i, element := range elements {
+		value := scannedValue(tuple.Elems[i], element)
+		if rv := reflect.ValueOf(value); rv.Kind() != reflect.Ptr || !rv.IsNil() {
+			isNull = false
+		}
+		result = append(result, value)
+	}
+
+	if isNull {
+		return nil
+	}
+	return &result
+}
+
 func allocateForType(info gocql.TypeInfo) interface{} {
 
 	a := time.Duration(123)
@@ -103,6 +147,20 @@ func allocateForType(info gocql.TypeInfo) interface{} {
 		return new(*[]byte)
 	case gocql.TypeTime:
 		return new(*time.Duration)
+	case gocql.TypeDate:
+		// Mapped to a json string, formatted as yyyy-mm-dd
+		return new(*string)
+	case gocql.TypeDuration:
+		return new(*gocql.Duration)
+	case gocql.TypeUDT:
+		return new(*UDTValue)
+	case gocql.TypeTuple:
+		return new(*TupleValue)
+	case gocql.TypeCustom:
+		if _, ok := types.AsVectorType(info); ok {
+			return new(*VectorValue)
+		}
+		return nil
 	case gocql.TypeList, gocql.TypeSet:
 		subTypeInfo, ok := info.(gocql.CollectionType)
 		if !ok {
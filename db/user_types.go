@@ -0,0 +1,322 @@
+package db
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/datastax/cassandra-data-apis/types"
+	"github.com/gocql/gocql"
+	"strings"
+)
+
+const userTypesQuery = "SELECT type_name, field_names, field_types FROM system_schema.types WHERE keyspace_name = ?"
+
+// nativeTypes contains the CQL native types by name, as represented in the schema tables
+var nativeTypes = map[string]gocql.Type{
+	"ascii":     gocql.TypeAscii,
+	"bigint":    gocql.TypeBigInt,
+	"blob":      gocql.TypeBlob,
+	"boolean":   gocql.TypeBoolean,
+	"counter":   gocql.TypeCounter,
+	"date":      gocql.TypeDate,
+	"decimal":   gocql.TypeDecimal,
+	"double":    gocql.TypeDouble,
+	"duration":  gocql.TypeDuration,
+	"float":     gocql.TypeFloat,
+	"inet":      gocql.TypeInet,
+	"int":       gocql.TypeInt,
+	"smallint":  gocql.TypeSmallInt,
+	"text":      gocql.TypeText,
+	"time":      gocql.TypeTime,
+	"timestamp": gocql.TypeTimestamp,
+	"timeuuid":  gocql.TypeTimeUUID,
+	"tinyint":   gocql.TypeTinyInt,
+	"uuid":      gocql.TypeUUID,
+	"varchar":   gocql.TypeVarchar,
+	"varint":    gocql.TypeVarint,
+}
+
+// UDTValue contains the values of the fields of a user-defined type by field name, using the same representation as
+// the values of the columns
+type UDTValue map[string]interface{}
+
+// TupleValue contains the values of the elements of a tuple, using the same representation as the values of the
+// columns
+type TupleValue []interface{}
+
+func (v *UDTValue) UnmarshalCQL(info gocql.TypeInfo, data []byte) error {
+	udt, ok := info.(gocql.UDTTypeInfo)
+	if !ok {
+		return fmt.Errorf("can not unmarshal %s into a user-defined type value", info.Type().String())
+	}
+
+	result := make(UDTValue, len(udt.Elements))
+	for _, field := range udt.Elements {
+		var element []byte
+		// Fields added after the value was written are not included
+		element, data = readElement(data)
+		value, err := unmarshalElement(field.Type, element)
+		if err != nil {
+			return err
+		}
+		result[field.Name] = value
+	}
+
+	*v = result
+	return nil
+}
+
+func (v *TupleValue) UnmarshalCQL(info gocql.TypeInfo, data []byte) error {
+	tuple, ok := info.(gocql.TupleTypeInfo)
+	if !ok {
+		return fmt.Errorf("can not unmarshal %s into a tuple value", info.Type().String())
+	}
+
+	result := make(TupleValue, 0, len(tuple.Elems))
+	for _, elem := range tuple.Elems {
+		var element []byte
+		element, data = readElement(data)
+		value, err := unmarshalElement(elem, element)
+		if err != nil {
+			return err
+		}
+		result = append(result, value)
+	}
+
+	*v = result
+	return nil
+}
+
+// readElement reads an element of a user-defined type or tuple value, prefixed by its length, returning nil for
+// null elements
+func readElement(data []byte) ([]byte, []byte) {
+	if len(data) < 4 {
+		return nil, nil
+	}
+
+	size := int32(binary.BigEndian.Uint32(data))
+	data = data[4:]
+	if size < 0 || int(size) > len(data) {
+		return nil, data
+	}
+
+	return data[:size], data[size:]
+}
+
+func unmarshalElement(info gocql.TypeInfo, data []byte) (interface{}, error) {
+	allocated := allocateForType(info)
+	if allocated == nil {
+		return nil, fmt.Errorf("Support for CQL type not found: %s", info.Type().String())
+	}
+
+	if err := gocql.Unmarshal(info, data, allocated); err != nil {
+		return nil, err
+	}
+
+	return scannedValue(info, allocated), nil
+}
+
+// userTypeDefinition contains the fields of a user-defined type, as represented in the schema tables
+type userTypeDefinition struct {
+	fieldNames []string
+	fieldTypes []string
+}
+
+type userTypeResolver struct {
+	keyspace    string
+	definitions map[string]userTypeDefinition
+	resolved    map[string]gocql.UDTTypeInfo
+}
+
+// resolveColumnTypes replaces the types of the columns that reference user-defined types or vectors, which the driver
+// metadata exposes as custom types without a name, with the definition of the types. The vector columns include the
+// metadata of their index.
+func (db *Db) resolveColumnTypes(ks *gocql.KeyspaceMetadata) error {
+	db.columnTypesMutex.Lock()
+	defer db.columnTypesMutex.Unlock()
+
+	if db.resolvedKeyspaces[ks.Name] == ks {
+		return nil
+	}
+
+	var columns []*gocql.ColumnMetadata
+	for _, table := range ks.Tables {
+		for _, column := range table.Columns {
+			if hasCustomType(column.Type) {
+				columns = append(columns, column)
+			}
+		}
+	}
+
+	if len(columns) > 0 {
+		resolver, err := db.newUserTypeResolver(ks.Name)
+		if err != nil {
+			return err
+		}
+
+		var vectorColumns []*gocql.ColumnMetadata
+		for _, column := range columns {
+			column.Type = resolver.parseType(column.Validator)
+			if _, ok := column.Type.(types.VectorType); ok {
+				vectorColumns = append(vectorColumns, column)
+			}
+		}
+
+		if len(vectorColumns) > 0 {
+			if err := db.resolveVectorIndexes(ks.Name, vectorColumns); err != nil {
+				return err
+			}
+		}
+	}
+
+	if db.resolvedKeyspaces == nil {
+		db.resolvedKeyspaces = make(map[string]*gocql.KeyspaceMetadata)
+	}
+	db.resolvedKeyspaces[ks.Name] = ks
+	return nil
+}
+
+func (db *Db) newUserTypeResolver(keyspace string) (*userTypeResolver, error) {
+	iter, err := db.session.ExecuteIter(userTypesQuery, nil, keyspace)
+	if err != nil {
+		return nil, err
+	}
+
+	definitions := make(map[string]userTypeDefinition, len(iter.Values()))
+	for _, row := range iter.Values() {
+		definitions[*row["type_name"].(*string)] = userTypeDefinition{
+			fieldNames: *row["field_names"].(*[]string),
+			fieldTypes: *row["field_types"].(*[]string),
+		}
+	}
+
+	return &userTypeResolver{
+		keyspace:    keyspace,
+		definitions: definitions,
+		resolved:    make(map[string]gocql.UDTTypeInfo, len(definitions)),
+	}, nil
+}
+
+// parseType returns the type info of a CQL type, as represented in the schema tables
+func (r *userTypeResolver) parseType(name string) gocql.TypeInfo {
+	name = strings.TrimSpace(name)
+	start := strings.Index(name, "<")
+	if start < 0 || !strings.HasSuffix(name, ">") {
+		return r.namedType(name)
+	}
+
+	kind := name[:start]
+	subTypes := splitTypes(name[start+1 : len(name)-1])
+	switch {
+	case kind == "frozen" && len(subTypes) == 1:
+		return r.parseType(subTypes[0])
+	case (kind == "list" || kind == "set") && len(subTypes) == 1:
+		typ := gocql.TypeList
+		if kind == "set" {
+			typ = gocql.TypeSet
+		}
+		return gocql.CollectionType{
+			NativeType: gocql.NewNativeType(0, typ, ""),
+			Elem:       r.parseType(subTypes[0]),
+		}
+	case kind == "map" && len(subTypes) == 2:
+		return gocql.CollectionType{
+			NativeType: gocql.NewNativeType(0, gocql.TypeMap, ""),
+			Key:        r.parseType(subTypes[0]),
+			Elem:       r.parseType(subTypes[1]),
+		}
+	case kind == "vector":
+		if vectorType, ok := types.ParseVectorType(name); ok {
+			return vectorType
+		}
+	case kind == "tuple":
+		elems := make([]gocql.TypeInfo, 0, len(subTypes))
+		for _, subType := range subTypes {
+			elems = append(elems, r.parseType(subType))
+		}
+		return gocql.TupleTypeInfo{
+			NativeType: gocql.NewNativeType(0, gocql.TypeTuple, ""),
+			Elems:      elems,
+		}
+	}
+
+	return gocql.NewNativeType(0, gocql.TypeCustom, name)
+}
+
+// namedType returns the type info of a native type or a user-defined type of the keyspace
+func (r *userTypeResolver) namedType(name string) gocql.TypeInfo {
+	if typ, ok := nativeTypes[name]; ok {
+		return gocql.NewNativeType(0, typ, "")
+	}
+
+	name = unquoteName(name)
+
+	if udt, ok := r.resolved[name]; ok {
+		return udt
+	}
+
+	definition, ok := r.definitions[name]
+	if !ok {
+		return gocql.NewNativeType(0, gocql.TypeCustom, name)
+	}
+
+	udt := gocql.UDTTypeInfo{
+		NativeType: gocql.NewNativeType(0, gocql.TypeUDT, ""),
+		KeySpace:   r.keyspace,
+		Name:       name,
+		Elements:   make([]gocql.UDTField, 0, len(definition.fieldNames)),
+	}
+	for i, fieldName := range definition.fieldNames {
+		udt.Elements = append(udt.Elements, gocql.UDTField{
+			Name: fieldName,
+			Type: r.parseType(definition.fieldTypes[i]),
+		})
+	}
+
+	r.resolved[name] = udt
+	return udt
+}
+
+// splitTypes splits the comma separated sub types of a type, ignoring the commas of nested types
+func splitTypes(value string) []string {
+	var result []string
+	depth := 0
+	start := 0
+	for i, c := range value {
+		switch c {
+		case '<':
+			depth++
+		case '>':
+			depth--
+		case ',':
+			if depth == 0 {
+				result = append(result, strings.TrimSpace(value[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(result, strings.TrimSpace(value[start:]))
+}
+
+// unquoteName returns the name of a type or column as represented in the schema tables, where case sensitive names
+// are quoted
+func unquoteName(name string) string {
+	if strings.HasPrefix(name, `"`) && strings.HasSuffix(name, `"`) && len(name) > 1 {
+		return strings.ReplaceAll(name[1:len(name)-1], `""`, `"`)
+	}
+	return name
+}
+
+func hasCustomType(info gocql.TypeInfo) bool {
+	switch t := info.(type) {
+	case gocql.CollectionType:
+		return (t.Key != nil && hasCustomType(t.Key)) || hasCustomType(t.Elem)
+	case gocql.TupleTypeInfo:
+		for _, elem := range t.Elems {
+			if hasCustomType(elem) {
+				return true
+			}
+		}
+		return false
+	}
+	return info.Type() == gocql.TypeCustom
+}
@@ -0,0 +1,121 @@
+package db
+
+import (
+	"github.com/datastax/cassandra-data-apis/types"
+	"github.com/gocql/gocql"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("User-defined types", func() {
+	newSession := func() *SessionMock {
+		ks := NewKeyspaceMock("ks1", map[string][]*gocql.ColumnMetadata{
+			"tbl1": {
+				{Name: "id", Kind: gocql.ColumnPartitionKey, Type: gocql.NewNativeType(0, gocql.TypeInt, "")},
+				{Name: "address", Kind: gocql.ColumnRegular, Validator: "frozen<address>",
+					Type: gocql.NewNativeType(0, gocql.TypeCustom, "")},
+				{Name: "previous", Kind: gocql.ColumnRegular, Validator: "list<frozen<address>>",
+					Type: gocql.CollectionType{
+						NativeType: gocql.NewNativeType(0, gocql.TypeList, ""),
+						Elem:       gocql.NewNativeType(0, gocql.TypeCustom, ""),
+					}},
+				{Name: "location", Kind: gocql.ColumnRegular, Validator: "frozen<tuple<float, frozen<\"Zip\">>>",
+					Type: gocql.TupleTypeInfo{
+						NativeType: gocql.NewNativeType(0, gocql.TypeTuple, ""),
+						Elems: []gocql.TypeInfo{
+							gocql.NewNativeType(0, gocql.TypeFloat, ""),
+							gocql.NewNativeType(0, gocql.TypeCustom, ""),
+						},
+					}},
+			},
+		})
+
+		session := NewSessionMock()
+		session.AddKeyspace(ks)
+		session.AddUserTypes([]UserTypeMock{
+			{"address", []string{"street", "zip"}, []string{"text", `frozen<"Zip">`}},
+			{"Zip", []string{"code", "tags"}, []string{"int", "map<text, bigint>"}},
+		})
+		return session
+	}
+
+	zipType := gocql.UDTTypeInfo{
+		NativeType: gocql.NewNativeType(0, gocql.TypeUDT, ""),
+		KeySpace:   "ks1",
+		Name:       "Zip",
+		Elements: []gocql.UDTField{
+			{Name: "code", Type: gocql.NewNativeType(0, gocql.TypeInt, "")},
+			{Name: "tags", Type: gocql.CollectionType{
+				NativeType: gocql.NewNativeType(0, gocql.TypeMap, ""),
+				Key:        gocql.NewNativeType(0, gocql.TypeText, ""),
+				Elem:       gocql.NewNativeType(0, gocql.TypeBigInt, ""),
+			}},
+		},
+	}
+	addressType := gocql.UDTTypeInfo{
+		NativeType: gocql.NewNativeType(0, gocql.TypeUDT, ""),
+		KeySpace:   "ks1",
+		Name:       "address",
+		Elements: []gocql.UDTField{
+			{Name: "street", Type: gocql.NewNativeType(0, gocql.TypeText, "")},
+			{Name: "zip", Type: zipType},
+		},
+	}
+
+	It("Should resolve the user-defined types of the columns", func() {
+		session := newSession()
+		db := NewDbWithSession(session)
+
+		table, err := db.Table("ks1", "tbl1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(table.Columns["id"].Type).To(Equal(gocql.NewNativeType(0, gocql.TypeInt, "")))
+		Expect(table.Columns["address"].Type).To(Equal(addressType))
+		Expect(table.Columns["previous"].Type).To(Equal(gocql.CollectionType{
+			NativeType: gocql.NewNativeType(0, gocql.TypeList, ""),
+			Elem:       addressType,
+		}))
+		Expect(table.Columns["location"].Type).To(Equal(gocql.TupleTypeInfo{
+			NativeType: gocql.NewNativeType(0, gocql.TypeTuple, ""),
+			Elems:      []gocql.TypeInfo{gocql.NewNativeType(0, gocql.TypeFloat, ""), zipType},
+		}))
+
+		// The types are only retrieved once per keyspace metadata
+		_, err = db.Table("ks1", "tbl1")
+		Expect(err).NotTo(HaveOccurred())
+		session.AssertNumberOfCalls(GinkgoT(), "ExecuteIter", 1)
+	})
+
+	It("Should unmarshal the values of user-defined types and tuples", func() {
+		// Types must include the protocol version to marshal the values
+		zip := gocql.UDTTypeInfo{
+			NativeType: gocql.NewNativeType(4, gocql.TypeUDT, ""),
+			Name:       "Zip",
+			Elements: []gocql.UDTField{
+				{Name: "code", Type: gocql.NewNativeType(4, gocql.TypeInt, "")},
+				{Name: "tags", Type: gocql.CollectionType{
+					NativeType: gocql.NewNativeType(4, gocql.TypeMap, ""),
+					Key:        gocql.NewNativeType(4, gocql.TypeText, ""),
+					Elem:       gocql.NewNativeType(4, gocql.TypeBigInt, ""),
+				}},
+			},
+		}
+		tuple := gocql.TupleTypeInfo{
+			NativeType: gocql.NewNativeType(4, gocql.TypeTuple, ""),
+			Elems:      []gocql.TypeInfo{gocql.NewNativeType(4, gocql.TypeText, ""), zip},
+		}
+
+		data, err := gocql.Marshal(tuple, types.TupleParameter{
+			"a", types.UDTParameter{"tags": map[string]int64{"b": 1}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		var value TupleValue
+		Expect(gocql.Unmarshal(tuple, data, &value)).To(Succeed())
+
+		text := "a"
+		tags := map[string]string{"b": "1"}
+		Expect(value).To(HaveLen(2))
+		Expect(value[0]).To(Equal(&text))
+		Expect(*value[1].(*UDTValue)).To(Equal(UDTValue{"code": (*int)(nil), "tags": &tags}))
+	})
+})
@@ -0,0 +1,100 @@
+package db
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/datastax/cassandra-data-apis/types"
+	"github.com/gocql/gocql"
+	"math"
+	"strings"
+)
+
+const vectorIndexesQuery = "SELECT table_name, index_name, options FROM system_schema.indexes WHERE keyspace_name = ?"
+
+// storageAttachedIndexClass is the class name of the indexes that support the approximate nearest neighbor search
+// of vectors
+const storageAttachedIndexClass = "org.apache.cassandra.index.sai.StorageAttachedIndex"
+
+const defaultSimilarityFunction = "cosine"
+
+// SimilarityColumn is the name of the similarity score in the rows returned by Select, when ordering by similarity
+const SimilarityColumn = "_similarity"
+
+// similarityFunctions contains the CQL function to compute the similarity score by index similarity function name
+var similarityFunctions = map[string]string{
+	"cosine":      "SIMILARITY_COSINE",
+	"euclidean":   "SIMILARITY_EUCLIDEAN",
+	"dot_product": "SIMILARITY_DOT_PRODUCT",
+}
+
+// VectorValue contains the values of a vector, using the same representation as float columns
+type VectorValue []float32
+
+func (v *VectorValue) UnmarshalCQL(info gocql.TypeInfo, data []byte) error {
+	vectorType, ok := types.AsVectorType(info)
+	if !ok {
+		return fmt.Errorf("can not unmarshal %s into a vector value", info.Type().String())
+	}
+
+	if len(data) != 4*vectorType.Dimensions {
+		return fmt.Errorf("expected %d bytes for a vector of %d dimensions, got %d",
+			4*vectorType.Dimensions, vectorType.Dimensions, len(data))
+	}
+
+	result := make(VectorValue, 0, vectorType.Dimensions)
+	for i := 0; i < len(data); i += 4 {
+		result = append(result, math.Float32frombits(binary.BigEndian.Uint32(data[i:])))
+	}
+
+	*v = result
+	return nil
+}
+
+// VectorSimilarityFunction returns the similarity function of the index of a vector column, the rows can only be
+// ordered by similarity to a vector when the column has a storage-attached index
+func VectorSimilarityFunction(column *gocql.ColumnMetadata) (string, bool) {
+	if _, ok := column.Type.(types.VectorType); !ok || !isStorageAttachedIndex(column.Index.Options) {
+		return "", false
+	}
+
+	if function, ok := column.Index.Options["similarity_function"].(string); ok && function != "" {
+		return strings.ToLower(function), true
+	}
+	return defaultSimilarityFunction, true
+}
+
+// resolveVectorIndexes retrieves the indexes of the vector columns, which are not included in the driver metadata
+func (db *Db) resolveVectorIndexes(keyspace string, columns []*gocql.ColumnMetadata) error {
+	iter, err := db.session.ExecuteIter(vectorIndexesQuery, nil, keyspace)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range iter.Values() {
+		tableName := *row["table_name"].(*string)
+		options := *row["options"].(*map[string]string)
+		for _, column := range columns {
+			if column.Table != tableName || unquoteName(options["target"]) != column.Name {
+				continue
+			}
+
+			column.Index = gocql.ColumnIndexMetadata{
+				Name:    *row["index_name"].(*string),
+				Type:    "CUSTOM",
+				Options: make(map[string]interface{}, len(options)),
+			}
+			for key, value := range options {
+				column.Index.Options[key] = value
+			}
+		}
+	}
+
+	return nil
+}
+
+func isStorageAttachedIndex(options map[string]interface{}) bool {
+	className, _ := options["class_name"].(string)
+	// The short name can be used when creating the index
+	return className == storageAttachedIndexClass || strings.EqualFold(className, "sai") ||
+		strings.EqualFold(className, "StorageAttachedIndex")
+}
@@ -0,0 +1,72 @@
+package db
+
+import (
+	"github.com/datastax/cassandra-data-apis/types"
+	"github.com/gocql/gocql"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Vectors", func() {
+	It("Should resolve the vector columns and their indexes", func() {
+		ks := NewKeyspaceMock("ks1", map[string][]*gocql.ColumnMetadata{
+			"tbl1": {
+				{Name: "id", Kind: gocql.ColumnPartitionKey, Type: gocql.NewNativeType(0, gocql.TypeInt, "")},
+				{Name: "embedding", Kind: gocql.ColumnRegular, Validator: "vector<float, 3>",
+					Type: gocql.NewNativeType(0, gocql.TypeCustom, "")},
+				{Name: "Other", Kind: gocql.ColumnRegular, Validator: "vector<float, 2>",
+					Type: gocql.NewNativeType(0, gocql.TypeCustom, "")},
+				{Name: "unindexed", Kind: gocql.ColumnRegular, Validator: "vector<float, 2>",
+					Type: gocql.NewNativeType(0, gocql.TypeCustom, "")},
+			},
+		})
+
+		session := NewSessionMock()
+		session.AddKeyspace(ks)
+		session.AddUserTypes(nil)
+		session.AddIndexes([]IndexMock{
+			{"tbl1", "embedding_idx", map[string]string{
+				"class_name": "org.apache.cassandra.index.sai.StorageAttachedIndex",
+				"target":     "embedding",
+			}},
+			{"tbl1", "other_idx", map[string]string{
+				"class_name":          "sai",
+				"target":              `"Other"`,
+				"similarity_function": "DOT_PRODUCT",
+			}},
+		})
+		db := NewDbWithSession(session)
+
+		table, err := db.Table("ks1", "tbl1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(table.Columns["embedding"].Type).To(Equal(types.NewVectorType(3)))
+		Expect(table.Columns["Other"].Type).To(Equal(types.NewVectorType(2)))
+
+		function, ok := VectorSimilarityFunction(table.Columns["embedding"])
+		Expect(ok).To(BeTrue())
+		Expect(function).To(Equal("cosine"))
+		function, ok = VectorSimilarityFunction(table.Columns["Other"])
+		Expect(ok).To(BeTrue())
+		Expect(function).To(Equal("dot_product"))
+		_, ok = VectorSimilarityFunction(table.Columns["unindexed"])
+		Expect(ok).To(BeFalse())
+		_, ok = VectorSimilarityFunction(table.Columns["id"])
+		Expect(ok).To(BeFalse())
+	})
+
+	It("Should unmarshal the values of vectors", func() {
+		// Vectors are sent as custom types in the metadata of the results
+		info := gocql.NewNativeType(4, gocql.TypeCustom,
+			"org.apache.cassandra.db.marshal.VectorType(org.apache.cassandra.db.marshal.FloatType, 3)")
+
+		data, err := gocql.Marshal(info, types.VectorParameter{1.5, -2, 0})
+		Expect(err).NotTo(HaveOccurred())
+
+		var value VectorValue
+		Expect(gocql.Unmarshal(info, data, &value)).To(Succeed())
+		Expect(value).To(Equal(VectorValue{1.5, -2, 0}))
+
+		_, err = gocql.Marshal(info, types.VectorParameter{1.5})
+		Expect(err).To(HaveOccurred())
+	})
+})
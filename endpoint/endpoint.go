@@ -1,6 +1,8 @@
 package endpoint
 
 import (
+	"github.com/datastax/cassandra-data-apis/audit"
+	"github.com/datastax/cassandra-data-apis/auth"
 	"github.com/datastax/cassandra-data-apis/config"
 	"github.com/datastax/cassandra-data-apis/db"
 	"github.com/datastax/cassandra-data-apis/graphql"
@@ -11,7 +13,10 @@ import (
 	"time"
 )
 
-const DefaultSchemaUpdateDuration = 10 * time.Second
+const (
+	DefaultSchemaUpdateDuration = 10 * time.Second
+	DefaultRoleSchemaCacheSize  = 1000
+)
 
 type DataEndpointConfig struct {
 	dbConfig          db.Config
@@ -22,6 +27,12 @@ type DataEndpointConfig struct {
 	useUserOrRoleAuth bool
 	logger            log.Logger
 	routerInfo        config.HttpRouterInfo
+	policy            *auth.Policy
+	useRoleSchemas    bool
+	roleSchemaSize    int
+	graphQLLimits     config.GraphQLLimits
+	auditor           *audit.Auditor
+	allowFiltering    config.AllowFilteringSettings
 }
 
 func (cfg DataEndpointConfig) ExcludedKeyspaces() []string {
@@ -52,6 +63,30 @@ func (cfg DataEndpointConfig) RouterInfo() config.HttpRouterInfo {
 	return cfg.routerInfo
 }
 
+func (cfg DataEndpointConfig) Policy() *auth.Policy {
+	return cfg.policy
+}
+
+func (cfg DataEndpointConfig) UseRoleSchemas() bool {
+	return cfg.useRoleSchemas
+}
+
+func (cfg DataEndpointConfig) RoleSchemaCacheSize() int {
+	return cfg.roleSchemaSize
+}
+
+func (cfg DataEndpointConfig) GraphQLLimits() config.GraphQLLimits {
+	return cfg.graphQLLimits
+}
+
+func (cfg DataEndpointConfig) Auditor() *audit.Auditor {
+	return cfg.auditor
+}
+
+func (cfg DataEndpointConfig) AllowFiltering() config.AllowFilteringSettings {
+	return cfg.allowFiltering
+}
+
 func (cfg *DataEndpointConfig) WithExcludedKeyspaces(ksExcluded []string) *DataEndpointConfig {
 	cfg.ksExcluded = ksExcluded
 	return cfg
@@ -83,6 +118,46 @@ func (cfg *DataEndpointConfig) WithRouterInfo(routerInfo config.HttpRouterInfo)
 	return cfg
 }
 
+// WithPolicy sets the authorization policy used to grant access to keyspaces, tables and columns.
+// When not set, all the operations are allowed.
+func (cfg *DataEndpointConfig) WithPolicy(policy *auth.Policy) *DataEndpointConfig {
+	cfg.policy = policy
+	return cfg
+}
+
+// WithRoleSchemas sets whether a GraphQL schema containing only the tables that the role can SELECT or MODIFY should be
+// built for each user or role. It requires user or role auth to be enabled.
+func (cfg *DataEndpointConfig) WithRoleSchemas(useRoleSchemas bool) *DataEndpointConfig {
+	cfg.useRoleSchemas = useRoleSchemas
+	return cfg
+}
+
+// WithRoleSchemaCacheSize sets the maximum number of role schemas that are kept in memory
+func (cfg *DataEndpointConfig) WithRoleSchemaCacheSize(size int) *DataEndpointConfig {
+	cfg.roleSchemaSize = size
+	return cfg
+}
+
+// WithGraphQLLimits sets the limits on depth, aliases and requested rows of the GraphQL queries
+func (cfg *DataEndpointConfig) WithGraphQLLimits(limits config.GraphQLLimits) *DataEndpointConfig {
+	cfg.graphQLLimits = limits
+	return cfg
+}
+
+// WithAuditor sets the auditor that records the mutations and schema changes. When not set, no audit events are
+// emitted.
+func (cfg *DataEndpointConfig) WithAuditor(auditor *audit.Auditor) *DataEndpointConfig {
+	cfg.auditor = auditor
+	return cfg
+}
+
+// WithAllowFiltering sets the tables that accept queries using ALLOW FILTERING. When not set, the queries using it are
+// rejected.
+func (cfg *DataEndpointConfig) WithAllowFiltering(settings config.AllowFilteringSettings) *DataEndpointConfig {
+	cfg.allowFiltering = settings
+	return cfg
+}
+
 func (cfg DataEndpointConfig) NewEndpoint() (*DataEndpoint, error) {
 	dbClient, err := db.NewDb(cfg.dbConfig, cfg.dbHosts...)
 	if err != nil {
@@ -93,12 +168,14 @@ func (cfg DataEndpointConfig) NewEndpoint() (*DataEndpoint, error) {
 
 func (cfg DataEndpointConfig) newEndpointWithDb(dbClient *db.Db) *DataEndpoint {
 	return &DataEndpoint{
+		dbClient:        dbClient,
 		graphQLRouteGen: graphql.NewRouteGenerator(dbClient, cfg),
 		restRouteGen:    rest.NewRouteGenerator(dbClient, cfg),
 	}
 }
 
 type DataEndpoint struct {
+	dbClient        *db.Db
 	graphQLRouteGen *graphql.RouteGenerator
 	restRouteGen    *rest.RouteGenerator
 }
@@ -118,9 +195,15 @@ func NewEndpointConfigWithLogger(logger log.Logger, hosts ...string) *DataEndpoi
 		naming:         config.NewDefaultNaming,
 		logger:         logger,
 		routerInfo:     config.DefaultRouterInfo(),
+		roleSchemaSize: DefaultRoleSchemaCacheSize,
 	}
 }
 
+// DbClient gets the database client used by the endpoint
+func (e *DataEndpoint) DbClient() *db.Db {
+	return e.dbClient
+}
+
 func (e *DataEndpoint) RoutesGraphQL(pattern string) ([]types.Route, error) {
 	return e.graphQLRouteGen.Routes(pattern, "")
 }
@@ -145,3 +228,8 @@ func (e *DataEndpoint) Keyspaces() ([]string, error) {
 func (e *DataEndpoint) RoutesRest(pattern string, operations config.SchemaOperations, singleKs string) []types.Route {
 	return e.restRouteGen.Routes(pattern, operations, singleKs)
 }
+
+// RoutesRestAuth gets the route that issues auth tokens, validating the provided credentials against the cluster
+func (e *DataEndpoint) RoutesRestAuth(pattern string, tokens *auth.TokenStore) []types.Route {
+	return e.restRouteGen.RoutesAuth(pattern, tokens)
+}
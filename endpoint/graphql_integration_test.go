@@ -391,6 +391,36 @@ var _ = Describe("DataEndpoint", func() {
 				}
 			})
 
+			It("Should support user-defined types and tuples", func() {
+				routes := getRoutes(config, keyspace)
+				schemas.ExecutePost(routes, "/graphql", `mutation {
+				  insertTblUdt(value: {
+				    id: 1, address: {line1: "Main St"}, contact: {name: "a", addresses: [{line1: "b", line2: "c"}]}
+				  }) { applied }
+				  insertTblTuple(value: {id: 1, location: {item0: 1.5, item1: -2}}) { applied }
+				}`)
+
+				buffer := schemas.ExecutePost(routes, "/graphql", `query {
+				  tblUdt(value: {id: 1}) { values { address { line1 line2 } contact { name addresses { line1 line2 } } } }
+				}`)
+				values := schemas.DecodeDataAsSliceOfMaps(buffer, "tblUdt", "values")
+				Expect(values).To(ConsistOf(map[string]interface{}{
+					"address": map[string]interface{}{"line1": "Main St", "line2": nil},
+					"contact": map[string]interface{}{
+						"name":      "a",
+						"addresses": []interface{}{map[string]interface{}{"line1": "b", "line2": "c"}},
+					},
+				}))
+
+				buffer = schemas.ExecutePost(routes, "/graphql", `query {
+				  tblTuple(value: {id: 1}) { values { location { item0 item1 } } }
+				}`)
+				values = schemas.DecodeDataAsSliceOfMaps(buffer, "tblTuple", "values")
+				Expect(values).To(ConsistOf(map[string]interface{}{
+					"location": map[string]interface{}{"item0": 1.5, "item1": float64(-2)},
+				}))
+			})
+
 			It("Should support case sensitive column names", func() {
 				routes := getRoutes(config, keyspace)
 				quirky.InsertWeirdCase(routes, 1)
@@ -600,6 +630,20 @@ var _ = Describe("DataEndpoint", func() {
 				}
 			})
 
+			It("Should support date data type", func() {
+				values := []string{"2020-03-25", "1970-01-01", "1900-12-31"}
+				for _, value := range values {
+					datatypes.MutateAndQueryScalar(routes, "date", "Date!", value, `"%s"`, nil, nil)
+				}
+			})
+
+			It("Should support duration data type", func() {
+				values := []string{"1y2mo3d4h5m6s", "-5d12h", "1h30m", "500ms", "2mo1us3ns"}
+				for _, value := range values {
+					datatypes.MutateAndQueryScalar(routes, "duration", "Duration!", value, `"%s"`, nil, nil)
+				}
+			})
+
 			It("Should timestamp data type", func() {
 				values := []string{"1983-02-23T00:00:50Z", "2010-04-29T23:20:21.52Z"}
 				for _, value := range values {
@@ -640,6 +684,8 @@ var _ = Describe("DataEndpoint", func() {
 					{"blob", `"ZZZ!"`},
 					{"timestamp", `"ZZZ!"`, "123"},
 					{"time", `"ZZZ!"`, "123"},
+					{"date", `"2020-13-01"`, `"ZZZ!"`, "123"},
+					{"duration", `"1x"`, `"ZZZ!"`, "123"},
 				}
 
 				for _, itemEach := range items {
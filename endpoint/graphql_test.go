@@ -5,22 +5,27 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/datastax/cassandra-data-apis/audit"
 	"github.com/datastax/cassandra-data-apis/auth"
 	"github.com/datastax/cassandra-data-apis/config"
 	"github.com/datastax/cassandra-data-apis/db"
 	"github.com/datastax/cassandra-data-apis/graphql"
 	"github.com/datastax/cassandra-data-apis/internal/testutil"
 	"github.com/datastax/cassandra-data-apis/internal/testutil/schemas"
+	"github.com/datastax/cassandra-data-apis/log"
 	"github.com/datastax/cassandra-data-apis/types"
 	"github.com/gocql/gocql"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
 	"net/http"
 	"net/http/httptest"
 	"path"
+	"strings"
 	"testing"
+	"time"
 )
 
 const (
@@ -354,6 +359,91 @@ func TestDataEndpoint_AuthNotProvided(t *testing.T) {
 	assert.Equal(t, "expected user or role for this operation", resp.Errors[0].Message)
 }
 
+func TestDataEndpoint_Policy(t *testing.T) {
+	policy, err := auth.NewPolicy(auth.PolicyConfig{Roles: []auth.RolePolicyConfig{
+		{Name: "user1", Grants: []auth.GrantConfig{
+			{Keyspace: "store", Table: "books", Permissions: []string{"read"}, Columns: []string{"pages"}},
+		}},
+		{Name: "user2", Grants: []auth.GrantConfig{
+			{Keyspace: "store", Permissions: []string{"all"}, Columns: []string{"pages"}},
+		}},
+	}})
+	assert.NoError(t, err)
+
+	session, routes := createRoutes(t,
+		createConfig(t).WithUseUserOrRoleAuth(true).WithPolicy(policy),
+		"/graphql", "store")
+	routes = withAuth(t, routes, map[string]string{"token1": "user1", "token2": "user2"})
+
+	resultMock := &db.ResultMock{}
+	resultMock.
+		On("PageState").Return([]byte{}).
+		On("Values").Return([]map[string]interface{}{}, nil)
+	session.On("ExecuteIter", mock.Anything, mock.Anything, mock.Anything).Return(resultMock, nil)
+
+	execute := func(token string, query string) schemas.ResponseBody {
+		buffer, err := executePost(routes, "/graphql", graphql.RequestBody{Query: query},
+			http.Header{"X-Cassandra-Token": []string{token}})
+		assert.NoError(t, err, "error executing query")
+		var resp schemas.ResponseBody
+		assert.NoError(t, json.NewDecoder(buffer).Decode(&resp), "error decoding response")
+		return resp
+	}
+
+	resp := execute("token1", bookQuery)
+	assert.Len(t, resp.Errors, 0)
+
+	// Columns not granted to any role are not part of the schema
+	resp = execute("token1", `query { books(value:{title:"abc"}) { values { title firstName } } }`)
+	assert.Len(t, resp.Errors, 1)
+	assert.Contains(t, resp.Errors[0].Message, `Cannot query field "firstName"`)
+
+	resp = execute("token1", `mutation { insertBooks(value:{title:"abc", pages: 1}) { applied } }`)
+	assert.Len(t, resp.Errors, 1)
+	assert.Equal(t, "insert permission on table 'store.books' not granted to 'user1'", resp.Errors[0].Message)
+
+	resp = execute("token2", `mutation { insertBooks(value:{title:"abc", pages: 1}) { applied } }`)
+	assert.Len(t, resp.Errors, 0)
+	session.AssertCalled(t, "ExecuteIter", mock.MatchedBy(func(query string) bool {
+		return strings.HasPrefix(query, `INSERT INTO "store"."books"`)
+	}), mock.Anything, mock.Anything)
+}
+
+func TestDataEndpoint_RoleSchemas(t *testing.T) {
+	session, routes := createRoutes(t,
+		createConfig(t).WithUseUserOrRoleAuth(true).WithRoleSchemas(true),
+		"/graphql", "store")
+	routes = withAuth(t, routes, map[string]string{"token1": "user1", "token2": "user2"})
+
+	session.
+		AddRolePermissions("user1", false, nil, map[string][]string{"data/store": {"SELECT", "MODIFY"}}).
+		AddRolePermissions("user2", false, nil, map[string][]string{"data/store/books": {"MODIFY"}})
+
+	resultMock := &db.ResultMock{}
+	resultMock.
+		On("PageState").Return([]byte{}).
+		On("Values").Return([]map[string]interface{}{}, nil)
+	session.On("ExecuteIter", `SELECT * FROM "store"."books" WHERE "title" = ?`, mock.Anything, mock.Anything).
+		Return(resultMock, nil)
+
+	execute := func(token string) schemas.ResponseBody {
+		buffer, err := executePost(routes, "/graphql", graphql.RequestBody{Query: bookQuery},
+			http.Header{"X-Cassandra-Token": []string{token}})
+		assert.NoError(t, err, "error executing query")
+		var resp schemas.ResponseBody
+		assert.NoError(t, json.NewDecoder(buffer).Decode(&resp), "error decoding response")
+		return resp
+	}
+
+	resp := execute("token1")
+	assert.Len(t, resp.Errors, 0)
+
+	// user2 can't SELECT from books
+	resp = execute("token2")
+	assert.Len(t, resp.Errors, 1)
+	assert.Contains(t, resp.Errors[0].Message, `Cannot query field "books"`)
+}
+
 func executePost(routes []types.Route, target string, body graphql.RequestBody, header http.Header) (*bytes.Buffer, error) {
 	b, err := json.Marshal(body)
 	if err != nil {
@@ -398,6 +488,614 @@ func executeGet(routes []types.Route, target string, params graphql.RequestBody,
 	return w.Body, nil
 }
 
+func TestDataEndpoint_GraphQLLimits(t *testing.T) {
+	cfg := createConfig(t).WithGraphQLLimits(config.GraphQLLimits{MaxAliases: 1, MaxPageSize: 1000})
+	session, routes := createRoutes(t, cfg, "/graphql", "store")
+
+	buffer, err := executePost(routes, "/graphql", graphql.RequestBody{
+		Query: `query { a: books { pageState } b: books { pageState } }`,
+	}, nil)
+	assert.NoError(t, err, "error executing query")
+	var resp schemas.ResponseBody
+	assert.NoError(t, json.NewDecoder(buffer).Decode(&resp))
+	assert.Len(t, resp.Errors, 1)
+	assert.Equal(t, "query contains 2 aliases, exceeding the maximum of 1", resp.Errors[0].Message)
+
+	buffer, err = executePost(routes, "/graphql", graphql.RequestBody{
+		Query: `query { books(options: {pageSize: 2000}) { pageState } }`,
+	}, nil)
+	assert.NoError(t, err, "error executing query")
+	resp = schemas.ResponseBody{}
+	assert.NoError(t, json.NewDecoder(buffer).Decode(&resp))
+	assert.Len(t, resp.Errors, 1)
+	assert.Equal(t, "2000 rows requested for field 'books' exceeds the maximum page size of 1000",
+		resp.Errors[0].Message)
+
+	// Rejected before execution
+	session.AssertNotCalled(t, "ExecuteIter", mock.MatchedBy(func(query string) bool {
+		return strings.HasPrefix(query, `SELECT * FROM "store"."books"`)
+	}), mock.Anything, mock.Anything)
+}
+
+func TestDataEndpoint_Audit(t *testing.T) {
+	var events []audit.Event
+	auditor := audit.NewAuditor(log.NewZapLogger(zap.NewNop()), audit.SinkFunc(func(event audit.Event) error {
+		events = append(events, event)
+		return nil
+	}))
+
+	session, routes := createRoutes(t, createConfig(t).WithUseUserOrRoleAuth(true).WithAuditor(auditor),
+		"/graphql", "store")
+	routes = withAuth(t, routes, map[string]string{"token1": "user1"})
+	for i, route := range routes {
+		routes[i].Handler = audit.NewHandler(route.Handler)
+	}
+
+	resultMock := &db.ResultMock{}
+	resultMock.
+		On("PageState").Return([]byte{}).
+		On("Values").Return([]map[string]interface{}{}, nil)
+	session.On("ExecuteIter", mock.Anything, mock.Anything, mock.Anything).Return(resultMock, nil)
+
+	header := http.Header{"X-Cassandra-Token": []string{"token1"}}
+	_, err := executePost(routes, "/graphql", graphql.RequestBody{Query: bookQuery}, header)
+	assert.NoError(t, err)
+	// Queries are not audited
+	assert.Len(t, events, 0)
+
+	_, err = executePost(routes, "/graphql", graphql.RequestBody{
+		Query: `mutation { insertBooks(value:{title:"abc", pages: 1}) { applied } }`,
+	}, header)
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	event := events[0]
+	assert.False(t, event.Time.IsZero())
+	assert.Equal(t, "user1", event.UserOrRole)
+	assert.Equal(t, "192.0.2.1", event.ClientIp)
+	assert.Equal(t, audit.SourceGraphQL, event.Source)
+	assert.Equal(t, audit.OperationInsert, event.Operation)
+	assert.Equal(t, "store", event.Keyspace)
+	assert.Equal(t, "books", event.Table)
+	assert.Equal(t, map[string]interface{}{"title": "abc"}, event.PrimaryKey)
+	assert.True(t, event.Applied)
+}
+
+func TestDataEndpoint_AtomicMutation(t *testing.T) {
+	session, routes := createRoutes(t, createConfig(t), "/graphql", "store")
+
+	session.On("ExecuteBatch", gocql.LoggedBatch, mock.Anything, mock.Anything).Return(nil).Once()
+	resp := executeQuery(t, routes, `mutation @atomic {
+  a: insertBooks(value:{title:"abc", pages: 1}) { applied }
+  b: deleteBooks(value:{title:"def"}) { applied }
+}`)
+	assert.Len(t, resp.Errors, 0)
+	assert.Equal(t, map[string]interface{}{
+		"a": map[string]interface{}{"applied": true},
+		"b": map[string]interface{}{"applied": true},
+	}, resp.Data)
+	session.AssertCalled(t, "ExecuteBatch", gocql.LoggedBatch, mock.MatchedBy(func(statements []db.Statement) bool {
+		return len(statements) == 2 &&
+			strings.HasPrefix(statements[0].Query, `INSERT INTO "store"."books"`) &&
+			statements[1].Query == `DELETE FROM "store"."books" WHERE "title" = ?`
+	}), mock.Anything)
+
+	session.On("ExecuteBatch", gocql.UnloggedBatch, mock.Anything, mock.Anything).
+		Return(errors.New("batch failed")).Once()
+	resp = executeQuery(t, routes, `mutation @atomic(unlogged: true) { insertBooks(value:{title:"abc"}) { applied } }`)
+	assert.Len(t, resp.Errors, 1)
+	assert.Equal(t, "batch failed", resp.Errors[0].Message)
+	assert.Nil(t, resp.Data)
+
+	// Conditional mutations are rejected and none of the mutations are executed
+	resp = executeQuery(t, routes, `mutation @atomic {
+  insertBooks(value:{title:"abc"}) { applied }
+  updateBooks(value:{title:"abc", pages: 2}, ifExists: true) { applied }
+}`)
+	assert.Len(t, resp.Errors, 1)
+	assert.Equal(t, "conditional mutations are not supported in atomic operations", resp.Errors[0].Message)
+	assert.Nil(t, resp.Data)
+	session.AssertNumberOfCalls(t, "ExecuteBatch", 2)
+	session.AssertNotCalled(t, "ExecuteIter", mock.MatchedBy(func(query string) bool {
+		return strings.HasPrefix(query, `INSERT INTO "store"."books"`)
+	}), mock.Anything, mock.Anything)
+}
+
+func TestDataEndpoint_Aggregate(t *testing.T) {
+	session, routes := createRoutes(t, createConfig(t), "/graphql", "store")
+
+	title1, title2 := "book1", "book2"
+	count1, count2 := "1", "2"
+	max1, max2 := 10, 20
+	resultMock := &db.ResultMock{}
+	resultMock.
+		On("PageState").Return([]byte{}).
+		On("Values").Return([]map[string]interface{}{
+		{"title": &title1, "count": &count1, "MAX(pages)": &max1},
+		{"title": &title2, "count": &count2, "MAX(pages)": &max2},
+	}, nil)
+	session.
+		On("ExecuteIter",
+			`SELECT "title", COUNT(*) AS "count", MAX("pages") AS "MAX(pages)" FROM "store"."books" `+
+				`WHERE "title" IN ? GROUP BY "title"`,
+			mock.Anything, mock.Anything).
+		Return(resultMock, nil)
+
+	resp := executeQuery(t, routes, `{
+  booksFilter(filter: {title: {in: ["book1", "book2"]}}) {
+    aggregate(groupBy: [title]) { group { title } count max { pages } }
+  }
+}`)
+	assert.Len(t, resp.Errors, 0)
+	assert.Equal(t, map[string]interface{}{
+		"booksFilter": map[string]interface{}{
+			"aggregate": []interface{}{
+				map[string]interface{}{
+					"group": map[string]interface{}{"title": title1},
+					"count": count1,
+					"max":   map[string]interface{}{"pages": float64(max1)},
+				},
+				map[string]interface{}{
+					"group": map[string]interface{}{"title": title2},
+					"count": count2,
+					"max":   map[string]interface{}{"pages": float64(max2)},
+				},
+			},
+		},
+	}, resp.Data)
+
+	// The groups are bounded by the rows requested by the query
+	resp = executeQuery(t, routes, `{
+  booksFilter(filter: {title: {in: ["book1", "book2"]}}, options: {pageSize: 1}) {
+    aggregate(groupBy: [title]) { group { title } count max { pages } }
+  }
+}`)
+	assert.Len(t, resp.Errors, 1)
+	assert.Equal(t, "aggregate exceeds the maximum of 1 groups, the rows requested by the query using "+
+		"pageSize and limit", resp.Errors[0].Message)
+
+	// Aggregating all the rows uses the token ranges, without retrieving the rows
+	session.On("TokenRanges").Return([]db.TokenRange{{End: int64(0)}, {Start: int64(0)}}, nil)
+	selectors := `SELECT COUNT(*) AS "partial0", MIN("pages") AS "partial1", SUM("pages") AS "partial2", ` +
+		`COUNT("pages") AS "partial2_count" FROM "store"."books" WHERE `
+	rangeResult := func(count string, min int, sum int, valueCount string) *db.ResultMock {
+		resultMock := &db.ResultMock{}
+		resultMock.On("Values").Return([]map[string]interface{}{{
+			"partial0": &count, "partial1": &min, "partial2": &sum, "partial2_count": &valueCount,
+		}}, nil)
+		return resultMock
+	}
+	session.On("ExecuteIter", selectors+`token("title") <= ?`, mock.Anything, mock.Anything).
+		Return(rangeResult("40", 12, 400, "2"), nil)
+	session.On("ExecuteIter", selectors+`token("title") > ?`, mock.Anything, mock.Anything).
+		Return(rangeResult("2", 3, 20, "2"), nil)
+
+	resp = executeQuery(t, routes, `{ books { aggregate { count min { pages } avg { pages } } } }`)
+	assert.Len(t, resp.Errors, 0, "%v", resp.Errors)
+	assert.Equal(t, map[string]interface{}{
+		"books": map[string]interface{}{
+			"aggregate": []interface{}{map[string]interface{}{
+				"count": "42",
+				"min":   map[string]interface{}{"pages": float64(3)},
+				"avg":   map[string]interface{}{"pages": float64(105)},
+			}},
+		},
+	}, resp.Data)
+	session.AssertNotCalled(t, "ExecuteIter", `SELECT * FROM "store"."books"`, mock.Anything, mock.Anything)
+}
+
+func TestDataEndpoint_WriteTimeAndTTL(t *testing.T) {
+	session, routes := createRoutes(t, createConfig(t), "/graphql", "store")
+
+	title := "book1"
+	pages := 42
+	writeTime := "1600000000000000"
+	ttl := 3600
+	resultMock := &db.ResultMock{}
+	resultMock.
+		On("PageState").Return([]byte{}).
+		On("Values").Return([]map[string]interface{}{
+		{"title": &title, "pages": &pages, "_writetime_first_name": &writeTime, "_ttl_pages": &ttl},
+	}, nil)
+	session.
+		On("ExecuteIter",
+			`SELECT "first_name", "last_name", "pages", "title", WRITETIME("first_name") AS "_writetime_first_name", `+
+				`TTL("pages") AS "_ttl_pages" FROM "store"."books" WHERE "title" = ?`,
+			mock.Anything, mock.Anything).
+		Return(resultMock, nil)
+
+	resp := executeQuery(t, routes,
+		`{ books(value: {title: "book1"}) { values { title pages _writetime_firstName _ttl_pages } } }`)
+	assert.Len(t, resp.Errors, 0)
+	assert.Equal(t, map[string]interface{}{
+		"books": map[string]interface{}{
+			"values": []interface{}{
+				map[string]interface{}{
+					"title":                title,
+					"pages":                float64(pages),
+					"_writetime_firstName": writeTime,
+					"_ttl_pages":           float64(ttl),
+				},
+			},
+		},
+	}, resp.Data)
+}
+
+func TestDataEndpoint_MutationTimestamp(t *testing.T) {
+	session, routes := createRoutes(t, createConfig(t), "/graphql", "store")
+	addResult(session, "", nil)
+
+	resp := executeQuery(t, routes,
+		`mutation { deleteBooks(value:{title:"abc"}, options: {timestamp: "1000"}) { applied } }`)
+	assert.Len(t, resp.Errors, 0)
+	session.AssertCalled(t, "ExecuteIter", `DELETE FROM "store"."books" USING TIMESTAMP ? WHERE "title" = ?`,
+		mock.Anything, []interface{}{int64(1000), "abc"})
+
+	calls := len(session.Calls)
+	resp = executeQuery(t, routes,
+		`mutation { deleteBooks(value:{title:"abc"}, options: {timestamp: "abc"}) { applied } }`)
+	assert.Len(t, resp.Errors, 1)
+	assert.Equal(t, "invalid timestamp 'abc'", resp.Errors[0].Message)
+	assert.Len(t, session.Calls, calls)
+}
+
+func TestDataEndpoint_CollectionOperations(t *testing.T) {
+	text := gocql.NewNativeType(0, gocql.TypeText, "")
+	integer := gocql.NewNativeType(0, gocql.TypeInt, "")
+	session, routes := createRoutesWithTables(t, createConfig(t), map[string][]*gocql.ColumnMetadata{
+		"libraries": {
+			{Name: "name", Kind: gocql.ColumnPartitionKey, Type: text},
+			{Name: "book_ids", Kind: gocql.ColumnRegular, Type: gocql.CollectionType{
+				NativeType: gocql.NewNativeType(0, gocql.TypeList, ""), Elem: integer}},
+			{Name: "tags", Kind: gocql.ColumnRegular, Type: gocql.CollectionType{
+				NativeType: gocql.NewNativeType(0, gocql.TypeSet, ""), Elem: text}},
+			{Name: "counts", Kind: gocql.ColumnRegular, Type: gocql.CollectionType{
+				NativeType: gocql.NewNativeType(0, gocql.TypeMap, ""), Key: text, Elem: integer}},
+		},
+	})
+	addResult(session, "", nil)
+
+	resp := executeQuery(t, routes, `mutation {
+		updateLibraries(value: {name: "central"}, operations: {
+			bookIds: {prepend: [1], setAt: [{index: 2, value: 3}]},
+			counts: {put: [{key: "a", value: 1}], remove: ["b"]},
+			tags: {append: ["new"], remove: ["old"]}
+		}) { applied }
+	}`)
+	assert.Len(t, resp.Errors, 0)
+
+	session.AssertCalled(t, "ExecuteIter",
+		`UPDATE "store"."libraries" SET "book_ids" = ? + "book_ids", "book_ids"[?] = ?, "counts" = "counts" - ?, `+
+			`"counts"[?] = ?, "tags" = "tags" + ?, "tags" = "tags" - ? WHERE "name" = ?`,
+		mock.Anything, []interface{}{
+			[]interface{}{1}, 2, 3, []interface{}{"b"}, "a", 1, []interface{}{"new"}, []interface{}{"old"}, "central"})
+}
+
+func TestDataEndpoint_IncrementCounters(t *testing.T) {
+	session, routes := createRoutesWithTables(t, createConfig(t), map[string][]*gocql.ColumnMetadata{
+		"page_views": {
+			{Name: "page", Kind: gocql.ColumnPartitionKey, Type: gocql.NewNativeType(0, gocql.TypeText, "")},
+			{Name: "views", Kind: gocql.ColumnRegular, Type: gocql.NewNativeType(0, gocql.TypeCounter, "")},
+		},
+	})
+	addResult(session, "", nil)
+
+	resp := executeQuery(t, routes, `mutation { incrementPageViews(value: {page: "home", views: "-2"}) { applied } }`)
+	assert.Len(t, resp.Errors, 0)
+	session.AssertCalled(t, "ExecuteIter", `UPDATE "store"."page_views" SET "views" = "views" + ? WHERE "page" = ?`,
+		mock.Anything, []interface{}{"-2", "home"})
+
+	// Counter tables don't have an insert mutation
+	resp = executeQuery(t, routes, `mutation { insertPageViews(value: {page: "home", views: "1"}) { applied } }`)
+	assert.Len(t, resp.Errors, 1)
+}
+
+func TestDataEndpoint_DeleteColumns(t *testing.T) {
+	text := gocql.NewNativeType(0, gocql.TypeText, "")
+	integer := gocql.NewNativeType(0, gocql.TypeInt, "")
+	session, routes := createRoutesWithTables(t, createConfig(t), map[string][]*gocql.ColumnMetadata{
+		"shelves": {
+			{Name: "library", Kind: gocql.ColumnPartitionKey, Type: text},
+			{Name: "shelf", Kind: gocql.ColumnClusteringKey, Type: integer},
+			{Name: "address", Kind: gocql.ColumnStatic, Type: text},
+			{Name: "label", Kind: gocql.ColumnRegular, Type: text},
+			{Name: "counts", Kind: gocql.ColumnRegular, Type: gocql.CollectionType{
+				NativeType: gocql.NewNativeType(0, gocql.TypeMap, ""), Key: text, Elem: integer}},
+		},
+	})
+	addResult(session, "", nil)
+
+	resp := executeQuery(t, routes,
+		`mutation { deleteShelves(value: {library: "central"}, columns: [address]) { applied } }`)
+	assert.Len(t, resp.Errors, 0)
+	session.AssertCalled(t, "ExecuteIter", `DELETE "address" FROM "store"."shelves" WHERE "library" = ?`,
+		mock.Anything, []interface{}{"central"})
+
+	resp = executeQuery(t, routes, `mutation {
+		deleteShelves(value: {library: "central", shelf: 1}, columns: [label], elements: {counts: ["a"]}) { applied }
+	}`)
+	assert.Len(t, resp.Errors, 0)
+	session.AssertCalled(t, "ExecuteIter", mock.MatchedBy(func(query string) bool {
+		return strings.HasPrefix(query, `DELETE "label", "counts"[?] FROM "store"."shelves" WHERE `)
+	}), mock.Anything, mock.Anything)
+
+	resp = executeQuery(t, routes,
+		`mutation { deleteShelves(value: {library: "central"}, columns: [label]) { applied } }`)
+	assert.Len(t, resp.Errors, 1)
+	assert.Equal(t, "regular columns must be deleted using the full primary key", resp.Errors[0].Message)
+}
+
+func TestDataEndpoint_UserDefinedTypesAndTuples(t *testing.T) {
+	float := gocql.NewNativeType(0, gocql.TypeFloat, "")
+	session, routes := createRoutesWithTables(t, createConfig(t), map[string][]*gocql.ColumnMetadata{
+		"customers": {
+			{Name: "id", Kind: gocql.ColumnPartitionKey, Type: gocql.NewNativeType(0, gocql.TypeInt, "")},
+			{Name: "address", Kind: gocql.ColumnRegular, Validator: "frozen<address>",
+				Type: gocql.NewNativeType(0, gocql.TypeCustom, "")},
+			{Name: "previous", Kind: gocql.ColumnRegular, Validator: "list<frozen<address>>",
+				Type: gocql.CollectionType{
+					NativeType: gocql.NewNativeType(0, gocql.TypeList, ""),
+					Elem:       gocql.NewNativeType(0, gocql.TypeCustom, "")}},
+			{Name: "location", Kind: gocql.ColumnRegular, Validator: "frozen<tuple<float, float>>",
+				Type: gocql.TupleTypeInfo{
+					NativeType: gocql.NewNativeType(0, gocql.TypeTuple, ""), Elems: []gocql.TypeInfo{float, float}}},
+		},
+	}, func(session *db.SessionMock) {
+		session.AddUserTypes([]db.UserTypeMock{
+			{Name: "address", FieldNames: []string{"street", "zip"}, FieldTypes: []string{"text", "int"}},
+		})
+	})
+
+	id, street, zip := 1, "Main St", 90210
+	latitude, longitude := float32(1.5), float32(-2)
+	previous := []*db.UDTValue{{"street": &street, "zip": (*int)(nil)}}
+	addResult(session, "SELECT", []map[string]interface{}{{
+		"id":       &id,
+		"address":  &db.UDTValue{"street": &street, "zip": &zip},
+		"previous": &previous,
+		"location": &db.TupleValue{&latitude, &longitude},
+	}})
+	addResult(session, "", nil)
+
+	resp := executeQuery(t, routes, `query {
+		customers(value: {id: 1}) {
+			values { address { street zip } previous { street zip } location { item0 item1 } }
+		}
+	}`)
+	assert.Len(t, resp.Errors, 0, "%v", resp.Errors)
+	assert.Equal(t, map[string]interface{}{
+		"customers": map[string]interface{}{
+			"values": []interface{}{map[string]interface{}{
+				"address":  map[string]interface{}{"street": street, "zip": float64(zip)},
+				"previous": []interface{}{map[string]interface{}{"street": street, "zip": nil}},
+				"location": map[string]interface{}{"item0": 1.5, "item1": float64(-2)},
+			}},
+		},
+	}, resp.Data)
+
+	// The input objects are converted using the types of the parameters
+	insertedValue := func() gocql.Marshaler {
+		_, values := lastCall(session, "INSERT")
+		for _, value := range values {
+			if marshaler, ok := value.(gocql.Marshaler); ok {
+				return marshaler
+			}
+		}
+		return nil
+	}
+
+	addressType := gocql.UDTTypeInfo{
+		NativeType: gocql.NewNativeType(4, gocql.TypeUDT, ""),
+		Name:       "address",
+		Elements: []gocql.UDTField{
+			{Name: "street", Type: gocql.NewNativeType(4, gocql.TypeText, "")},
+			{Name: "zip", Type: gocql.NewNativeType(4, gocql.TypeInt, "")},
+		},
+	}
+	resp = executeQuery(t, routes, `mutation { insertCustomers(value: {id: 1, address: {street: "Main St"}}) { applied } }`)
+	assert.Len(t, resp.Errors, 0)
+	expected, err := gocql.Marshal(addressType, map[string]interface{}{"street": street, "zip": nil})
+	assert.NoError(t, err)
+	actual, err := insertedValue().MarshalCQL(addressType)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, actual)
+
+	tupleType := gocql.TupleTypeInfo{
+		NativeType: gocql.NewNativeType(4, gocql.TypeTuple, ""),
+		Elems:      []gocql.TypeInfo{gocql.NewNativeType(4, gocql.TypeFloat, ""), gocql.NewNativeType(4, gocql.TypeFloat, "")},
+	}
+	resp = executeQuery(t, routes, `mutation { insertCustomers(value: {id: 1, location: {item0: 1.5, item1: -2}}) { applied } }`)
+	assert.Len(t, resp.Errors, 0)
+	expected, err = gocql.Marshal(tupleType, []interface{}{latitude, longitude})
+	assert.NoError(t, err)
+	actual, err = insertedValue().MarshalCQL(tupleType)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, actual)
+}
+
+func TestDataEndpoint_DateAndDuration(t *testing.T) {
+	session, routes := createRoutesWithTables(t, createConfig(t), map[string][]*gocql.ColumnMetadata{
+		"events": {
+			{Name: "id", Kind: gocql.ColumnPartitionKey, Type: gocql.NewNativeType(0, gocql.TypeInt, "")},
+			{Name: "day", Kind: gocql.ColumnClusteringKey, Type: gocql.NewNativeType(0, gocql.TypeDate, "")},
+			{Name: "length", Kind: gocql.ColumnRegular, Type: gocql.NewNativeType(0, gocql.TypeDuration, "")},
+		},
+	})
+
+	id, day := 1, "2020-03-25"
+	length := gocql.Duration{Months: 14, Days: 3, Nanoseconds: int64(4*time.Hour + 5*time.Minute)}
+	addResult(session, "SELECT", []map[string]interface{}{{"id": &id, "day": &day, "length": &length}})
+	addResult(session, "", nil)
+
+	resp := executeQuery(t, routes, `query {
+		eventsFilter(filter: {id: {eq: 1}, day: {gte: "2020-01-01"}}, orderBy: [day_DESC]) {
+			values { day length }
+		}
+	}`)
+	assert.Len(t, resp.Errors, 0, "%v", resp.Errors)
+	assert.Equal(t, map[string]interface{}{
+		"eventsFilter": map[string]interface{}{
+			"values": []interface{}{map[string]interface{}{"day": day, "length": "1y2mo3d4h5m"}},
+		},
+	}, resp.Data)
+	_, values := lastCall(session, "SELECT")
+	assert.Contains(t, values, "2020-01-01")
+
+	resp = executeQuery(t, routes, `mutation { insertEvents(value: {id: 1, day: "2020-03-25", length: "-1h30m"}) { applied } }`)
+	assert.Len(t, resp.Errors, 0, "%v", resp.Errors)
+	_, values = lastCall(session, "INSERT")
+	assert.Contains(t, values, gocql.Duration{Nanoseconds: -int64(90 * time.Minute)})
+
+	for _, value := range []string{`day: "2020-13-01"`, `length: "1x"`} {
+		resp = executeQuery(t, routes, fmt.Sprintf(`mutation { insertEvents(value: {id: 1, %s}) { applied } }`, value))
+		assert.NotEmpty(t, resp.Errors, "expected an error for %s", value)
+	}
+}
+
+func TestDataEndpoint_Vectors(t *testing.T) {
+	session, routes := createRoutesWithTables(t, createConfig(t), map[string][]*gocql.ColumnMetadata{
+		"products": {
+			{Name: "id", Kind: gocql.ColumnPartitionKey, Type: gocql.NewNativeType(0, gocql.TypeInt, "")},
+			{Name: "embedding", Kind: gocql.ColumnRegular, Validator: "vector<float, 3>",
+				Type: gocql.NewNativeType(0, gocql.TypeCustom, "")},
+		},
+	}, func(session *db.SessionMock) {
+		session.AddUserTypes(nil)
+		session.AddIndexes([]db.IndexMock{
+			{Table: "products", Name: "embedding_idx", Options: map[string]string{
+				"class_name":          "StorageAttachedIndex",
+				"target":              "embedding",
+				"similarity_function": "euclidean",
+			}},
+		})
+	})
+
+	id, similarity := 1, float32(0.5)
+	addResult(session, "SELECT", []map[string]interface{}{
+		{"id": &id, "embedding": &db.VectorValue{1.5, -2, 0}, db.SimilarityColumn: &similarity},
+	})
+	addResult(session, "", nil)
+
+	resp := executeQuery(t, routes, `query {
+		products(annOf: {embedding: [1, -2, 0.5]}, options: {limit: 1}) { values { id embedding _similarity } }
+	}`)
+	assert.Len(t, resp.Errors, 0, "%v", resp.Errors)
+	assert.Equal(t, map[string]interface{}{
+		"products": map[string]interface{}{
+			"values": []interface{}{map[string]interface{}{
+				"id": float64(id), "embedding": []interface{}{1.5, float64(-2), float64(0)}, "_similarity": 0.5,
+			}},
+		},
+	}, resp.Data)
+	query, values := lastCall(session, `SELECT "`)
+	assert.Equal(t, `SELECT "embedding", "id", SIMILARITY_EUCLIDEAN("embedding", ?) AS "_similarity" `+
+		`FROM "store"."products" ORDER BY "embedding" ANN OF ? LIMIT ?`, query)
+	assert.Equal(t, []interface{}{types.VectorParameter{1, -2, 0.5}, types.VectorParameter{1, -2, 0.5}, 1}, values)
+
+	// The similarity requires ordering by similarity, which requires a limit
+	resp = executeQuery(t, routes, `query { products(value: {id: 1}) { values { _similarity } } }`)
+	assert.NotEmpty(t, resp.Errors)
+	resp = executeQuery(t, routes, `query { products(annOf: {embedding: [1, -2, 0.5]}) { values { id } } }`)
+	assert.NotEmpty(t, resp.Errors)
+	resp = executeQuery(t, routes, `query { products(annOf: {embedding: [1, -2]}, options: {limit: 1}) { values { id } } }`)
+	assert.NotEmpty(t, resp.Errors)
+
+	// The vectors are converted using the type of the parameter
+	info := gocql.NewNativeType(4, gocql.TypeCustom,
+		"org.apache.cassandra.db.marshal.VectorType(org.apache.cassandra.db.marshal.FloatType, 3)")
+	resp = executeQuery(t, routes, `mutation { insertProducts(value: {id: 1, embedding: [1.5, -2, 0]}) { applied } }`)
+	assert.Len(t, resp.Errors, 0, "%v", resp.Errors)
+	_, values = lastCall(session, "INSERT")
+	expected, err := gocql.Marshal(info, types.VectorParameter{1.5, -2, 0})
+	assert.NoError(t, err)
+	for _, value := range values {
+		if marshaler, ok := value.(gocql.Marshaler); ok {
+			actual, err := marshaler.MarshalCQL(info)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, actual)
+		}
+	}
+}
+
+func TestDataEndpoint_CollectionAndTextFilters(t *testing.T) {
+	text := gocql.NewNativeType(0, gocql.TypeText, "")
+	session, routes := createRoutesWithTables(t, createConfig(t), map[string][]*gocql.ColumnMetadata{
+		"books": {
+			{Name: "id", Kind: gocql.ColumnPartitionKey, Type: gocql.NewNativeType(0, gocql.TypeInt, "")},
+			{Name: "title", Kind: gocql.ColumnRegular, Type: text},
+			{Name: "tags", Kind: gocql.ColumnRegular, Type: gocql.CollectionType{
+				NativeType: gocql.NewNativeType(0, gocql.TypeSet, ""), Elem: text}},
+			{Name: "ratings", Kind: gocql.ColumnRegular, Type: gocql.CollectionType{
+				NativeType: gocql.NewNativeType(0, gocql.TypeMap, ""),
+				Key:        text,
+				Elem:       gocql.NewNativeType(0, gocql.TypeInt, "")}},
+		},
+	})
+	addResult(session, "", nil)
+
+	resp := executeQuery(t, routes, `query {
+		booksFilter(filter: {
+			title: {like: "Moby%"},
+			tags: {contains: "classic"},
+			ratings: {containsKey: "goodreads", containsEntry: {key: "amazon", value: 5}}
+		}) { values { id } }
+	}`)
+	assert.Len(t, resp.Errors, 0, "%v", resp.Errors)
+
+	query, values := lastCall(session, `SELECT * FROM "store"."books"`)
+	assert.Contains(t, query, `"title" LIKE ?`)
+	assert.Contains(t, query, `"tags" CONTAINS ?`)
+	assert.Contains(t, query, `"ratings" CONTAINS KEY ?`)
+	assert.Contains(t, query, `"ratings"[?] = ?`)
+	assert.ElementsMatch(t, []interface{}{"Moby%", "classic", "goodreads", "amazon", 5}, values)
+}
+
+func TestDataEndpoint_AllowFiltering(t *testing.T) {
+	newRoutes := func(settings config.AllowFilteringSettings) (*db.SessionMock, []types.Route) {
+		session, routes := createRoutesWithTables(t, createConfig(t).WithAllowFiltering(settings),
+			map[string][]*gocql.ColumnMetadata{
+				"books": {
+					{Name: "id", Kind: gocql.ColumnPartitionKey, Type: gocql.NewNativeType(0, gocql.TypeInt, "")},
+					{Name: "title", Kind: gocql.ColumnRegular, Type: gocql.NewNativeType(0, gocql.TypeText, "")},
+				},
+			})
+		addResult(session, "", nil)
+		return session, routes
+	}
+
+	execute := func(routes []types.Route, options string) schemas.ResponseBody {
+		return executeQuery(t, routes, fmt.Sprintf(`query {
+			booksFilter(filter: { title: { eq: "Moby Dick" } }, options: %s) { values { id } }
+		}`, options))
+	}
+
+	// Disabled by default
+	_, routes := newRoutes(config.AllowFilteringSettings{})
+	resp := execute(routes, `{ allowFiltering: true, limit: 10 }`)
+	assert.Len(t, resp.Errors, 1)
+	assert.Contains(t, resp.Errors[0].Message, "allow filtering is not enabled for table 'books'")
+
+	session, routes := newRoutes(config.AllowFilteringSettings{Tables: []string{"store.books"}})
+
+	// Requires a partition key restriction or a limit
+	resp = execute(routes, `{ allowFiltering: true }`)
+	assert.Len(t, resp.Errors, 1)
+	assert.Contains(t, resp.Errors[0].Message, "must restrict the partition key or use a limit")
+
+	resp = execute(routes, `{ allowFiltering: true, limit: 10 }`)
+	assert.Len(t, resp.Errors, 0, "%v", resp.Errors)
+	session.AssertCalled(t, "ExecuteIter",
+		`SELECT * FROM "store"."books" WHERE "title" = ? LIMIT ? ALLOW FILTERING`,
+		mock.Anything, []interface{}{"Moby Dick", 10})
+
+	// The limit must be no greater than the maximum
+	_, routes = newRoutes(config.AllowFilteringSettings{Tables: []string{"store.books"}, MaxLimit: 10})
+	resp = execute(routes, `{ allowFiltering: true, limit: 10 }`)
+	assert.Len(t, resp.Errors, 0, "%v", resp.Errors)
+	resp = execute(routes, `{ allowFiltering: true, limit: 11 }`)
+	assert.Len(t, resp.Errors, 1)
+	assert.Contains(t, resp.Errors[0].Message, "use a limit of at most 10")
+}
+
 func createConfig(t *testing.T) *DataEndpointConfig {
 	cfg, err := NewEndpointConfig(host)
 	assert.NoError(t, err, "error creating endpoint config")
@@ -416,6 +1114,64 @@ func createRoutes(t *testing.T, cfg *DataEndpointConfig, pattern string, ksName
 	return sessionMock, routes
 }
 
+// createRoutesWithTables creates the routes of the "store" keyspace containing the tables, the setup functions can
+// add the rest of the schema metadata, e.g. user-defined types or indexes
+func createRoutesWithTables(
+	t *testing.T,
+	cfg *DataEndpointConfig,
+	tables map[string][]*gocql.ColumnMetadata,
+	setup ...func(session *db.SessionMock),
+) (*db.SessionMock, []types.Route) {
+	sessionMock := db.NewSessionMock()
+	sessionMock.SetSchemaVersion("a78bc282-aff7-4c2a-8f23-4ce3584adbb0")
+	sessionMock.AddKeyspace(db.NewKeyspaceMock("store", tables))
+	for _, fn := range setup {
+		fn(sessionMock)
+	}
+	sessionMock.AddViews(nil)
+
+	endpoint := cfg.newEndpointWithDb(db.NewDbWithSession(sessionMock))
+	routes, err := endpoint.RoutesKeyspaceGraphQL("/graphql", "store")
+	assert.NoError(t, err, "error getting routes for keyspace")
+
+	return sessionMock, routes
+}
+
+// addResult sets the rows returned by the queries starting with the prefix, an empty prefix matches all the queries.
+// The first matching result is used, so the results must be added from the most to the least specific prefix.
+func addResult(sessionMock *db.SessionMock, prefix string, rows []map[string]interface{}) {
+	if rows == nil {
+		rows = []map[string]interface{}{}
+	}
+	resultMock := &db.ResultMock{}
+	resultMock.
+		On("PageState").Return([]byte{}).
+		On("Values").Return(rows, nil)
+	sessionMock.On("ExecuteIter", mock.MatchedBy(func(query string) bool {
+		return strings.HasPrefix(query, prefix)
+	}), mock.Anything, mock.Anything).Return(resultMock, nil)
+}
+
+// lastCall returns the last executed query starting with the prefix and its parameters
+func lastCall(sessionMock *db.SessionMock, prefix string) (string, []interface{}) {
+	for i := len(sessionMock.Calls) - 1; i >= 0; i-- {
+		call := sessionMock.Calls[i]
+		if call.Method == "ExecuteIter" && strings.HasPrefix(call.Arguments.String(0), prefix) {
+			return call.Arguments.String(0), call.Arguments.Get(2).([]interface{})
+		}
+	}
+	return "", nil
+}
+
+// executeQuery executes the query using a POST request to "/graphql" and decodes the response
+func executeQuery(t *testing.T, routes []types.Route, query string) schemas.ResponseBody {
+	buffer, err := executePost(routes, "/graphql", graphql.RequestBody{Query: query}, nil)
+	assert.NoError(t, err, "error executing query")
+	var resp schemas.ResponseBody
+	assert.NoError(t, json.NewDecoder(buffer).Decode(&resp), "error decoding response")
+	return resp
+}
+
 func expectedBooksResponse(pages int, title string) schemas.ResponseBody {
 	return schemas.ResponseBody{
 		Data: map[string]interface{}{
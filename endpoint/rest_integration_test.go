@@ -195,6 +195,242 @@ var _ = Describe("DataEndpoint", func() {
 					}
 				})
 			}
+
+			It("Should support user-defined types and tuples", func() {
+				body := `{ "columns": [
+				  { "name": "id", "value": 1},
+				  { "name": "address", "value": { "line1": "Main St" }}
+				]}`
+				code := rest.ExecutePost(routes, pathFormat, body, nil, "quirky", "tbl_udt")
+				Expect(code).To(Equal(http.StatusCreated))
+
+				var response models.Rows
+				rest.ExecuteGet(routes, e.RowSinglePathFormat, &response, "quirky", "tbl_udt", "1")
+				Expect(response.Rows).To(HaveLen(1))
+				Expect(response.Rows[0]["address"]).To(Equal(map[string]interface{}{"line1": "Main St", "line2": nil}))
+
+				body = `{ "columns": [
+				  { "name": "id", "value": 1},
+				  { "name": "location", "value": [1.5, -2]}
+				]}`
+				code = rest.ExecutePost(routes, pathFormat, body, nil, "quirky", "tbl_tuple")
+				Expect(code).To(Equal(http.StatusCreated))
+
+				rest.ExecuteGet(routes, e.RowSinglePathFormat, &response, "quirky", "tbl_tuple", "1")
+				Expect(response.Rows).To(HaveLen(1))
+				Expect(response.Rows[0]["location"]).To(Equal([]interface{}{1.5, float64(-2)}))
+			})
+
+			It("Should return 400 when the fields of a user-defined type are not found", func() {
+				body := `{ "columns": [
+				  { "name": "id", "value": 2},
+				  { "name": "address", "value": { "line3": "Main St" }}
+				]}`
+				code := rest.ExecutePost(routes, pathFormat, body, nil, "quirky", "tbl_udt")
+				Expect(code).To(Equal(http.StatusBadRequest))
+			})
+		})
+
+		Describe("POST /keyspaces/{keyspaceName}/tables/{tableName}/rows/bulk", func() {
+			pathFormat := e.BulkRowsPathFormat
+
+			It("Should insert NDJSON rows", func() {
+				id1 := schemas.NewUuid()
+				id2 := schemas.NewUuid()
+				body := fmt.Sprintf(`{"videoid": "%s", "name": "video 1", "location_type": 1}
+{"videoid": "%s", "name": "video 2"}
+{"videoid": "%s", "location_type": "abc"}
+{"not_found": 1}
+`, id1, id2, id1)
+
+				var response models.BulkRowsResponse
+				code := rest.ExecutePostWithContentType(
+					routes, pathFormat, "application/x-ndjson", body, &response, "killrvideo", "videos")
+				Expect(code).To(Equal(http.StatusOK))
+				Expect(response.RowsInserted).To(Equal(2))
+				Expect(response.RowsFailed).To(Equal(2))
+				Expect(response.Errors).To(Equal([]models.BulkRowError{
+					{Row: 3, Description: "wrong type provided for column 'location_type'"},
+					{Row: 4, Description: "column 'not_found' not found in table"},
+				}))
+
+				rs, err := dbClient.Execute("SELECT * FROM killrvideo.videos WHERE videoid = ?", nil, id2)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(rs.Values()).To(HaveLen(1))
+				Expect(rs.Values()[0]).To(MatchKeys(IgnoreExtras, Keys{
+					"name": PointTo(Equal("video 2")),
+				}))
+			})
+
+			It("Should insert CSV rows", func() {
+				id1 := schemas.NewUuid()
+				id2 := schemas.NewUuid()
+				body := fmt.Sprintf("videoid,name,location_type\n%s,video 1,1\n%s,,2\n", id1, id2)
+
+				var response models.BulkRowsResponse
+				code := rest.ExecutePostWithContentType(
+					routes, pathFormat, "text/csv", body, &response, "killrvideo", "videos")
+				Expect(code).To(Equal(http.StatusOK))
+				Expect(response.RowsInserted).To(Equal(2))
+				Expect(response.RowsFailed).To(Equal(0))
+
+				rs, err := dbClient.Execute("SELECT * FROM killrvideo.videos WHERE videoid = ?", nil, id1)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(rs.Values()).To(HaveLen(1))
+				Expect(rs.Values()[0]).To(MatchKeys(IgnoreExtras, Keys{
+					"name":          PointTo(Equal("video 1")),
+					"location_type": PointTo(Equal(1)),
+				}))
+			})
+
+			It("Should return 400 when the CSV header does not match", func() {
+				var response models.ModelError
+				code := rest.ExecutePostWithContentType(
+					routes, pathFormat, "text/csv", "a,b\n1,2\n", &response, "killrvideo", "videos")
+				Expect(code).To(Equal(http.StatusBadRequest))
+				Expect(response.Description).To(Equal("column 'a' not found in table"))
+			})
+		})
+
+		Describe("GET and POST /keyspaces/{keyspaceName}/tables/{tableName}/export", func() {
+			pathFormat := e.ExportPathFormat
+
+			It("Should export the rows matching the filters as NDJSON", func() {
+				letter := schemas.NewUuid()
+				for _, tag := range []string{"a", "b", "c"} {
+					_, err := dbClient.Execute(
+						"INSERT INTO killrvideo.tags_by_letter (first_letter, tag) VALUES (?, ?)", nil, letter, tag)
+					Expect(err).NotTo(HaveOccurred())
+				}
+
+				body := fmt.Sprintf(`{"filters": [{"columnName": "first_letter", "operator": "eq", "value": ["%s"]}],
+					"columnNames": ["tag"]}`, letter)
+				w := rest.ExecuteRaw(http.MethodPost, routes, pathFormat, "", body, "killrvideo", "tags_by_letter")
+				Expect(w.Code).To(Equal(http.StatusOK))
+				Expect(w.Header().Get("Content-Type")).To(Equal("application/x-ndjson"))
+				Expect(w.Body.String()).To(Equal("{\"tag\":\"a\"}\n{\"tag\":\"b\"}\n{\"tag\":\"c\"}\n"))
+			})
+
+			It("Should export the rows as CSV", func() {
+				id := schemas.NewUuid()
+				_, err := dbClient.Execute(
+					"INSERT INTO killrvideo.videos (videoid, name, location_type) VALUES (?, ?, ?)", nil, id, "v,1", 2)
+				Expect(err).NotTo(HaveOccurred())
+
+				body := fmt.Sprintf(`{"filters": [{"columnName": "videoid", "operator": "eq", "value": ["%s"]}],
+					"columnNames": ["videoid", "name", "location_type", "description"]}`, id)
+				w := rest.ExecuteRaw(http.MethodPost, routes, pathFormat, "format=csv", body, "killrvideo", "videos")
+				Expect(w.Code).To(Equal(http.StatusOK))
+				Expect(w.Header().Get("Content-Type")).To(Equal("text/csv"))
+				Expect(w.Body.String()).To(Equal(
+					fmt.Sprintf("videoid,name,location_type,description\n%s,\"v,1\",2,\n", id)))
+			})
+
+			It("Should export all the rows of the table as Parquet", func() {
+				w := rest.ExecuteRaw(http.MethodGet, routes, pathFormat, "format=parquet", "", "killrvideo", "videos")
+				Expect(w.Code).To(Equal(http.StatusOK))
+				Expect(w.Header().Get("Content-Type")).To(Equal("application/vnd.apache.parquet"))
+				data := w.Body.Bytes()
+				Expect(string(data[:4])).To(Equal("PAR1"))
+				Expect(string(data[len(data)-4:])).To(Equal("PAR1"))
+			})
+
+			It("Should return 400 when the format is not supported", func() {
+				w := rest.ExecuteRaw(http.MethodGet, routes, pathFormat, "format=xml", "", "killrvideo", "videos")
+				Expect(w.Code).To(Equal(http.StatusBadRequest))
+			})
+		})
+
+		Describe("POST /keyspaces/{keyspaceName}/tables/{tableName}/rows/query with aggregation", func() {
+			pathFormat := e.QueryPathFormat
+
+			insertUserVideos := func(userId string, dates ...string) {
+				for _, date := range dates {
+					_, err := dbClient.Execute(fmt.Sprintf(
+						"INSERT INTO killrvideo.user_videos (userid, added_date, videoid) VALUES (?, '%s', ?)", date),
+						nil, userId, schemas.NewUuid())
+					Expect(err).NotTo(HaveOccurred())
+				}
+			}
+
+			It("Should count the rows matching the filters", func() {
+				userId := schemas.NewUuid()
+				insertUserVideos(userId, "2020-01-01 00:00:00+0000", "2020-01-02 00:00:00+0000", "2020-01-02 00:00:00+0000")
+
+				body := fmt.Sprintf(`{"filters": [{"columnName": "userid", "operator": "eq", "value": ["%s"]}],
+					"aggregation": {"functions": [{"name": "count"}, {"name": "max", "columnName": "added_date"}]}}`,
+					userId)
+				var response models.Rows
+				code := rest.ExecutePost(routes, pathFormat, body, &response, "killrvideo", "user_videos")
+				Expect(code).To(Equal(http.StatusOK))
+				Expect(response.Rows).To(Equal([]map[string]interface{}{
+					{"count": "3", "max_added_date": "2020-01-02T00:00:00Z"},
+				}))
+			})
+
+			It("Should group the rows by primary key columns", func() {
+				userId := schemas.NewUuid()
+				insertUserVideos(userId, "2020-01-01 00:00:00+0000", "2020-01-02 00:00:00+0000", "2020-01-02 00:00:00+0000")
+
+				body := fmt.Sprintf(`{"filters": [{"columnName": "userid", "operator": "eq", "value": ["%s"]}],
+					"aggregation": {"functions": [{"name": "count"}], "groupBy": ["userid", "added_date"]}}`,
+					userId)
+				var response models.Rows
+				code := rest.ExecutePost(routes, pathFormat, body, &response, "killrvideo", "user_videos")
+				Expect(code).To(Equal(http.StatusOK))
+				Expect(response.Rows).To(Equal([]map[string]interface{}{
+					{"userid": userId, "added_date": "2020-01-02T00:00:00Z", "count": "2"},
+					{"userid": userId, "added_date": "2020-01-01T00:00:00Z", "count": "1"},
+				}))
+			})
+
+			It("Should return 400 when grouping by a regular column", func() {
+				body := `{"filters": [], "aggregation": {"functions": [{"name": "count"}], "groupBy": ["name"]}}`
+				var response models.ModelError
+				code := rest.ExecutePost(routes, pathFormat, body, &response, "killrvideo", "videos")
+				Expect(code).To(Equal(http.StatusBadRequest))
+				Expect(response.Description).To(Equal("column 'name' is not a primary key column"))
+			})
+
+			It("Should return 400 when the function requires a column", func() {
+				body := `{"filters": [], "aggregation": {"functions": [{"name": "sum"}]}}`
+				var response models.ModelError
+				code := rest.ExecutePost(routes, pathFormat, body, &response, "killrvideo", "videos")
+				Expect(code).To(Equal(http.StatusBadRequest))
+				Expect(response.Description).To(Equal("aggregate function 'sum' requires a column"))
+			})
+		})
+
+		Describe("POST /keyspaces/{keyspaceName}/tables/{tableName}/rows/query with write time and ttl", func() {
+			pathFormat := e.QueryPathFormat
+
+			It("Should return the write time and ttl of the columns", func() {
+				id := schemas.NewUuid()
+				_, err := dbClient.Execute(
+					"INSERT INTO killrvideo.videos (videoid, name) VALUES (?, ?) USING TIMESTAMP 1000 AND TTL 3600",
+					nil, id, "video 1")
+				Expect(err).NotTo(HaveOccurred())
+
+				body := fmt.Sprintf(`{"filters": [{"columnName": "videoid", "operator": "eq", "value": ["%s"]}],
+					"columnNames": ["name"], "writeTime": ["name"], "ttl": ["name"]}`, id)
+				var response models.Rows
+				code := rest.ExecutePost(routes, pathFormat, body, &response, "killrvideo", "videos")
+				Expect(code).To(Equal(http.StatusOK))
+				Expect(response.Rows).To(HaveLen(1))
+				Expect(response.Rows[0]).To(MatchAllKeys(Keys{
+					"name":            Equal("video 1"),
+					"_writetime_name": Equal("1000"),
+					"_ttl_name":       BeNumerically("~", 3600, 60),
+				}))
+			})
+
+			It("Should return 400 when selecting the write time of a primary key column", func() {
+				body := `{"filters": [], "writeTime": ["videoid"]}`
+				var response models.ModelError
+				code := rest.ExecutePost(routes, pathFormat, body, &response, "killrvideo", "videos")
+				Expect(code).To(Equal(http.StatusBadRequest))
+				Expect(response.Description).To(Equal("write time and ttl are not supported for column 'videoid'"))
+			})
 		})
 
 		Describe("PUT /keyspaces/{keyspaceName}/tables/{tableName}/rows/{rowIdentifier}", func() {
@@ -223,6 +459,21 @@ var _ = Describe("DataEndpoint", func() {
 				}))
 			})
 
+			It("Should upsert a row using the provided timestamp", func() {
+				id := schemas.NewUuid()
+				body := `{ "changeset": [{ "column": "name", "value": "sample"}], "timestamp": 1000}`
+
+				var response models.RowsResponse
+				code := rest.ExecutePut(routes, pathFormat, body, &response, "killrvideo", "videos", id)
+				Expect(code).To(Equal(http.StatusOK))
+
+				rs, err := dbClient.Execute(
+					"SELECT WRITETIME(name) AS wt FROM killrvideo.videos WHERE videoid = ?", nil, id)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(rs.Values()).To(HaveLen(1))
+				Expect(rs.Values()[0]["wt"]).To(PointTo(Equal("1000")))
+			})
+
 			It("Should return 404 when keyspace is not found", func() {
 				id := schemas.NewUuid()
 				body := `{ "changeset": [{ "column": "name", "value": "sample"}]}`
@@ -269,6 +520,48 @@ var _ = Describe("DataEndpoint", func() {
 				}))
 			})
 
+			It("Should modify the elements of collections", func() {
+				id := schemas.NewUuid()
+				removed := schemas.NewUuid()
+				added := schemas.NewUuid()
+				_, err := dbClient.Execute(
+					fmt.Sprintf("INSERT INTO datatypes.collections (id, list_text, set_uuid) VALUES (?, ['b', 'c'], {%s})",
+						removed), nil, id)
+				Expect(err).NotTo(HaveOccurred())
+
+				body := fmt.Sprintf(`{ "changeset": [
+				  { "column": "list_text", "operation": "prepend", "value": ["a"]},
+				  { "column": "list_text", "operation": "append", "value": ["d"]},
+				  { "column": "set_uuid", "operation": "append", "value": ["%s"]},
+				  { "column": "set_uuid", "operation": "remove", "value": ["%s"]}
+				]}`, added, removed)
+
+				var response models.RowsResponse
+				code := rest.ExecutePut(routes, pathFormat, body, &response, "datatypes", "collections", id)
+				Expect(code).To(Equal(http.StatusOK))
+				Expect(response.Success).To(BeTrue())
+
+				body = `{ "changeset": [{ "column": "list_text", "operation": "put", "key": 1, "value": "B"}]}`
+				code = rest.ExecutePut(routes, pathFormat, body, &response, "datatypes", "collections", id)
+				Expect(code).To(Equal(http.StatusOK))
+
+				rs, err := dbClient.Execute(
+					"SELECT list_text, set_uuid FROM datatypes.collections WHERE id = ?", nil, id)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(rs.Values()).To(HaveLen(1))
+				Expect(rs.Values()[0]["list_text"]).To(PointTo(Equal([]string{"a", "B", "c", "d"})))
+				Expect(rs.Values()[0]["set_uuid"]).To(PointTo(HaveLen(1)))
+			})
+
+			It("Should return 400 when the collection operation is not supported", func() {
+				id := schemas.NewUuid()
+				body := `{ "changeset": [{ "column": "set_uuid", "operation": "prepend", "value": []}]}`
+				var response models.ModelError
+				code := rest.ExecutePut(routes, pathFormat, body, &response, "datatypes", "collections", id)
+				Expect(code).To(Equal(http.StatusBadRequest))
+				Expect(response.Description).To(Equal("operation 'prepend' not supported for column 'set_uuid'"))
+			})
+
 			for _, itemEach := range datatypes.ScalarJsonValues() {
 				// Capture item
 				item := itemEach
@@ -296,6 +589,46 @@ var _ = Describe("DataEndpoint", func() {
 			}
 		})
 
+		Describe("POST /keyspaces/{keyspaceName}/tables/{tableName}/increment/{rowIdentifier}", func() {
+			pathFormat := e.RowIncrementPathFormat
+
+			It("Should increment and decrement counters", func() {
+				id := schemas.NewUuid()
+				body := `{"deltas": [{"column": "rating_counter", "value": 3}, {"column": "rating_total", "value": 10}]}`
+				var response models.RowsResponse
+				code := rest.ExecutePost(routes, pathFormat, body, &response, "killrvideo", "video_rating", id)
+				Expect(code).To(Equal(http.StatusOK))
+				Expect(response.Success).To(BeTrue())
+
+				body = `{"deltas": [{"column": "rating_counter", "value": -1}]}`
+				code = rest.ExecutePost(routes, pathFormat, body, &response, "killrvideo", "video_rating", id)
+				Expect(code).To(Equal(http.StatusOK))
+
+				rs, err := dbClient.Execute("SELECT * FROM killrvideo.video_rating WHERE videoid = ?", nil, id)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(rs.Values()).To(HaveLen(1))
+				Expect(rs.Values()[0]).To(MatchKeys(IgnoreExtras, Keys{
+					"rating_counter": PointTo(Equal("2")),
+					"rating_total":   PointTo(Equal("10")),
+				}))
+			})
+
+			It("Should return 400 when the column is not a counter", func() {
+				id := schemas.NewUuid()
+				body := `{"deltas": [{"column": "videoid", "value": 1}]}`
+				var response models.ModelError
+				code := rest.ExecutePost(routes, pathFormat, body, &response, "killrvideo", "video_rating", id)
+				Expect(code).To(Equal(http.StatusBadRequest))
+				Expect(response.Description).To(Equal("column 'videoid' is not a counter"))
+			})
+
+			It("Should return 400 when inserting into a counter table", func() {
+				body := fmt.Sprintf(`{"columns": [{"name": "videoid", "value": "%s"}]}`, schemas.NewUuid())
+				code := rest.ExecutePost(routes, e.RowsPathFormat, body, nil, "killrvideo", "video_rating")
+				Expect(code).To(Equal(http.StatusBadRequest))
+			})
+		})
+
 		Describe("DELETE /keyspaces/{keyspaceName}/tables/{tableName}/rows/{rowIdentifier}", func() {
 			pathFormat := e.RowSinglePathFormat
 
@@ -313,6 +646,61 @@ var _ = Describe("DataEndpoint", func() {
 				Expect(rs.Values()).To(HaveLen(0))
 			})
 
+			It("Should not delete a row written after the provided timestamp", func() {
+				id := schemas.NewUuid()
+				insertIntoVideos(dbClient, id, "sample video")
+
+				w := rest.ExecuteRaw(
+					http.MethodDelete, routes, pathFormat, "", `{"timestamp": 1}`, "killrvideo", "videos", id)
+				Expect(w.Code).To(Equal(http.StatusNoContent))
+
+				rs, err := dbClient.Execute("SELECT * FROM killrvideo.videos WHERE videoid = ?", nil, id)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(rs.Values()).To(HaveLen(1))
+			})
+
+			It("Should delete columns and keep the rest of the row", func() {
+				id := schemas.NewUuid()
+				insertIntoVideos(dbClient, id, "sample video")
+
+				w := rest.ExecuteRaw(
+					http.MethodDelete, routes, pathFormat, "", `{"columns": ["name"]}`, "killrvideo", "videos", id)
+				Expect(w.Code).To(Equal(http.StatusNoContent))
+
+				rs, err := dbClient.Execute("SELECT * FROM killrvideo.videos WHERE videoid = ?", nil, id)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(rs.Values()).To(HaveLen(1))
+				Expect(rs.Values()[0]).To(MatchKeys(IgnoreExtras, Keys{
+					"name":   BeNil(),
+					"userid": PointTo(Equal(id)),
+				}))
+			})
+
+			It("Should delete static columns using the partition key", func() {
+				id := schemas.NewUuid()
+				_, err := dbClient.Execute(
+					"INSERT INTO datatypes.table_static (id1, id2, value, value_static) VALUES (?, 1, 2, 3)", nil, id)
+				Expect(err).NotTo(HaveOccurred())
+
+				w := rest.ExecuteRaw(http.MethodDelete, routes, pathFormat, "", `{"columns": ["value_static"]}`,
+					"datatypes", "table_static", id)
+				Expect(w.Code).To(Equal(http.StatusNoContent))
+
+				rs, err := dbClient.Execute("SELECT * FROM datatypes.table_static WHERE id1 = ?", nil, id)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(rs.Values()).To(HaveLen(1))
+				Expect(rs.Values()[0]).To(MatchKeys(IgnoreExtras, Keys{
+					"value":        PointTo(Equal(2)),
+					"value_static": BeNil(),
+				}))
+			})
+
+			It("Should return 400 when deleting regular columns using the partition key", func() {
+				w := rest.ExecuteRaw(http.MethodDelete, routes, pathFormat, "", `{"columns": ["value"]}`,
+					"datatypes", "table_static", schemas.NewUuid())
+				Expect(w.Code).To(Equal(http.StatusBadRequest))
+			})
+
 			It("Should return 404 when keyspace is not found", func() {
 				code := rest.ExecuteDelete(routes, pathFormat, "keyspace_not_found", "videos", "abc")
 				Expect(code).To(Equal(http.StatusNotFound))
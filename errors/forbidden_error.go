@@ -0,0 +1,13 @@
+package errors
+
+type ForbiddenError struct {
+	msg string
+}
+
+func (e *ForbiddenError) Error() string {
+	return e.msg
+}
+
+func NewForbiddenError(text string) error {
+	return &ForbiddenError{text}
+}
@@ -0,0 +1,355 @@
+package graphql
+
+import (
+	"fmt"
+	"github.com/datastax/cassandra-data-apis/auth"
+	"github.com/datastax/cassandra-data-apis/db"
+	"github.com/datastax/cassandra-data-apis/types"
+	"github.com/gocql/gocql"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"sort"
+)
+
+const (
+	aggregateFieldName = "aggregate"
+	countFieldName     = "count"
+	groupFieldName     = "group"
+)
+
+// aggregateFunctions are the functions applied to columns, in the order they are included in the query
+var aggregateFunctions = []db.AggregateFunction{db.AggregateMin, db.AggregateMax, db.AggregateSum, db.AggregateAvg}
+
+// aggregateFieldNames contains the field name of the aggregate type by aggregate function
+var aggregateFieldNames = map[db.AggregateFunction]string{
+	db.AggregateMin: "min",
+	db.AggregateMax: "max",
+	db.AggregateSum: "sum",
+	db.AggregateAvg: "avg",
+}
+
+// numericTypes are the CQL types that support SUM and AVG
+var numericTypes = map[gocql.Type]bool{
+	gocql.TypeInt:      true,
+	gocql.TypeTinyInt:  true,
+	gocql.TypeSmallInt: true,
+	gocql.TypeBigInt:   true,
+	gocql.TypeCounter:  true,
+	gocql.TypeFloat:    true,
+	gocql.TypeDouble:   true,
+	gocql.TypeDecimal:  true,
+	gocql.TypeVarint:   true,
+}
+
+// queryResult is the result of a select query, it contains the query to be able to compute the aggregates of the
+// rows
+type queryResult struct {
+	PageState string                   `json:"pageState"`
+	Values    []map[string]interface{} `json:"values"`
+
+	where      []types.ConditionItem
+	options    types.QueryOptions
+	userOrRole string
+}
+
+func (s *KeyspaceGraphQLSchema) buildAggregateTypes(keyspace *gocql.KeyspaceMetadata) {
+	s.aggregateTypes = make(map[string]*graphql.Object, len(keyspace.Tables))
+	s.groupByEnums = make(map[string]*graphql.Enum, len(keyspace.Tables))
+
+	for _, table := range keyspace.Tables {
+		valueType, ok := s.tableValueTypes[table.Name]
+		if !ok {
+			continue
+		}
+
+		valueFields := valueType.Fields()
+		comparableFields := graphql.Fields{}
+		numericFields := graphql.Fields{}
+		groupByValues := graphql.EnumValueConfigMap{}
+		for name, column := range table.Columns {
+			fieldName := s.naming.ToGraphQLField(table.Name, name)
+			field, ok := valueFields[fieldName]
+			if !ok {
+				continue
+			}
+
+			// Durations can be filtered by equality but have no order
+			if operatorsInputTypes[column.Type.Type()] != nil && column.Type.Type() != gocql.TypeDuration {
+				comparableFields[fieldName] = &graphql.Field{Type: field.Type}
+			}
+			if numericTypes[column.Type.Type()] {
+				numericFields[fieldName] = &graphql.Field{Type: field.Type}
+			}
+			if column.Kind == gocql.ColumnPartitionKey || column.Kind == gocql.ColumnClusteringKey {
+				groupByValues[fieldName] = &graphql.EnumValueConfig{
+					Value:       name,
+					Description: fmt.Sprintf("Group %s by %s.", table.Name, name),
+				}
+			}
+		}
+
+		fields := graphql.Fields{
+			countFieldName: {Type: bigint, Description: "The amount of rows."},
+			groupFieldName: {Type: valueType, Description: "The values of the columns used to group the rows."},
+		}
+
+		if len(comparableFields) > 0 {
+			minMaxType := graphql.NewObject(graphql.ObjectConfig{
+				Description: fmt.Sprintf("Minimum or maximum values of the '%s' table columns.", table.Name),
+				Name:        s.naming.ToGraphQLTypeUnique(table.Name, "AggregateValues"),
+				Fields:      comparableFields,
+			})
+			fields["min"] = &graphql.Field{Type: minMaxType}
+			fields["max"] = &graphql.Field{Type: minMaxType}
+		}
+
+		if len(numericFields) > 0 {
+			numericType := graphql.NewObject(graphql.ObjectConfig{
+				Description: fmt.Sprintf("Sum or average values of the '%s' table numeric columns.", table.Name),
+				Name:        s.naming.ToGraphQLTypeUnique(table.Name, "NumericValues"),
+				Fields:      numericFields,
+			})
+			fields["sum"] = &graphql.Field{Type: numericType}
+			fields["avg"] = &graphql.Field{Type: numericType}
+		}
+
+		s.aggregateTypes[table.Name] = graphql.NewObject(graphql.ObjectConfig{
+			Description: fmt.Sprintf("Aggregate result type for the '%s' table.", table.Name),
+			Name:        s.naming.ToGraphQLTypeUnique(table.Name, "Aggregate"),
+			Fields:      fields,
+		})
+
+		if len(groupByValues) > 0 {
+			s.groupByEnums[table.Name] = graphql.NewEnum(graphql.EnumConfig{
+				Description: fmt.Sprintf("Primary key columns to group the '%s' table rows.", table.Name),
+				Name:        s.naming.ToGraphQLTypeUnique(table.Name, "GroupBy"),
+				Values:      groupByValues,
+			})
+		}
+	}
+}
+
+// aggregateField returns the field of the query result type that computes the aggregates of the rows matching the
+// query
+func (s *KeyspaceGraphQLSchema) aggregateField(table *gocql.TableMetadata) *graphql.Field {
+	field := &graphql.Field{
+		Description: fmt.Sprintf("Computes aggregate functions over the '%s' rows matching the query.\n", table.Name) +
+			"All the rows are read, regardless of the page state. The number of groups can't exceed the page size " +
+			"or the limit of the query.",
+		Type:    graphql.NewList(graphql.NewNonNull(s.aggregateTypes[table.Name])),
+		Resolve: s.schemaGen.aggregateFieldResolver(table, s),
+	}
+
+	if groupByEnum, ok := s.groupByEnums[table.Name]; ok {
+		field.Args = graphql.FieldConfigArgument{
+			"groupBy": {Type: graphql.NewList(graphql.NewNonNull(groupByEnum))},
+		}
+	}
+
+	return field
+}
+
+func (sg *SchemaGenerator) aggregateFieldResolver(
+	table *gocql.TableMetadata,
+	ksSchema *KeyspaceGraphQLSchema,
+) graphql.FieldResolveFn {
+	return func(params graphql.ResolveParams) (interface{}, error) {
+		source, ok := params.Source.(*queryResult)
+		if !ok {
+			return nil, fmt.Errorf("unexpected source for field '%s'", aggregateFieldName)
+		}
+
+		var groupBy []string
+		if values, ok := params.Args["groupBy"].([]interface{}); ok {
+			for _, value := range values {
+				groupBy = append(groupBy, value.(string))
+			}
+		}
+
+		fields := selectedFields(params.Info, params.Info.FieldASTs)
+		var aggregates []db.Aggregate
+		if len(fields[countFieldName]) > 0 {
+			aggregates = append(aggregates, db.Aggregate{Function: db.AggregateCount, Alias: countFieldName})
+		}
+
+		readColumns := append([]string{}, groupBy...)
+		for _, function := range aggregateFunctions {
+			columnFields := selectedFields(params.Info, fields[aggregateFieldNames[function]])
+			for _, columnField := range sortedKeys(columnFields) {
+				if columnField == "__typename" {
+					continue
+				}
+				column := ksSchema.naming.ToCQLColumn(table.Name, columnField)
+				aggregates = append(aggregates, db.Aggregate{
+					Function: function,
+					Column:   column,
+					Alias:    aggregateAlias(function, column),
+				})
+				readColumns = append(readColumns, column)
+			}
+		}
+
+		if len(aggregates) == 0 && len(groupBy) == 0 {
+			// Only the group or the type name was selected
+			return []map[string]interface{}{{}}, nil
+		}
+
+		if err := sg.checkPolicy(params, table, auth.PermissionRead, readColumns); err != nil {
+			return nil, err
+		}
+
+		if source.options.AllowFiltering {
+			// The limit of the query doesn't bound the rows read to compute the aggregates
+			if err := sg.checkAllowFiltering(table, source.where, 0); err != nil {
+				return nil, err
+			}
+		}
+
+		options := db.NewQueryOptions().
+			WithUserOrRole(source.userOrRole).
+			WithPageSize(source.options.PageSize).
+			WithConsistency(gocql.Consistency(source.options.Consistency))
+
+		if len(source.where) == 0 && len(groupBy) == 0 && db.CanScanAggregates(table, aggregates) {
+			// Aggregating all the rows of the table is distributed across the token ranges
+			row, err := sg.dbClient.ScanAggregates(
+				table.Keyspace, table.Name, aggregates, options, db.DefaultScanConcurrency)
+			if err != nil {
+				return nil, err
+			}
+			return []map[string]interface{}{ksSchema.adaptAggregateResult(table.Name, row, aggregates, nil)}, nil
+		}
+
+		info := &db.SelectInfo{
+			Keyspace:       table.Keyspace,
+			Table:          table.Name,
+			Columns:        groupBy,
+			Aggregates:     aggregates,
+			GroupBy:        groupBy,
+			Where:          source.where,
+			AllowFiltering: source.options.AllowFiltering,
+		}
+
+		maxGroups := maxAggregateGroups(source.options)
+		var result []map[string]interface{}
+		for {
+			rs, err := sg.dbClient.Select(info, options)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, row := range rs.Values() {
+				result = append(result, ksSchema.adaptAggregateResult(table.Name, row, aggregates, groupBy))
+			}
+
+			if len(result) > maxGroups {
+				return nil, fmt.Errorf("aggregate exceeds the maximum of %d groups, the rows requested by the "+
+					"query using pageSize and limit", maxGroups)
+			}
+
+			pageState := rs.PageState()
+			if len(pageState) == 0 {
+				break
+			}
+			options = options.WithPageState(pageState)
+		}
+
+		return result, nil
+	}
+}
+
+// maxAggregateGroups returns the maximum number of groups of an aggregate, which are bounded by the rows requested by
+// the query, the same way as the values are
+func maxAggregateGroups(options types.QueryOptions) int {
+	pageSize := options.PageSize
+	if pageSize <= 0 {
+		pageSize = driverPageSize
+	}
+
+	if options.Limit > 0 && options.Limit < pageSize {
+		return options.Limit
+	}
+
+	return pageSize
+}
+
+func (s *KeyspaceGraphQLSchema) adaptAggregateResult(
+	tableName string,
+	row map[string]interface{},
+	aggregates []db.Aggregate,
+	groupBy []string,
+) map[string]interface{} {
+	result := make(map[string]interface{}, len(aggregateFieldNames)+2)
+	for _, aggregate := range aggregates {
+		if aggregate.Column == "" {
+			result[countFieldName] = adaptResultValue(row[aggregate.Alias])
+			continue
+		}
+
+		fieldName := aggregateFieldNames[aggregate.Function]
+		values, ok := result[fieldName].(map[string]interface{})
+		if !ok {
+			values = make(map[string]interface{})
+			result[fieldName] = values
+		}
+		values[s.naming.ToGraphQLField(tableName, aggregate.Column)] = adaptResultValue(row[aggregate.Alias])
+	}
+
+	if len(groupBy) > 0 {
+		group := make(map[string]interface{}, len(groupBy))
+		for _, column := range groupBy {
+			group[s.naming.ToGraphQLField(tableName, column)] = adaptResultValue(row[column])
+		}
+		result[groupFieldName] = group
+	}
+
+	return result
+}
+
+// aggregateAlias returns the name of the result of an aggregate function, it can't collide with column names
+func aggregateAlias(function db.AggregateFunction, column string) string {
+	return fmt.Sprintf("%s(%s)", function, column)
+}
+
+// selectedFields returns the fields selected by name, including the fields selected through fragments
+func selectedFields(info graphql.ResolveInfo, fields []*ast.Field) map[string][]*ast.Field {
+	result := make(map[string][]*ast.Field)
+	visited := make(map[string]bool)
+
+	var collect func(selectionSet *ast.SelectionSet)
+	collect = func(selectionSet *ast.SelectionSet) {
+		if selectionSet == nil {
+			return
+		}
+
+		for _, selection := range selectionSet.Selections {
+			switch s := selection.(type) {
+			case *ast.Field:
+				result[s.Name.Value] = append(result[s.Name.Value], s)
+			case *ast.InlineFragment:
+				collect(s.SelectionSet)
+			case *ast.FragmentSpread:
+				name := s.Name.Value
+				if fragment, ok := info.Fragments[name].(*ast.FragmentDefinition); ok && !visited[name] {
+					visited[name] = true
+					collect(fragment.SelectionSet)
+				}
+			}
+		}
+	}
+
+	for _, field := range fields {
+		collect(field.SelectionSet)
+	}
+
+	return result
+}
+
+func sortedKeys(fields map[string][]*ast.Field) []string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
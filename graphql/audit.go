@@ -0,0 +1,68 @@
+package graphql
+
+import (
+	"github.com/datastax/cassandra-data-apis/audit"
+	"github.com/datastax/cassandra-data-apis/types"
+	"github.com/gocql/gocql"
+	"github.com/graphql-go/graphql"
+)
+
+var auditOperations = map[mutationOperation]string{
+	insertOperation:    audit.OperationInsert,
+	updateOperation:    audit.OperationUpdate,
+	deleteOperation:    audit.OperationDelete,
+	incrementOperation: audit.OperationUpdate,
+}
+
+// auditMutation records the result of an insert, update or delete, including the primary key of the row
+func (sg *SchemaGenerator) auditMutation(
+	params graphql.ResolveParams,
+	table *gocql.TableMetadata,
+	operation mutationOperation,
+	columnNames []string,
+	queryParams []interface{},
+	result *types.ModificationResult,
+	err error,
+) {
+	primaryKey := make(map[string]interface{})
+	for i, name := range columnNames {
+		column, found := table.Columns[name]
+		if found && (column.Kind == gocql.ColumnPartitionKey || column.Kind == gocql.ColumnClusteringKey) {
+			primaryKey[name] = queryParams[i]
+		}
+	}
+
+	event := audit.Event{
+		Source:     audit.SourceGraphQL,
+		Operation:  auditOperations[operation],
+		Keyspace:   table.Keyspace,
+		Table:      table.Name,
+		PrimaryKey: primaryKey,
+		Applied:    err == nil && result != nil && result.Applied,
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	sg.auditor.Log(params.Context, event)
+}
+
+// auditSchemaChange records the result of a DDL statement
+func (sg *SchemaGenerator) auditSchemaChange(
+	params graphql.ResolveParams,
+	operation string,
+	keyspace string,
+	table string,
+	err error,
+) {
+	event := audit.Event{
+		Source:    audit.SourceGraphQL,
+		Operation: operation,
+		Keyspace:  keyspace,
+		Table:     table,
+		Applied:   err == nil,
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	sg.auditor.Log(params.Context, event)
+}
@@ -0,0 +1,119 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"github.com/datastax/cassandra-data-apis/db"
+	"github.com/gocql/gocql"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// atomicDirective marks a mutation operation to be executed as a single CQL batch
+var atomicDirective = graphql.NewDirective(graphql.DirectiveConfig{
+	Name: "atomic",
+	Description: "Executes all the mutations of the operation atomically as a single batch. " +
+		"Conditional mutations are not supported.",
+	Locations: []string{graphql.DirectiveLocationMutation},
+	Args: graphql.FieldConfigArgument{
+		"unlogged": &graphql.ArgumentConfig{
+			Type:         graphql.Boolean,
+			DefaultValue: false,
+			Description:  "Use an unlogged batch, only atomic when all the mutations target the same partition",
+		},
+	},
+})
+
+var schemaDirectives = append([]*graphql.Directive{atomicDirective}, graphql.SpecifiedDirectives...)
+
+var errBatchNotExecuted = errors.New("atomic operation was not executed")
+
+type contextKey struct {
+	name string
+}
+
+var batchKey = &contextKey{"mutationBatch"}
+
+// mutationBatch collects the statements of an atomic mutation operation, executed once all the fields are resolved
+type mutationBatch struct {
+	batchType  gocql.BatchType
+	statements []db.Statement
+	options    *db.QueryOptions
+	onExecuted []func(err error)
+}
+
+func withMutationBatch(ctx context.Context, batch *mutationBatch) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, batchKey, batch)
+}
+
+// atomicBatch gets the batch of the operation when it's marked with the atomic directive, otherwise it returns nil
+func atomicBatch(params graphql.ResolveParams) *mutationBatch {
+	operation, ok := params.Info.Operation.(*ast.OperationDefinition)
+	if !ok || params.Context == nil {
+		return nil
+	}
+
+	for _, directive := range operation.Directives {
+		if directive.Name == nil || directive.Name.Value != atomicDirective.Name {
+			continue
+		}
+
+		batch, _ := params.Context.Value(batchKey).(*mutationBatch)
+		if batch == nil {
+			return nil
+		}
+
+		batch.batchType = gocql.LoggedBatch
+		for _, arg := range directive.Arguments {
+			if arg.Name.Value == "unlogged" && directiveArgValue(arg.Value, params.Info.VariableValues) == true {
+				batch.batchType = gocql.UnloggedBatch
+			}
+		}
+		return batch
+	}
+
+	return nil
+}
+
+func directiveArgValue(value ast.Value, variables map[string]interface{}) interface{} {
+	switch v := value.(type) {
+	case *ast.Variable:
+		return variables[v.Name.Value]
+	case *ast.BooleanValue:
+		return v.Value
+	}
+	return nil
+}
+
+func (b *mutationBatch) add(statement db.Statement, options *db.QueryOptions, onExecuted func(err error)) error {
+	if b.options == nil {
+		b.options = options
+	} else if b.options.Consistency != options.Consistency ||
+		b.options.SerialConsistency != options.SerialConsistency {
+		return errors.New("all the mutations of an atomic operation must use the same consistency")
+	}
+
+	b.statements = append(b.statements, statement)
+	b.onExecuted = append(b.onExecuted, onExecuted)
+	return nil
+}
+
+func (b *mutationBatch) execute(dbClient *db.Db) error {
+	err := dbClient.ExecuteBatch(b.batchType, b.statements, b.options)
+	b.done(err)
+	return err
+}
+
+// discard signals that the statements were not executed
+func (b *mutationBatch) discard() {
+	b.done(errBatchNotExecuted)
+}
+
+func (b *mutationBatch) done(err error) {
+	for _, onExecuted := range b.onExecuted {
+		onExecuted(err)
+	}
+}
@@ -0,0 +1,229 @@
+package graphql
+
+import (
+	"fmt"
+	"github.com/datastax/cassandra-data-apis/db"
+	"github.com/gocql/gocql"
+	"github.com/graphql-go/graphql"
+	"sort"
+)
+
+const operationsArgName = "operations"
+
+// collectionOperators contains the collection operator by field name of the operations input types, in the order
+// the operations are applied
+var collectionOperators = []struct {
+	fieldName string
+	operator  db.CollectionOperator
+}{
+	{"append", db.CollectionAppend},
+	{"prepend", db.CollectionPrepend},
+	{"remove", db.CollectionRemove},
+	{"setAt", db.CollectionPut},
+	{"put", db.CollectionPut},
+}
+
+func (s *KeyspaceGraphQLSchema) buildCollectionOperationsTypes(keyspace *gocql.KeyspaceMetadata) {
+	s.collectionOperationsTypes = make(map[string]*graphql.InputObject, len(keyspace.Tables))
+	operationTypes := make(map[string]*graphql.InputObject)
+
+	for _, table := range keyspace.Tables {
+		inputType, ok := s.tableScalarInputTypes[table.Name]
+		if !ok {
+			continue
+		}
+
+		inputFields := inputType.Fields()
+		fields := graphql.InputObjectConfigFieldMap{}
+		for name, column := range table.Columns {
+			fieldName := s.naming.ToGraphQLField(table.Name, name)
+			inputField, ok := inputFields[fieldName]
+			if !ok || column.Kind != gocql.ColumnRegular {
+				continue
+			}
+
+			listType, ok := inputField.Type.(*graphql.List)
+			if !ok {
+				continue
+			}
+
+			var operationsType *graphql.InputObject
+			switch column.Type.Type() {
+			case gocql.TypeList:
+				operationsType = s.listOperationsType(listType, operationTypes)
+			case gocql.TypeSet:
+				operationsType = s.setOperationsType(listType, operationTypes)
+			case gocql.TypeMap:
+				operationsType = s.mapOperationsType(listType, operationTypes)
+			}
+
+			if operationsType != nil {
+				fields[fieldName] = &graphql.InputObjectFieldConfig{Type: operationsType}
+			}
+		}
+
+		if len(fields) == 0 {
+			continue
+		}
+
+		s.collectionOperationsTypes[table.Name] = graphql.NewInputObject(graphql.InputObjectConfig{
+			Description: fmt.Sprintf("Input type to modify the elements of the '%s' table collections.", table.Name),
+			Name:        s.naming.ToGraphQLTypeUnique(table.Name, "OperationsInput"),
+			Fields:      fields,
+		})
+	}
+}
+
+func (s *KeyspaceGraphQLSchema) listOperationsType(
+	listType *graphql.List,
+	operationTypes map[string]*graphql.InputObject,
+) *graphql.InputObject {
+	elemName := getTypeName(listType.OfType)
+	if elemName == "" {
+		return nil
+	}
+
+	typeName := s.naming.ToGraphQLTypeUnique(fmt.Sprintf("List%sOperations", elemName), "Input")
+	if t, ok := operationTypes[typeName]; ok {
+		return t
+	}
+
+	indexType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: s.naming.ToGraphQLTypeUnique(fmt.Sprintf("List%sIndex", elemName), "Input"),
+		Fields: graphql.InputObjectConfigFieldMap{
+			"index": {Type: graphql.NewNonNull(graphql.Int)},
+			"value": {Type: listType.OfType},
+		},
+	})
+
+	t := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: typeName,
+		Fields: graphql.InputObjectConfigFieldMap{
+			"append":  {Type: listType, Description: "Elements to add at the end of the list."},
+			"prepend": {Type: listType, Description: "Elements to add at the beginning of the list."},
+			"remove":  {Type: listType, Description: "Elements to remove from the list."},
+			"setAt": {
+				Type:        graphql.NewList(graphql.NewNonNull(indexType)),
+				Description: "Values to set at the provided indexes of the list.",
+			},
+		},
+	})
+	operationTypes[typeName] = t
+	return t
+}
+
+func (s *KeyspaceGraphQLSchema) setOperationsType(
+	listType *graphql.List,
+	operationTypes map[string]*graphql.InputObject,
+) *graphql.InputObject {
+	elemName := getTypeName(listType.OfType)
+	if elemName == "" {
+		return nil
+	}
+
+	typeName := s.naming.ToGraphQLTypeUnique(fmt.Sprintf("Set%sOperations", elemName), "Input")
+	if t, ok := operationTypes[typeName]; ok {
+		return t
+	}
+
+	t := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: typeName,
+		Fields: graphql.InputObjectConfigFieldMap{
+			"append": {Type: listType, Description: "Elements to add to the set."},
+			"remove": {Type: listType, Description: "Elements to remove from the set."},
+		},
+	})
+	operationTypes[typeName] = t
+	return t
+}
+
+func (s *KeyspaceGraphQLSchema) mapOperationsType(
+	listType *graphql.List,
+	operationTypes map[string]*graphql.InputObject,
+) *graphql.InputObject {
+	kvType, ok := listType.OfType.(*graphql.InputObject)
+	if !ok {
+		return nil
+	}
+
+	keyType, ok := kvType.Fields()["key"].Type.(*graphql.NonNull)
+	if !ok {
+		return nil
+	}
+
+	keyName := getTypeName(keyType.OfType)
+	valueName := getTypeName(kvType.Fields()["value"].Type)
+	if keyName == "" || valueName == "" {
+		return nil
+	}
+
+	typeName := s.naming.ToGraphQLTypeUnique(fmt.Sprintf("Map%s%sOperations", keyName, valueName), "Input")
+	if t, ok := operationTypes[typeName]; ok {
+		return t
+	}
+
+	t := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: typeName,
+		Fields: graphql.InputObjectConfigFieldMap{
+			"put":    {Type: listType, Description: "Keys and values to set in the map."},
+			"remove": {Type: graphql.NewList(keyType), Description: "Keys to remove from the map."},
+		},
+	})
+	operationTypes[typeName] = t
+	return t
+}
+
+// adaptCollectionOperations converts the operations argument of an update into the collection updates, sorted by
+// column
+func (s *KeyspaceGraphQLSchema) adaptCollectionOperations(
+	tableName string,
+	data map[string]interface{},
+) []db.CollectionUpdate {
+	fieldNames := make([]string, 0, len(data))
+	for fieldName := range data {
+		fieldNames = append(fieldNames, fieldName)
+	}
+	sort.Strings(fieldNames)
+
+	var result []db.CollectionUpdate
+	for _, fieldName := range fieldNames {
+		operations, ok := data[fieldName].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		column := s.naming.ToCQLColumn(tableName, fieldName)
+		for _, item := range collectionOperators {
+			values, ok := operations[item.fieldName].([]interface{})
+			if !ok {
+				continue
+			}
+
+			if item.operator != db.CollectionPut {
+				elements := make([]interface{}, 0, len(values))
+				for _, value := range values {
+					elements = append(elements, adaptParameterValue(value))
+				}
+				result = append(result, db.CollectionUpdate{Column: column, Operator: item.operator, Value: elements})
+				continue
+			}
+
+			// Each entry sets the value of a list index or a map key
+			keyName := "key"
+			if item.fieldName == "setAt" {
+				keyName = "index"
+			}
+			for _, value := range values {
+				entry := value.(map[string]interface{})
+				result = append(result, db.CollectionUpdate{
+					Column:   column,
+					Operator: db.CollectionPut,
+					Key:      adaptParameterValue(entry[keyName]),
+					Value:    adaptParameterValue(entry["value"]),
+				})
+			}
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,137 @@
+package graphql
+
+import (
+	"fmt"
+	"github.com/datastax/cassandra-data-apis/db"
+	"github.com/gocql/gocql"
+	"github.com/graphql-go/graphql"
+	"sort"
+)
+
+const (
+	deleteColumnsArgName  = "columns"
+	deleteElementsArgName = "elements"
+)
+
+func (s *KeyspaceGraphQLSchema) buildDeleteTargetTypes(keyspace *gocql.KeyspaceMetadata) {
+	s.deleteColumnEnums = make(map[string]*graphql.Enum, len(keyspace.Tables))
+	s.deleteElementsTypes = make(map[string]*graphql.InputObject, len(keyspace.Tables))
+
+	for _, table := range keyspace.Tables {
+		inputType, ok := s.tableScalarInputTypes[table.Name]
+		if !ok {
+			continue
+		}
+
+		inputFields := inputType.Fields()
+		columnValues := graphql.EnumValueConfigMap{}
+		elementFields := graphql.InputObjectConfigFieldMap{}
+		for name, column := range table.Columns {
+			fieldName := s.naming.ToGraphQLField(table.Name, name)
+			inputField, ok := inputFields[fieldName]
+			if !ok || (column.Kind != gocql.ColumnRegular && column.Kind != gocql.ColumnStatic) {
+				continue
+			}
+
+			columnValues[fieldName] = &graphql.EnumValueConfig{
+				Value:       name,
+				Description: fmt.Sprintf("Delete the %s column of %s.", name, table.Name),
+			}
+
+			switch column.Type.Type() {
+			case gocql.TypeList:
+				elementFields[fieldName] = &graphql.InputObjectFieldConfig{
+					Type:        graphql.NewList(graphql.NewNonNull(graphql.Int)),
+					Description: fmt.Sprintf("Indexes of the %s elements to delete.", name),
+				}
+			case gocql.TypeMap:
+				if keyType := mapKeyInputType(inputField.Type); keyType != nil {
+					elementFields[fieldName] = &graphql.InputObjectFieldConfig{
+						Type:        graphql.NewList(keyType),
+						Description: fmt.Sprintf("Keys of the %s entries to delete.", name),
+					}
+				}
+			}
+		}
+
+		if len(columnValues) > 0 {
+			s.deleteColumnEnums[table.Name] = graphql.NewEnum(graphql.EnumConfig{
+				Description: fmt.Sprintf("Columns that can be deleted from the '%s' table rows.", table.Name),
+				Name:        s.naming.ToGraphQLTypeUnique(table.Name, "DeleteColumn"),
+				Values:      columnValues,
+			})
+		}
+
+		if len(elementFields) > 0 {
+			s.deleteElementsTypes[table.Name] = graphql.NewInputObject(graphql.InputObjectConfig{
+				Description: fmt.Sprintf("Input type to delete elements of the '%s' table collections.", table.Name),
+				Name:        s.naming.ToGraphQLTypeUnique(table.Name, "DeleteElementsInput"),
+				Fields:      elementFields,
+			})
+		}
+	}
+}
+
+// deleteTargetArgs adds the arguments to delete columns and collection elements instead of the whole row
+func (s *KeyspaceGraphQLSchema) deleteTargetArgs(tableName string, args graphql.FieldConfigArgument) {
+	if columnEnum, ok := s.deleteColumnEnums[tableName]; ok {
+		args[deleteColumnsArgName] = &graphql.ArgumentConfig{
+			Type: graphql.NewList(graphql.NewNonNull(columnEnum)),
+			Description: "Columns to delete instead of the whole row. Static columns are deleted using only the " +
+				"partition key.",
+		}
+	}
+	if elementsType, ok := s.deleteElementsTypes[tableName]; ok {
+		args[deleteElementsArgName] = &graphql.ArgumentConfig{
+			Type:        elementsType,
+			Description: "Collection elements to delete instead of the whole row.",
+		}
+	}
+}
+
+// adaptDeleteTargets converts the columns and elements arguments of a delete into the delete targets
+func (s *KeyspaceGraphQLSchema) adaptDeleteTargets(tableName string, args map[string]interface{}) []db.DeleteTarget {
+	var result []db.DeleteTarget
+	if columns, ok := args[deleteColumnsArgName].([]interface{}); ok {
+		for _, column := range columns {
+			result = append(result, db.DeleteTarget{Column: column.(string)})
+		}
+	}
+
+	if elements, ok := args[deleteElementsArgName].(map[string]interface{}); ok {
+		fieldNames := make([]string, 0, len(elements))
+		for fieldName := range elements {
+			fieldNames = append(fieldNames, fieldName)
+		}
+		sort.Strings(fieldNames)
+
+		for _, fieldName := range fieldNames {
+			keys, _ := elements[fieldName].([]interface{})
+			for _, key := range keys {
+				result = append(result, db.DeleteTarget{
+					Column: s.naming.ToCQLColumn(tableName, fieldName),
+					Key:    adaptParameterValue(key),
+				})
+			}
+		}
+	}
+
+	return result
+}
+
+// mapKeyInputType returns the type of the keys of a map input type, represented as a list of key/value input types
+func mapKeyInputType(t graphql.Input) graphql.Input {
+	listType, ok := t.(*graphql.List)
+	if !ok {
+		return nil
+	}
+	kvType, ok := listType.OfType.(*graphql.InputObject)
+	if !ok {
+		return nil
+	}
+	keyField, ok := kvType.Fields()["key"]
+	if !ok {
+		return nil
+	}
+	return keyField.Type
+}
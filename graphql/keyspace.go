@@ -2,6 +2,8 @@ package graphql
 
 import (
 	"fmt"
+	"github.com/datastax/cassandra-data-apis/audit"
+	"github.com/datastax/cassandra-data-apis/auth"
 	"github.com/datastax/cassandra-data-apis/config"
 	"github.com/datastax/cassandra-data-apis/db"
 	"github.com/gocql/gocql"
@@ -121,7 +123,8 @@ func (sg *SchemaGenerator) buildKeyspaceQuery(singleKeyspace string) *graphql.Ob
 				},
 				Resolve: func(params graphql.ResolveParams) (interface{}, error) {
 					ksName := params.Args["name"].(string)
-					if sg.isKeyspaceExcludedOrNotSingle(ksName, singleKeyspace) {
+					if sg.isKeyspaceExcludedOrNotSingle(ksName, singleKeyspace) ||
+						!sg.policy.IsKeyspaceVisible(auth.ContextUserOrRole(params.Context), ksName) {
 						return nil, fmt.Errorf("keyspace does not exist '%s'", ksName)
 					}
 					keyspace, err := sg.dbClient.Keyspace(ksName)
@@ -143,7 +146,8 @@ func (sg *SchemaGenerator) buildKeyspaceQuery(singleKeyspace string) *graphql.Ob
 						}
 
 						for _, ksName := range ksNames {
-							if sg.isKeyspaceExcluded(ksName) {
+							if sg.isKeyspaceExcluded(ksName) ||
+								!sg.policy.IsKeyspaceVisible(auth.ContextUserOrRole(params.Context), ksName) {
 								continue
 							}
 							keyspace, err := sg.dbClient.Keyspace(ksName)
@@ -201,11 +205,17 @@ func (sg *SchemaGenerator) buildKeyspaceMutation(singleKeyspace string, ops conf
 					return nil, err
 				}
 
+				err = sg.policy.CheckTable(auth.ContextUserOrRole(params.Context), ksName, "", auth.PermissionSchema)
+				if err != nil {
+					return nil, err
+				}
+
 				err = sg.dbClient.CreateKeyspace(&db.CreateKeyspaceInfo{
 					Name:        ksName,
 					DCReplicas:  dcReplicas,
 					IfNotExists: getBoolArg(args, "ifNotExists"),
 				}, db.NewQueryOptions().WithUserOrRole(userOrRole).WithContext(params.Context))
+				sg.auditSchemaChange(params, audit.OperationCreateKeyspace, ksName, "", err)
 				return err != nil, err
 			},
 		}
@@ -231,10 +241,16 @@ func (sg *SchemaGenerator) buildKeyspaceMutation(singleKeyspace string, ops conf
 					return nil, err
 				}
 
+				err = sg.policy.CheckTable(auth.ContextUserOrRole(params.Context), ksName, "", auth.PermissionSchema)
+				if err != nil {
+					return nil, err
+				}
+
 				err = sg.dbClient.DropKeyspace(&db.DropKeyspaceInfo{
 					Name:     ksName,
 					IfExists: getBoolArg(args, "ifExists"),
 				}, db.NewQueryOptions().WithUserOrRole(userOrRole).WithContext(params.Context))
+				sg.auditSchemaChange(params, audit.OperationDropKeyspace, ksName, "", err)
 				return err != nil, err
 			},
 		}
@@ -342,6 +358,10 @@ func (sg *SchemaGenerator) checkKeyspace(singleKeyspace string, p graphql.Resolv
 	if  sg.isKeyspaceExcludedOrNotSingle(ksName, singleKeyspace){
 		return nil, fmt.Errorf("keyspace does not exist '%s'", ksName)
 	}
+	tableName, _ := p.Args["tableName"].(string)
+	if err := sg.policy.CheckTable(auth.ContextUserOrRole(p.Context), ksName, tableName, auth.PermissionSchema); err != nil {
+		return nil, err
+	}
 	return op(p)
 }
 
@@ -6,6 +6,7 @@ import (
 	"github.com/datastax/cassandra-data-apis/db"
 	"github.com/datastax/cassandra-data-apis/types"
 	"regexp"
+	"strings"
 
 	"github.com/gocql/gocql"
 	"github.com/graphql-go/graphql"
@@ -26,11 +27,29 @@ type KeyspaceGraphQLSchema struct {
 	resultUpdateTypes map[string]*graphql.Object
 	// A map containing the order enum by table name
 	orderEnums map[string]*graphql.Enum
+	// A map containing the aggregate result type by table name
+	aggregateTypes map[string]*graphql.Object
+	// A map containing the enum of the primary key columns to group rows by table name
+	groupByEnums map[string]*graphql.Enum
+	// A map containing the input type of the operations on the collection columns by table name
+	collectionOperationsTypes map[string]*graphql.InputObject
+	// A map containing the enum of the columns that can be deleted by table name
+	deleteColumnEnums map[string]*graphql.Enum
+	// A map containing the input type of the collection elements to delete by table name
+	deleteElementsTypes map[string]*graphql.InputObject
+	// A map containing the input type to order the rows by similarity to a vector by table name
+	annInputTypes map[string]*graphql.InputObject
+	// A map containing the filter input types of list, set and map columns by type name
+	collectionOperatorTypes map[string]*graphql.InputObject
 	// A map containing key/value types for maps
 	keyValueTypes map[string]graphql.Output
+	// A map containing the object and input types of user-defined types and tuples by type name
+	compositeTypes map[string]graphql.Output
 
 	schemaGen *SchemaGenerator
 	naming    config.NamingConvention
+	// The permissions of the role the schema is built for, nil when the schema is shared by all roles
+	permissions *db.RolePermissions
 }
 
 var inputQueryOptions = graphql.NewInputObject(graphql.InputObjectConfig{
@@ -41,6 +60,11 @@ var inputQueryOptions = graphql.NewInputObject(graphql.InputObjectConfig{
 		"pageSize":    {Type: graphql.Int, DefaultValue: config.DefaultPageSize},
 		"pageState":   {Type: graphql.String},
 		"consistency": {Type: queryConsistencyEnum, DefaultValue: config.DefaultConsistencyLevel},
+		"allowFiltering": {
+			Type: graphql.Boolean,
+			Description: "Executes the query using ALLOW FILTERING, it must be enabled for the table on the server " +
+				"and the query must restrict the partition key or use a limit.",
+		},
 	},
 })
 
@@ -51,6 +75,10 @@ var inputMutationOptions = graphql.NewInputObject(graphql.InputObjectConfig{
 		"ttl":               {Type: graphql.Int, DefaultValue: -1},
 		"consistency":       {Type: mutationConsistencyEnum, DefaultValue: config.DefaultConsistencyLevel},
 		"serialConsistency": {Type: serialConsistencyEnum, DefaultValue: config.DefaultSerialConsistencyLevel},
+		"timestamp": {
+			Type:        bigint,
+			Description: "The write time of the mutation in microseconds since the epoch, defaults to the server time.",
+		},
 	},
 })
 
@@ -133,13 +161,31 @@ func (s *KeyspaceGraphQLSchema) buildType(typeInfo gocql.TypeInfo, isInput bool)
 		return blob, nil
 	case gocql.TypeTime:
 		return localTime, nil
+	case gocql.TypeDate:
+		return date, nil
+	case gocql.TypeDuration:
+		return duration, nil
 	case gocql.TypeList, gocql.TypeSet:
 		elem, err := s.buildType(typeInfo.(gocql.CollectionType).Elem, isInput)
 		if err != nil {
 			return nil, err
 		}
 		return graphql.NewList(elem), nil
+	case gocql.TypeCustom:
+		if _, ok := typeInfo.(types.VectorType); ok {
+			return graphql.NewList(float32Scalar), nil
+		}
+		return nil, fmt.Errorf("Unsupported type %s", typeInfo.Custom())
+	case gocql.TypeUDT:
+		return s.buildUDTType(typeInfo.(gocql.UDTTypeInfo), isInput)
+	case gocql.TypeTuple:
+		return s.buildTupleType(typeInfo.(gocql.TupleTypeInfo), isInput)
 	case gocql.TypeMap:
+		keyType := typeInfo.(gocql.CollectionType).Key.Type()
+		if keyType == gocql.TypeUDT || keyType == gocql.TypeTuple || keyType == gocql.TypeCustom {
+			// Map parameters are converted to go maps, which can not use objects or vectors as keys
+			return nil, fmt.Errorf("Unsupported map key type %s", keyType.String())
+		}
 		key, err := s.buildType(typeInfo.(gocql.CollectionType).Key, isInput)
 		if err != nil {
 			return nil, err
@@ -233,6 +279,10 @@ func getTypeName(t graphql.Output) string {
 func (s *KeyspaceGraphQLSchema) BuildTypes(keyspace *gocql.KeyspaceMetadata) error {
 	s.buildOrderEnums(keyspace)
 	s.buildTableTypes(keyspace)
+	s.buildAnnTypes(keyspace)
+	s.buildCollectionOperationsTypes(keyspace)
+	s.buildDeleteTargetTypes(keyspace)
+	s.buildAggregateTypes(keyspace)
 	s.buildResultTypes(keyspace)
 	return nil
 }
@@ -242,6 +292,9 @@ func (s *KeyspaceGraphQLSchema) buildOrderEnums(keyspace *gocql.KeyspaceMetadata
 	for _, table := range keyspace.Tables {
 		values := make(map[string]*graphql.EnumValueConfig, len(table.Columns))
 		for _, column := range table.Columns {
+			if !s.schemaGen.policy.IsColumnVisible(table, column.Name) {
+				continue
+			}
 			field := s.naming.ToGraphQLField(table.Name, column.Name)
 			values[field+"_ASC"] = &graphql.EnumValueConfig{
 				Value:       column.Name + "_ASC",
@@ -263,17 +316,35 @@ func (s *KeyspaceGraphQLSchema) buildOrderEnums(keyspace *gocql.KeyspaceMetadata
 
 func (s *KeyspaceGraphQLSchema) buildTableTypes(keyspace *gocql.KeyspaceMetadata) {
 	s.keyValueTypes = make(map[string]graphql.Output)
+	s.collectionOperatorTypes = make(map[string]*graphql.InputObject)
+	s.compositeTypes = make(map[string]graphql.Output)
 	s.tableValueTypes = make(map[string]*graphql.Object, len(keyspace.Tables))
 	s.tableScalarInputTypes = make(map[string]*graphql.InputObject, len(keyspace.Tables))
 	s.tableOperatorInputTypes = make(map[string]*graphql.InputObject, len(keyspace.Tables))
 
 	for _, table := range keyspace.Tables {
+		if !s.schemaGen.policy.IsTableVisible(table.Keyspace, table.Name) {
+			s.schemaGen.logger.Debug("ignoring table not granted by the policy", "tableName", table.Name)
+			s.ignoredTables[table.Name] = true
+			continue
+		}
+
+		if !s.permissions.CanSelect(table.Keyspace, table.Name) && !s.permissions.CanModify(table.Keyspace, table.Name) {
+			s.ignoredTables[table.Name] = true
+			continue
+		}
+
 		fields := graphql.Fields{}
 		inputFields := graphql.InputObjectConfigFieldMap{}
 		inputOperatorFields := graphql.InputObjectConfigFieldMap{}
 		var err error
 
 		for name, column := range table.Columns {
+			if !s.schemaGen.policy.IsColumnVisible(table, name) {
+				// Columns that are not granted to any user or role are not exposed
+				continue
+			}
+
 			var fieldType graphql.Output
 			var inputFieldType graphql.Output
 			if !validName.MatchString(table.Name) || !validName.MatchString(name) {
@@ -301,9 +372,30 @@ func (s *KeyspaceGraphQLSchema) buildTableTypes(keyspace *gocql.KeyspaceMetadata
 			fields[fieldName] = &graphql.Field{Type: fieldType}
 			inputFields[fieldName] = &graphql.InputObjectFieldConfig{Type: inputFieldType}
 
+			if db.SupportsCellMetadata(column) {
+				fields[db.WriteTimePrefix+fieldName] = &graphql.Field{
+					Type:        bigint,
+					Description: fmt.Sprintf("The write time of the '%s' value, in microseconds.", name),
+				}
+				fields[db.TTLPrefix+fieldName] = &graphql.Field{
+					Type:        graphql.Int,
+					Description: fmt.Sprintf("The remaining time-to-live of the '%s' value, in seconds.", name),
+				}
+			}
+
+			if _, ok := db.VectorSimilarityFunction(column); ok {
+				fields[db.SimilarityColumn] = similarityField
+			}
+
 			t := operatorsInputTypes[column.Type.Type()]
+			if t == nil {
+				// The elements of lists, sets and maps can be filtered using an index on the collection
+				if t, err = s.collectionOperatorType(column.Type); err != nil {
+					break
+				}
+			}
 			if t != nil {
-				// Only allow filtering for types that are supported (i.e. lists are not included)
+				// Only allow filtering for types that are supported (i.e. user-defined types are not included)
 				inputOperatorFields[fieldName] = &graphql.InputObjectFieldConfig{
 					Type: t,
 				}
@@ -362,8 +454,9 @@ func (s *KeyspaceGraphQLSchema) buildResultTypes(keyspace *gocql.KeyspaceMetadat
 			Description: fmt.Sprintf("Query result type for the '%s' table.", table.Name),
 			Name:        s.naming.ToGraphQLTypeUnique(table.Name, "Result"),
 			Fields: graphql.Fields{
-				"pageState": {Type: graphql.String},
-				"values":    {Type: graphql.NewList(graphql.NewNonNull(itemType))},
+				"pageState":        {Type: graphql.String},
+				"values":           {Type: graphql.NewList(graphql.NewNonNull(itemType))},
+				aggregateFieldName: s.aggregateField(table),
 			},
 		})
 
@@ -387,10 +480,20 @@ func (s *KeyspaceGraphQLSchema) adaptCondition(tableName string, data map[string
 		mapValue := value.(map[string]interface{})
 
 		for operatorName, itemValue := range mapValue {
+			operator := types.CqlOperators[operatorName]
+			if entry, ok := itemValue.(map[string]interface{}); ok && operator == types.ContainsEntryOperator {
+				itemValue = types.MapEntry{
+					Key:   adaptParameterValue(entry["key"]),
+					Value: adaptParameterValue(entry["value"]),
+				}
+			} else {
+				itemValue = adaptParameterValue(itemValue)
+			}
+
 			result = append(result, types.ConditionItem{
 				Column:   s.naming.ToCQLColumn(tableName, key),
-				Operator: types.CqlOperators[operatorName],
-				Value:    adaptParameterValue(itemValue),
+				Operator: operator,
+				Value:    itemValue,
 			})
 		}
 	}
@@ -402,7 +505,7 @@ func (s *KeyspaceGraphQLSchema) adaptResult(tableName string, values []map[strin
 	for _, item := range values {
 		resultItem := make(map[string]interface{})
 		for k, v := range item {
-			resultItem[s.naming.ToGraphQLField(tableName, k)] = adaptResultValue(v)
+			resultItem[s.resultFieldName(tableName, k)] = adaptResultValue(v)
 		}
 		result = append(result, resultItem)
 	}
@@ -410,8 +513,23 @@ func (s *KeyspaceGraphQLSchema) adaptResult(tableName string, values []map[strin
 	return result
 }
 
+// resultFieldName returns the field name of a column in the rows returned by a query, including the write time and
+// ttl of the columns and the similarity score
+func (s *KeyspaceGraphQLSchema) resultFieldName(tableName string, columnName string) string {
+	if columnName == db.SimilarityColumn {
+		return columnName
+	}
+	for _, prefix := range []string{db.WriteTimePrefix, db.TTLPrefix} {
+		if strings.HasPrefix(columnName, prefix) {
+			return prefix + s.naming.ToGraphQLField(tableName, strings.TrimPrefix(columnName, prefix))
+		}
+	}
+	return s.naming.ToGraphQLField(tableName, columnName)
+}
+
 func (s *KeyspaceGraphQLSchema) getModificationResult(
 	table *gocql.TableMetadata,
+	userOrRole string,
 	inputValues map[string]interface{},
 	rs db.ResultSet,
 	err error,
@@ -429,7 +547,7 @@ func (s *KeyspaceGraphQLSchema) getModificationResult(
 	}
 
 	result := types.ModificationResult{}
-	row := rows[0]
+	row := s.schemaGen.policy.FilterRows(userOrRole, table, rows[:1])[0]
 	applied := row["[applied]"].(*bool)
 	result.Applied = applied != nil && *applied
 
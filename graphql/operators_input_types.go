@@ -6,7 +6,7 @@ import (
 	"github.com/graphql-go/graphql"
 )
 
-var stringOperatorType = operatorType(graphql.String)
+var stringOperatorType = textOperatorType(graphql.String)
 var intOperatorType = operatorType(graphql.Int)
 var floatOperatorType = operatorType(graphql.Float)
 
@@ -26,20 +26,99 @@ var operatorsInputTypes = map[gocql.Type]*graphql.InputObject{
 	gocql.TypeDecimal:   operatorType(decimal),
 	gocql.TypeVarint:    operatorType(varint),
 	gocql.TypeBlob:      operatorType(blob),
+	gocql.TypeDate:      operatorType(date),
+	// Range restrictions are not supported on duration columns
+	gocql.TypeDuration: equalityOperatorType(duration),
 }
 
 func operatorType(graphqlType graphql.Type) *graphql.InputObject {
+	return graphql.NewInputObject(graphql.InputObjectConfig{
+		Description: fmt.Sprintf("Input type to be used in filter queries for the %s type.", graphqlType.Name()),
+		Name:        graphqlType.Name() + "FilterInput",
+		Fields:      operatorFields(graphqlType),
+	})
+}
+
+// textOperatorType returns the filter input type of text columns, which can also be filtered by pattern using an
+// index that supports it. It must only be used for the types supported by db.SupportsLikeOperator.
+func textOperatorType(graphqlType graphql.Type) *graphql.InputObject {
+	fields := operatorFields(graphqlType)
+	fields["like"] = &graphql.InputObjectFieldConfig{Type: graphqlType}
+	return graphql.NewInputObject(graphql.InputObjectConfig{
+		Description: fmt.Sprintf("Input type to be used in filter queries for the %s type.", graphqlType.Name()),
+		Name:        graphqlType.Name() + "FilterInput",
+		Fields:      fields,
+	})
+}
+
+func operatorFields(graphqlType graphql.Type) graphql.InputObjectConfigFieldMap {
+	return graphql.InputObjectConfigFieldMap{
+		"eq":    {Type: graphqlType},
+		"notEq": {Type: graphqlType},
+		"gt":    {Type: graphqlType},
+		"gte":   {Type: graphqlType},
+		"lt":    {Type: graphqlType},
+		"lte":   {Type: graphqlType},
+		"in":    {Type: graphql.NewList(graphqlType)},
+	}
+}
+
+func equalityOperatorType(graphqlType graphql.Type) *graphql.InputObject {
 	return graphql.NewInputObject(graphql.InputObjectConfig{
 		Description: fmt.Sprintf("Input type to be used in filter queries for the %s type.", graphqlType.Name()),
 		Name:        graphqlType.Name() + "FilterInput",
 		Fields: graphql.InputObjectConfigFieldMap{
 			"eq":    {Type: graphqlType},
 			"notEq": {Type: graphqlType},
-			"gt":    {Type: graphqlType},
-			"gte":   {Type: graphqlType},
-			"lt":    {Type: graphqlType},
-			"lte":   {Type: graphqlType},
 			"in":    {Type: graphql.NewList(graphqlType)},
 		},
 	})
 }
+
+// collectionOperatorType returns the filter input type of a list, set or map column, to filter the rows by the
+// elements of the collection. The types are shared by the collections with the same element types.
+func (s *KeyspaceGraphQLSchema) collectionOperatorType(typeInfo gocql.TypeInfo) (*graphql.InputObject, error) {
+	collection, ok := typeInfo.(gocql.CollectionType)
+	if !ok {
+		return nil, nil
+	}
+
+	elem, err := s.buildType(collection.Elem, true)
+	if err != nil {
+		return nil, err
+	}
+
+	typeName := "List" + getTypeName(elem)
+	fields := graphql.InputObjectConfigFieldMap{
+		"contains": {Type: elem},
+	}
+
+	if collection.Type() == gocql.TypeMap {
+		key, err := s.buildType(collection.Key, true)
+		if err != nil {
+			return nil, err
+		}
+
+		entryType := s.buildKeyValueType(key, elem, true)
+		if entryType == nil {
+			return nil, fmt.Errorf("Type for %s could not be created", typeInfo.Type().String())
+		}
+
+		typeName = fmt.Sprintf("Key%sValue%s", getTypeName(key), getTypeName(elem))
+		fields["containsKey"] = &graphql.InputObjectFieldConfig{Type: key}
+		fields["containsEntry"] = &graphql.InputObjectFieldConfig{Type: entryType}
+	}
+
+	typeName = s.naming.ToGraphQLTypeUnique(typeName, "FilterInput")
+	t, ok := s.collectionOperatorTypes[typeName]
+	if !ok {
+		t = graphql.NewInputObject(graphql.InputObjectConfig{
+			Description: fmt.Sprintf("Input type to be used in filter queries for the %s type.", typeInfo.Type()),
+			Name:        typeName,
+			Fields:      fields,
+		})
+		s.collectionOperatorTypes[typeName] = t
+	}
+
+	return t, nil
+}
@@ -0,0 +1,236 @@
+package graphql
+
+import (
+	"fmt"
+	"github.com/datastax/cassandra-data-apis/config"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"strconv"
+)
+
+// driverPageSize is the page size used by the driver when it's not set in the query options
+const driverPageSize = 5000
+
+type queryLimitsChecker struct {
+	limits    config.GraphQLLimits
+	schema    graphql.Schema
+	variables map[string]interface{}
+	fragments map[string]*ast.FragmentDefinition
+	aliases   int
+}
+
+// checkQueryLimits returns an error when the operation to execute exceeds any of the limits. Invalid documents are
+// not considered, as they're rejected when executed.
+func checkQueryLimits(limits config.GraphQLLimits, schema graphql.Schema, request RequestBody) error {
+	if limits == (config.GraphQLLimits{}) {
+		return nil
+	}
+
+	document, err := parser.Parse(parser.ParseParams{Source: request.Query})
+	if err != nil {
+		return nil
+	}
+
+	c := &queryLimitsChecker{
+		limits:    limits,
+		schema:    schema,
+		variables: request.Variables,
+		fragments: make(map[string]*ast.FragmentDefinition),
+	}
+
+	var operation *ast.OperationDefinition
+	for _, definition := range document.Definitions {
+		switch d := definition.(type) {
+		case *ast.FragmentDefinition:
+			c.fragments[d.Name.Value] = d
+		case *ast.OperationDefinition:
+			if operation == nil && (request.OperationName == "" ||
+				(d.Name != nil && d.Name.Value == request.OperationName)) {
+				operation = d
+			}
+		}
+	}
+
+	if operation == nil {
+		return nil
+	}
+
+	depth := c.depth(operation.SelectionSet, make(map[string]bool))
+	if limits.MaxDepth > 0 && depth > limits.MaxDepth {
+		return fmt.Errorf("query depth of %d exceeds the maximum of %d", depth, limits.MaxDepth)
+	}
+
+	if limits.MaxAliases > 0 && c.aliases > limits.MaxAliases {
+		return fmt.Errorf("query contains %d aliases, exceeding the maximum of %d", c.aliases, limits.MaxAliases)
+	}
+
+	if operation.Operation == ast.OperationTypeQuery && schema.QueryType() != nil {
+		return c.checkPageSize(c.rootFields(operation.SelectionSet, make(map[string]bool), nil))
+	}
+
+	return nil
+}
+
+// depth returns the maximum nesting of the selection set, counting the aliases
+func (c *queryLimitsChecker) depth(selectionSet *ast.SelectionSet, visited map[string]bool) int {
+	if selectionSet == nil {
+		return 0
+	}
+
+	maxDepth := 0
+	for _, selection := range selectionSet.Selections {
+		depth := 0
+		switch s := selection.(type) {
+		case *ast.Field:
+			if s.Alias != nil {
+				c.aliases++
+			}
+			depth = 1 + c.depth(s.SelectionSet, visited)
+		case *ast.InlineFragment:
+			depth = c.depth(s.SelectionSet, visited)
+		case *ast.FragmentSpread:
+			name := s.Name.Value
+			if fragment, found := c.fragments[name]; found && !visited[name] {
+				// Avoid cycles, they are rejected when validating the document
+				visited[name] = true
+				depth = c.depth(fragment.SelectionSet, visited)
+				delete(visited, name)
+			}
+		}
+
+		if depth > maxDepth {
+			maxDepth = depth
+		}
+	}
+
+	return maxDepth
+}
+
+func (c *queryLimitsChecker) rootFields(
+	selectionSet *ast.SelectionSet,
+	visited map[string]bool,
+	fields []*ast.Field,
+) []*ast.Field {
+	for _, selection := range selectionSet.Selections {
+		switch s := selection.(type) {
+		case *ast.Field:
+			fields = append(fields, s)
+		case *ast.InlineFragment:
+			fields = c.rootFields(s.SelectionSet, visited, fields)
+		case *ast.FragmentSpread:
+			name := s.Name.Value
+			if fragment, found := c.fragments[name]; found && !visited[name] {
+				visited[name] = true
+				fields = c.rootFields(fragment.SelectionSet, visited, fields)
+			}
+		}
+	}
+	return fields
+}
+
+func (c *queryLimitsChecker) checkPageSize(fields []*ast.Field) error {
+	queryFields := c.schema.QueryType().Fields()
+	total := 0
+	for _, field := range fields {
+		definition, found := queryFields[field.Name.Value]
+		if !found || !hasQueryOptions(definition) {
+			continue
+		}
+
+		rows := c.requestedRows(field)
+		if c.limits.MaxPageSize > 0 && rows > c.limits.MaxPageSize {
+			return fmt.Errorf("%d rows requested for field '%s' exceeds the maximum page size of %d",
+				rows, fieldResponseKey(field), c.limits.MaxPageSize)
+		}
+
+		total += rows
+		if c.limits.MaxTotalPageSize > 0 && total > c.limits.MaxTotalPageSize {
+			return fmt.Errorf("total rows requested by the query exceeds the maximum of %d",
+				c.limits.MaxTotalPageSize)
+		}
+	}
+
+	return nil
+}
+
+// requestedRows returns the maximum number of rows a query field can return, using the page size and limit options
+func (c *queryLimitsChecker) requestedRows(field *ast.Field) int {
+	pageSize := config.DefaultPageSize
+	limit := 0
+
+	for _, argument := range field.Arguments {
+		if argument.Name.Value != "options" {
+			continue
+		}
+
+		options, ok := c.value(argument.Value).(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		if value, ok := toInt(options["pageSize"]); ok {
+			pageSize = value
+		}
+		if value, ok := toInt(options["limit"]); ok {
+			limit = value
+		}
+	}
+
+	if pageSize <= 0 {
+		pageSize = driverPageSize
+	}
+
+	if limit > 0 && limit < pageSize {
+		return limit
+	}
+
+	return pageSize
+}
+
+// value converts the argument value, resolving the variables
+func (c *queryLimitsChecker) value(value ast.Value) interface{} {
+	switch v := value.(type) {
+	case *ast.Variable:
+		return c.variables[v.Name.Value]
+	case *ast.IntValue:
+		result, err := strconv.Atoi(v.Value)
+		if err != nil {
+			return nil
+		}
+		return result
+	case *ast.ObjectValue:
+		result := make(map[string]interface{}, len(v.Fields))
+		for _, f := range v.Fields {
+			result[f.Name.Value] = c.value(f.Value)
+		}
+		return result
+	}
+	return nil
+}
+
+func hasQueryOptions(definition *graphql.FieldDefinition) bool {
+	for _, arg := range definition.Args {
+		if arg.Type == inputQueryOptions {
+			return true
+		}
+	}
+	return false
+}
+
+func fieldResponseKey(field *ast.Field) string {
+	if field.Alias != nil {
+		return field.Alias.Value
+	}
+	return field.Name.Value
+}
+
+func toInt(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return v, true
+	case float64:
+		return int(v), true
+	}
+	return 0, false
+}
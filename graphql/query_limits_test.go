@@ -0,0 +1,53 @@
+package graphql
+
+import (
+	"github.com/datastax/cassandra-data-apis/config"
+	"github.com/datastax/cassandra-data-apis/db"
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestCheckQueryLimits(t *testing.T) {
+	sessionMock := db.NewSessionMock()
+	sessionMock.AddKeyspace(db.NewKeyspaceMock(
+		"store", map[string][]*gocql.ColumnMetadata{"books": db.BooksColumnsMock}))
+	sessionMock.AddViews(nil)
+	schemaGen := NewSchemaGenerator(db.NewDbWithSession(sessionMock), config.NewConfigMock().Default())
+	schema, err := schemaGen.buildSchema("store", nil)
+	require.NoError(t, err)
+
+	limits := config.GraphQLLimits{MaxDepth: 3, MaxAliases: 2, MaxPageSize: 500, MaxTotalPageSize: 600}
+	check := func(query string, variables map[string]interface{}) error {
+		return checkQueryLimits(limits, schema, RequestBody{Query: query, Variables: variables})
+	}
+
+	assert.NoError(t, check("{ books { values { title } } }", nil))
+	assert.NoError(t, check("{ a: books(options: {pageSize: 500}) { values { title } } "+
+		"b: books(options: {pageSize: 1000, limit: 100}) { values { title } } }", nil))
+
+	assert.EqualError(t, check("{ books { values { title { x } } } }", nil),
+		"query depth of 4 exceeds the maximum of 3")
+	assert.EqualError(t, check("{ ...f } fragment f on Query { books { values { ...g } } } "+
+		"fragment g on Books { title { x } }", nil),
+		"query depth of 4 exceeds the maximum of 3")
+
+	assert.EqualError(t, check("{ a: books { pageState } b: books { pageState } c: books { pageState } }", nil),
+		"query contains 3 aliases, exceeding the maximum of 2")
+
+	assert.EqualError(t, check("{ books(options: {pageSize: 501}) { values { title } } }", nil),
+		"501 rows requested for field 'books' exceeds the maximum page size of 500")
+	assert.EqualError(t,
+		check("query ($options: QueryOptions) { books(options: $options) { values { title } } }",
+			map[string]interface{}{"options": map[string]interface{}{"pageSize": float64(1000)}}),
+		"1000 rows requested for field 'books' exceeds the maximum page size of 500")
+	assert.EqualError(t, check("{ books(options: {pageSize: 0}) { values { title } } }", nil),
+		"5000 rows requested for field 'books' exceeds the maximum page size of 500")
+	assert.EqualError(t, check("{ a: books(options: {pageSize: 400}) { values { title } } "+
+		"b: books(options: {pageSize: 400}) { values { title } } }", nil),
+		"total rows requested by the query exceeds the maximum of 600")
+
+	// Mutations are not limited by page size
+	assert.NoError(t, check(`mutation { insertBooks(value: {title: "a"}) { applied } }`, nil))
+}
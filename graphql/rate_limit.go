@@ -0,0 +1,82 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/datastax/cassandra-data-apis/ratelimit"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// maxClassifiedBodySize is the maximum size of the request body read to classify the request, larger requests are
+// classified as mutations
+const maxClassifiedBodySize = 1 << 20
+
+// RateLimitClassifier returns a classifier for the requests to the GraphQL routes, mutations are classified as
+// writes, or schema changes for the schema management routes, and the rest as reads
+func RateLimitClassifier(pattern string, singleKeyspace string, isSchemaManagement bool) ratelimit.Classifier {
+	pathParser := getPathParser(pattern)
+	return func(r *http.Request) (string, ratelimit.Operation) {
+		keyspace := singleKeyspace
+		if keyspace == "" && !isSchemaManagement {
+			keyspace = pathParser(r.URL.Path)
+		}
+
+		if !isMutationRequest(r) {
+			return keyspace, ratelimit.OperationRead
+		}
+
+		if isSchemaManagement {
+			return keyspace, ratelimit.OperationSchema
+		}
+
+		return keyspace, ratelimit.OperationWrite
+	}
+}
+
+// isMutationRequest determines whether the operation to execute is a mutation, the request body is preserved
+func isMutationRequest(r *http.Request) bool {
+	var body RequestBody
+	if r.Method == http.MethodGet {
+		body.Query = r.URL.Query().Get("query")
+		body.OperationName = r.URL.Query().Get("operationName")
+	} else if r.Body != nil {
+		// The bytes read from the body are buffered to restore it, including the ones past the limit
+		var read bytes.Buffer
+		data, err := ioutil.ReadAll(http.MaxBytesReader(nil, ioutil.NopCloser(io.TeeReader(r.Body, &read)),
+			maxClassifiedBodySize))
+		r.Body = ioutil.NopCloser(io.MultiReader(&read, r.Body))
+		if read.Len() > maxClassifiedBodySize {
+			return true
+		}
+		if err != nil || json.Unmarshal(data, &body) != nil {
+			// Invalid requests are rejected by the route
+			return false
+		}
+	}
+
+	document, err := parser.Parse(parser.ParseParams{Source: body.Query})
+	if err != nil {
+		return false
+	}
+
+	for _, definition := range document.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+
+		if body.OperationName != "" && (operation.Name == nil || operation.Name.Value != body.OperationName) {
+			continue
+		}
+
+		if operation.Operation == ast.OperationTypeMutation {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,65 @@
+package graphql
+
+import (
+	"github.com/datastax/cassandra-data-apis/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestRateLimitClassifier(t *testing.T) {
+	classify := RateLimitClassifier("/graphql", "", false)
+
+	body := `{"query": "mutation { insertBooks(value: {title: \"a\"}) { applied } }"}`
+	r := httptest.NewRequest(http.MethodPost, "/graphql/store", strings.NewReader(body))
+	keyspace, op := classify(r)
+	assert.Equal(t, "store", keyspace)
+	assert.Equal(t, ratelimit.OperationWrite, op)
+
+	// The body is preserved for the route
+	data, err := ioutil.ReadAll(r.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, body, string(data))
+
+	r = httptest.NewRequest(http.MethodGet,
+		"/graphql/store?query="+url.QueryEscape("{ books { values { title } } }"), nil)
+	keyspace, op = classify(r)
+	assert.Equal(t, "store", keyspace)
+	assert.Equal(t, ratelimit.OperationRead, op)
+
+	// Only the operation to execute is considered
+	body = `{"query": "query q1 { books { values { title } } } mutation m1 { deleteBooks(value: {title: \"a\"}) { applied } }",
+		"operationName": "q1"}`
+	_, op = classify(httptest.NewRequest(http.MethodPost, "/graphql/store", strings.NewReader(body)))
+	assert.Equal(t, ratelimit.OperationRead, op)
+
+	classify = RateLimitClassifier("/graphql-schema", "", true)
+	body = `{"query": "mutation { createKeyspace(name: \"ks1\", dcs: {name: \"dc1\", replicas: 1}) }"}`
+	_, op = classify(httptest.NewRequest(http.MethodPost, "/graphql-schema", strings.NewReader(body)))
+	assert.Equal(t, ratelimit.OperationSchema, op)
+}
+
+func TestRateLimitClassifier_LargeBody(t *testing.T) {
+	classify := RateLimitClassifier("/graphql", "", false)
+
+	// Bodies larger than the limit are not read in full and classified as mutations
+	body := `{"query": "{ books { values { title } } }", "variables": {"value": "` +
+		strings.Repeat("a", maxClassifiedBodySize) + `"}}`
+	r := httptest.NewRequest(http.MethodPost, "/graphql/store", strings.NewReader(body))
+	_, op := classify(r)
+	assert.Equal(t, ratelimit.OperationWrite, op)
+
+	// The body is preserved for the route
+	data, err := ioutil.ReadAll(r.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, body, string(data))
+
+	body = `{"query": "{ books { values { title } } }", "variables": {"value": "` +
+		strings.Repeat("a", maxClassifiedBodySize/2) + `"}}`
+	_, op = classify(httptest.NewRequest(http.MethodPost, "/graphql/store", strings.NewReader(body)))
+	assert.Equal(t, ratelimit.OperationRead, op)
+}
@@ -3,14 +3,18 @@ package graphql
 import (
 	"encoding/base64"
 	"fmt"
+	"github.com/datastax/cassandra-data-apis/auth"
 	"github.com/datastax/cassandra-data-apis/db"
 	"github.com/datastax/cassandra-data-apis/types"
 	"github.com/gocql/gocql"
 	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
 	"github.com/mitchellh/mapstructure"
 	"gopkg.in/inf.v0"
 	"math/big"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -21,6 +25,7 @@ const (
 	insertOperation mutationOperation = iota
 	updateOperation
 	deleteOperation
+	incrementOperation
 )
 
 func (sg *SchemaGenerator) queryFieldResolver(
@@ -67,19 +72,78 @@ func (sg *SchemaGenerator) queryFieldResolver(
 			return nil, err
 		}
 
+		readColumns := conditionColumns(whereClause)
+		var annOf *db.AnnOrder
+		if params.Args[annOfArgName] != nil {
+			annOf, err = ksSchema.adaptAnnOrder(table, params.Args[annOfArgName].(map[string]interface{}))
+			if err != nil {
+				return nil, err
+			}
+			readColumns = append(readColumns, annOf.Column)
+		}
+
+		if err := sg.checkPolicy(params, table, auth.PermissionRead, readColumns); err != nil {
+			return nil, err
+		}
+
+		if options.AllowFiltering {
+			if err := sg.checkAllowFiltering(table, whereClause, options.Limit); err != nil {
+				return nil, err
+			}
+		}
+
 		pageState, err := base64.StdEncoding.DecodeString(options.PageState)
 		if err != nil {
 			return nil, err
 		}
 
+		response := &queryResult{
+			where:      whereClause,
+			options:    options,
+			userOrRole: userOrRole,
+		}
+
+		fields := selectedFields(params.Info, params.Info.FieldASTs)
+		if len(fields["values"]) == 0 && len(fields["pageState"]) == 0 {
+			// Avoid retrieving the rows when only the aggregates are selected
+			return response, nil
+		}
+
+		info := &db.SelectInfo{
+			Keyspace:       table.Keyspace,
+			Table:          table.Name,
+			Where:          whereClause,
+			OrderBy:        parseColumnOrder(orderBy),
+			AnnOf:          annOf,
+			Options:        &options,
+			AllowFiltering: options.AllowFiltering,
+		}
+
+		valueFields := selectedFields(params.Info, fields["values"])
+		info.WriteTime = ksSchema.cellMetadataColumns(table.Name, valueFields, db.WriteTimePrefix)
+		info.TTL = ksSchema.cellMetadataColumns(table.Name, valueFields, db.TTLPrefix)
+		if len(valueFields[db.SimilarityColumn]) > 0 {
+			if annOf == nil {
+				return nil, fmt.Errorf("%s can only be selected when using %s", db.SimilarityColumn, annOfArgName)
+			}
+			info.Similarity, _ = db.VectorSimilarityFunction(table.Columns[annOf.Column])
+		}
+
+		if len(info.WriteTime) > 0 || len(info.TTL) > 0 || info.Similarity != "" {
+			metadataColumns := append(append([]string{}, info.WriteTime...), info.TTL...)
+			if err := sg.checkPolicy(params, table, auth.PermissionRead, metadataColumns); err != nil {
+				return nil, err
+			}
+
+			// The columns must be listed along with the write time, ttl and similarity selectors
+			for name := range table.Columns {
+				info.Columns = append(info.Columns, name)
+			}
+			sort.Strings(info.Columns)
+		}
+
 		result, err := sg.dbClient.Select(
-			&db.SelectInfo{
-				Keyspace: table.Keyspace,
-				Table:    table.Name,
-				Where:    whereClause,
-				OrderBy:  parseColumnOrder(orderBy),
-				Options:  &options,
-			},
+			info,
 			db.NewQueryOptions().
 				WithUserOrRole(userOrRole).
 				WithPageSize(options.PageSize).
@@ -90,10 +154,11 @@ func (sg *SchemaGenerator) queryFieldResolver(
 			return nil, err
 		}
 
-		return &types.QueryResult{
-			PageState: base64.StdEncoding.EncodeToString(result.PageState()),
-			Values:    ksSchema.adaptResult(table.Name, result.Values()),
-		}, nil
+		values := sg.policy.FilterRows(auth.ContextUserOrRole(params.Context), table, result.Values())
+
+		response.PageState = base64.StdEncoding.EncodeToString(result.PageState())
+		response.Values = ksSchema.adaptResult(table.Name, values)
+		return response, nil
 	}
 }
 
@@ -117,6 +182,11 @@ func (sg *SchemaGenerator) mutationFieldResolver(
 			return nil, err
 		}
 
+		timestamp, err := parseTimestamp(options.Timestamp)
+		if err != nil {
+			return nil, err
+		}
+
 		userOrRole, err := sg.checkUserOrRoleAuth(params)
 		if err != nil {
 			return nil, err
@@ -127,52 +197,176 @@ func (sg *SchemaGenerator) mutationFieldResolver(
 			WithConsistency(gocql.Consistency(options.Consistency)).
 			WithSerialConsistency(gocql.SerialConsistency(options.SerialConsistency))
 
-		var result db.ResultSet
+		var ifCondition []types.ConditionItem
+		if params.Args["ifCondition"] != nil {
+			ifCondition = ksSchema.adaptCondition(
+				table.Name, params.Args["ifCondition"].(map[string]interface{}))
+		}
+
+		var collectionUpdates []db.CollectionUpdate
+		var deleteTargets []db.DeleteTarget
+		policyColumns := columnNames
+		if params.Args[operationsArgName] != nil {
+			collectionUpdates = ksSchema.adaptCollectionOperations(
+				table.Name, params.Args[operationsArgName].(map[string]interface{}))
+			policyColumns = append(collectionUpdateColumns(collectionUpdates), columnNames...)
+		}
+		if operation == deleteOperation {
+			deleteTargets = ksSchema.adaptDeleteTargets(table.Name, params.Args)
+			if err := db.ValidateDeleteTargets(table, columnNames, deleteTargets); err != nil {
+				return nil, err
+			}
+			policyColumns = deleteTargetColumns(deleteTargets)
+		}
+
+		if err := sg.checkMutationPolicy(params, table, operation, policyColumns, ifCondition); err != nil {
+			return nil, err
+		}
+
+		var statement db.Statement
+		isConditional := len(ifCondition) > 0 || params.Args["ifExists"] == true
 
 		switch operation {
 		case insertOperation:
 			ifNotExists := params.Args["ifNotExists"] == true
-			result, err = sg.dbClient.Insert(&db.InsertInfo{
+			isConditional = ifNotExists
+			statement = db.InsertStatement(&db.InsertInfo{
 				Keyspace:    table.Keyspace,
 				Table:       table.Name,
 				Columns:     columnNames,
 				QueryParams: queryParams,
 				IfNotExists: ifNotExists,
 				TTL:         options.TTL,
-			}, queryOptions)
+				Timestamp:   timestamp,
+			})
 		case deleteOperation:
-			var ifCondition []types.ConditionItem
-			if params.Args["ifCondition"] != nil {
-				ifCondition = ksSchema.adaptCondition(
-					table.Name, params.Args["ifCondition"].(map[string]interface{}))
-			}
-			result, err = sg.dbClient.Delete(&db.DeleteInfo{
+			statement = db.DeleteStatement(&db.DeleteInfo{
 				Keyspace:    table.Keyspace,
 				Table:       table.Name,
 				Columns:     columnNames,
 				QueryParams: queryParams,
 				IfCondition: ifCondition,
-				IfExists:    params.Args["ifExists"] == true}, queryOptions)
+				IfExists:    params.Args["ifExists"] == true,
+				Timestamp:   timestamp,
+				Targets:     deleteTargets})
 		case updateOperation:
-			var ifCondition []types.ConditionItem
-			if params.Args["ifCondition"] != nil {
-				ifCondition = ksSchema.adaptCondition(
-					table.Name, params.Args["ifCondition"].(map[string]interface{}))
+			statement, err = db.UpdateStatement(&db.UpdateInfo{
+				Keyspace:          table.Keyspace,
+				Table:             table,
+				Columns:           columnNames,
+				QueryParams:       queryParams,
+				IfCondition:       ifCondition,
+				TTL:               options.TTL,
+				IfExists:          params.Args["ifExists"] == true,
+				Timestamp:         timestamp,
+				CollectionUpdates: collectionUpdates})
+		case incrementOperation:
+			if options.TTL >= 0 || timestamp != nil {
+				return nil, fmt.Errorf("ttl and timestamp are not supported when incrementing counters")
 			}
-			result, err = sg.dbClient.Update(&db.UpdateInfo{
+			statement, err = db.IncrementStatement(&db.IncrementInfo{
 				Keyspace:    table.Keyspace,
 				Table:       table,
 				Columns:     columnNames,
-				QueryParams: queryParams,
-				IfCondition: ifCondition,
-				TTL:         options.TTL,
-				IfExists:    params.Args["ifExists"] == true}, queryOptions)
+				QueryParams: queryParams})
 		default:
 			return false, fmt.Errorf("operation not supported")
 		}
 
-		return ksSchema.getModificationResult(table, value, result, err)
+		if batch := atomicBatch(params); batch != nil && err == nil {
+			if isConditional {
+				return nil, fmt.Errorf("conditional mutations are not supported in atomic operations")
+			}
+			if operation == incrementOperation {
+				// Counter mutations can only be batched with other counter mutations
+				return nil, fmt.Errorf("counter mutations are not supported in atomic operations")
+			}
+			err = batch.add(statement, queryOptions, func(err error) {
+				result := &types.ModificationResult{Applied: err == nil, Value: value}
+				sg.auditMutation(params, table, operation, columnNames, queryParams, result, err)
+			})
+			if err != nil {
+				return nil, err
+			}
+			// The result is discarded when the batch fails
+			return &types.ModificationResult{Applied: true, Value: value}, nil
+		}
+
+		var result db.ResultSet
+		if err == nil {
+			result, err = sg.dbClient.Execute(statement.Query, queryOptions, statement.Values...)
+		}
+
+		modificationResult, err := ksSchema.getModificationResult(
+			table, auth.ContextUserOrRole(params.Context), value, result, err)
+		sg.auditMutation(params, table, operation, columnNames, queryParams, modificationResult, err)
+		return modificationResult, err
+	}
+}
+
+func (sg *SchemaGenerator) checkMutationPolicy(
+	params graphql.ResolveParams,
+	table *gocql.TableMetadata,
+	operation mutationOperation,
+	columnNames []string,
+	ifCondition []types.ConditionItem,
+) error {
+	var err error
+	switch operation {
+	case insertOperation:
+		err = sg.checkPolicy(params, table, auth.PermissionInsert, columnNames)
+	case updateOperation, incrementOperation:
+		err = sg.checkPolicy(params, table, auth.PermissionUpdate, columnNames)
+	case deleteOperation:
+		// The values only contain the primary key, the columns are the ones deleted instead of the whole row
+		err = sg.checkPolicy(params, table, auth.PermissionDelete, columnNames)
+	}
+
+	if err == nil && len(ifCondition) > 0 {
+		// Conditions expose the current values of the columns
+		err = sg.checkPolicy(params, table, auth.PermissionRead, conditionColumns(ifCondition))
+	}
+
+	return err
+}
+
+// cellMetadataColumns returns the columns of the write time or ttl fields selected, identified by the prefix
+func (s *KeyspaceGraphQLSchema) cellMetadataColumns(
+	tableName string,
+	fields map[string][]*ast.Field,
+	prefix string,
+) []string {
+	var columns []string
+	for _, fieldName := range sortedKeys(fields) {
+		if strings.HasPrefix(fieldName, prefix) {
+			columns = append(columns, s.naming.ToCQLColumn(tableName, strings.TrimPrefix(fieldName, prefix)))
+		}
+	}
+	return columns
+}
+
+func collectionUpdateColumns(updates []db.CollectionUpdate) []string {
+	columns := make([]string, 0, len(updates))
+	for _, update := range updates {
+		columns = append(columns, update.Column)
+	}
+	return columns
+}
+
+func deleteTargetColumns(targets []db.DeleteTarget) []string {
+	columns := make([]string, 0, len(targets))
+	for _, target := range targets {
+		columns = append(columns, target.Column)
+	}
+	return columns
+}
+
+func conditionColumns(conditions []types.ConditionItem) []string {
+	columns := make([]string, 0, len(conditions))
+	for _, item := range conditions {
+		columns = append(columns, item.Column)
 	}
+	return columns
 }
 
 func adaptParameterValue(value interface{}) interface{} {
@@ -191,6 +385,11 @@ func adaptParameterValue(value interface{}) interface{} {
 }
 
 func adaptCollectionParameter(value interface{}) interface{} {
+	if fields, ok := value.(map[string]interface{}); ok {
+		// It's an input object: a user-defined type or a tuple
+		return adaptObjectParameter(fields)
+	}
+
 	rv := reflect.ValueOf(value)
 	switch rv.Type().Kind() {
 	case reflect.Slice:
@@ -201,16 +400,19 @@ func adaptCollectionParameter(value interface{}) interface{} {
 			return value
 		}
 		firstElement := rv.Index(0)
+		if elements, ok := value.([]interface{}); ok && isFloat32(elements[0]) {
+			// It can be a collection of floats or a vector, depending on the type of the parameter
+			return floatListValue(elements)
+		}
 		if reflect.TypeOf(firstElement.Interface()).Kind() != reflect.Map {
 			return value
 		}
 
-		result := make(map[interface{}]interface{})
-		// It's a slice of maps that only contains two keys: 'key' and 'value'
-		// It's the graphql representation of a map: [KeyValueType]
+		// It's a slice of input objects: the graphql representation of a map, [KeyValueType], or a collection of
+		// user-defined types or tuples
+		result := make(objectListValue, 0, length)
 		for i := 0; i < length; i++ {
-			element := rv.Index(i).Interface().(map[string]interface{})
-			result[element["key"]] = adaptParameterValue(element["value"])
+			result = append(result, adaptObjectParameter(rv.Index(i).Interface().(map[string]interface{})))
 		}
 
 		return result
@@ -219,6 +421,32 @@ func adaptCollectionParameter(value interface{}) interface{} {
 	return value
 }
 
+func isFloat32(value interface{}) bool {
+	_, ok := value.(float32)
+	return ok
+}
+
+func adaptObjectParameter(fields map[string]interface{}) objectValue {
+	result := make(objectValue, len(fields))
+	for name, value := range fields {
+		result[name] = adaptParameterValue(value)
+	}
+	return result
+}
+
+// parseTimestamp parses the write time of a mutation, it returns nil when the value is empty
+func parseTimestamp(value string) (*int64, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	timestamp, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp '%s'", value)
+	}
+	return &timestamp, nil
+}
+
 func parseColumnOrder(values []interface{}) []db.ColumnOrder {
 	result := make([]db.ColumnOrder, 0, len(values))
 
@@ -239,11 +467,34 @@ func adaptResultValue(value interface{}) interface{} {
 		return nil
 	}
 
-	switch value.(type) {
+	switch value := value.(type) {
 	case *int8, *int16, *int, *float32, *float64, *int32, *string, *bool,
 		*time.Time, *inf.Dec, *big.Int, *gocql.UUID, *[]byte:
 		// Avoid reflection whenever possible
 		return value
+	case *db.UDTValue:
+		if value == nil {
+			return nil
+		}
+		result := make(map[string]interface{}, len(*value))
+		for name, fieldValue := range *value {
+			result[name] = adaptResultValue(fieldValue)
+		}
+		return result
+	case *db.VectorValue:
+		if value == nil {
+			return nil
+		}
+		return []float32(*value)
+	case *db.TupleValue:
+		if value == nil {
+			return nil
+		}
+		result := make(map[string]interface{}, len(*value))
+		for i, elemValue := range *value {
+			result[tupleFieldName(i)] = adaptResultValue(elemValue)
+		}
+		return result
 	}
 
 	rv := reflect.ValueOf(value)
@@ -253,6 +504,17 @@ func adaptResultValue(value interface{}) interface{} {
 		return nil
 	}
 
+	if typeKind == reflect.Ptr && rv.Elem().Type().Kind() == reflect.Slice &&
+		rv.Elem().Type().Elem().Kind() == reflect.Ptr {
+		// Elements can be user-defined types or tuples
+		rv = rv.Elem()
+		result := make([]interface{}, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			result = append(result, adaptResultValue(rv.Index(i).Interface()))
+		}
+		return result
+	}
+
 	if !(typeKind == reflect.Ptr && rv.Elem().Type().Kind() == reflect.Map) {
 		return value
 	}
@@ -267,7 +529,7 @@ func adaptResultValue(value interface{}) interface{} {
 		value := iter.Value()
 		result = append(result, map[string]interface{}{
 			"key":   key.Interface(),
-			"value": value.Interface(),
+			"value": adaptResultValue(value.Interface()),
 		})
 	}
 
@@ -0,0 +1,80 @@
+package graphql
+
+import (
+	"container/list"
+	"github.com/graphql-go/graphql"
+	"sync"
+	"time"
+)
+
+type roleSchemaKey struct {
+	userOrRole string
+	keyspace   string
+}
+
+type roleSchemaEntry struct {
+	key     roleSchemaKey
+	schema  *graphql.Schema
+	created time.Time
+}
+
+// roleSchemaCache is a least recently used cache of keyspace schemas by role, the entries expire after the ttl to
+// reflect schema and permission changes
+type roleSchemaCache struct {
+	mutex   sync.Mutex
+	maxSize int
+	ttl     time.Duration
+	entries map[roleSchemaKey]*list.Element
+	lru     *list.List
+	now     func() time.Time
+}
+
+func newRoleSchemaCache(maxSize int, ttl time.Duration) *roleSchemaCache {
+	return &roleSchemaCache{
+		maxSize: maxSize,
+		ttl:     ttl,
+		entries: make(map[roleSchemaKey]*list.Element),
+		lru:     list.New(),
+		now:     time.Now,
+	}
+}
+
+func (c *roleSchemaCache) get(userOrRole string, keyspace string) *graphql.Schema {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, found := c.entries[roleSchemaKey{userOrRole, keyspace}]
+	if !found {
+		return nil
+	}
+
+	entry := element.Value.(*roleSchemaEntry)
+	if c.now().Sub(entry.created) > c.ttl {
+		c.remove(element)
+		return nil
+	}
+
+	c.lru.MoveToFront(element)
+	return entry.schema
+}
+
+func (c *roleSchemaCache) put(userOrRole string, keyspace string, schema *graphql.Schema) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	key := roleSchemaKey{userOrRole, keyspace}
+	if element, found := c.entries[key]; found {
+		c.remove(element)
+	}
+
+	c.entries[key] = c.lru.PushFront(&roleSchemaEntry{key: key, schema: schema, created: c.now()})
+
+	for c.lru.Len() > c.maxSize {
+		c.remove(c.lru.Back())
+	}
+}
+
+func (c *roleSchemaCache) remove(element *list.Element) {
+	c.lru.Remove(element)
+	delete(c.entries, element.Value.(*roleSchemaEntry).key)
+}
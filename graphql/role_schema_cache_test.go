@@ -0,0 +1,38 @@
+package graphql
+
+import (
+	"github.com/graphql-go/graphql"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestRoleSchemaCache_Eviction(t *testing.T) {
+	cache := newRoleSchemaCache(2, time.Minute)
+	schema1, schema2, schema3 := &graphql.Schema{}, &graphql.Schema{}, &graphql.Schema{}
+
+	cache.put("role1", "ks1", schema1)
+	cache.put("role2", "ks1", schema2)
+	assert.Same(t, schema1, cache.get("role1", "ks1"))
+	assert.Nil(t, cache.get("role1", "ks2"))
+
+	// role2 is the least recently used
+	cache.put("role3", "ks1", schema3)
+	assert.Nil(t, cache.get("role2", "ks1"))
+	assert.Same(t, schema1, cache.get("role1", "ks1"))
+	assert.Same(t, schema3, cache.get("role3", "ks1"))
+}
+
+func TestRoleSchemaCache_Expiration(t *testing.T) {
+	now := time.Now()
+	cache := newRoleSchemaCache(10, time.Minute)
+	cache.now = func() time.Time { return now }
+	schema := &graphql.Schema{}
+
+	cache.put("role1", "ks1", schema)
+	now = now.Add(30 * time.Second)
+	assert.Same(t, schema, cache.get("role1", "ks1"))
+	now = now.Add(time.Minute)
+	assert.Nil(t, cache.get("role1", "ks1"))
+	assert.Len(t, cache.entries, 0)
+}
@@ -4,11 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"github.com/datastax/cassandra-data-apis/auth"
 	"github.com/datastax/cassandra-data-apis/config"
 	"github.com/datastax/cassandra-data-apis/db"
 	"github.com/datastax/cassandra-data-apis/log"
 	"github.com/datastax/cassandra-data-apis/types"
 	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
 	"net/http"
 	"path"
 	"regexp"
@@ -19,11 +21,14 @@ import (
 type executeQueryFunc func(request RequestBody, urlPath string, ctx context.Context) *graphql.Result
 
 type RouteGenerator struct {
-	dbClient       *db.Db
-	updateInterval time.Duration
-	logger         log.Logger
-	schemaGen      *SchemaGenerator
-	routerInfo     config.HttpRouterInfo
+	dbClient            *db.Db
+	updateInterval      time.Duration
+	logger              log.Logger
+	schemaGen           *SchemaGenerator
+	routerInfo          config.HttpRouterInfo
+	useRoleSchemas      bool
+	roleSchemaCacheSize int
+	limits              config.GraphQLLimits
 }
 
 type Config struct {
@@ -38,11 +43,14 @@ type RequestBody struct {
 
 func NewRouteGenerator(dbClient *db.Db, cfg config.Config) *RouteGenerator {
 	return &RouteGenerator{
-		dbClient:       dbClient,
-		updateInterval: cfg.SchemaUpdateInterval(),
-		logger:         cfg.Logger(),
-		schemaGen:      NewSchemaGenerator(dbClient, cfg),
-		routerInfo:     cfg.RouterInfo(),
+		dbClient:            dbClient,
+		updateInterval:      cfg.SchemaUpdateInterval(),
+		logger:              cfg.Logger(),
+		schemaGen:           NewSchemaGenerator(dbClient, cfg),
+		routerInfo:          cfg.RouterInfo(),
+		useRoleSchemas:      cfg.UseUserOrRoleAuth() && cfg.UseRoleSchemas(),
+		roleSchemaCacheSize: cfg.RoleSchemaCacheSize(),
+		limits:              cfg.GraphQLLimits(),
 	}
 }
 
@@ -64,6 +72,11 @@ func (rg *RouteGenerator) Routes(pattern string, singleKeyspace string) ([]types
 
 	go updater.Start()
 
+	var roleSchemas *roleSchemaCache
+	if rg.useRoleSchemas {
+		roleSchemas = newRoleSchemaCache(rg.roleSchemaCacheSize, rg.updateInterval)
+	}
+
 	pathParser := getPathParser(pattern)
 	if singleKeyspace == "" {
 		// Use a single route with keyspace as dynamic parameter
@@ -88,10 +101,40 @@ func (rg *RouteGenerator) Routes(pattern string, singleKeyspace string) ([]types
 			return nil
 		}
 
+		if userOrRole := auth.ContextUserOrRole(ctx); roleSchemas != nil && userOrRole != "" {
+			var err error
+			if schema, err = rg.roleSchema(roleSchemas, ksName, userOrRole); err != nil {
+				return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+			}
+		}
+
 		return rg.executeQuery(request, ctx, *schema)
 	}), nil
 }
 
+// roleSchema gets the schema of the keyspace for the user or role from the cache, building it when needed
+func (rg *RouteGenerator) roleSchema(
+	cache *roleSchemaCache,
+	keyspace string,
+	userOrRole string,
+) (*graphql.Schema, error) {
+	if schema := cache.get(userOrRole, keyspace); schema != nil {
+		return schema, nil
+	}
+
+	schema, err := rg.schemaGen.BuildRoleSchema(keyspace, userOrRole)
+	if err != nil {
+		rg.logger.Error("unable to build graphql schema for role",
+			"keyspace", keyspace,
+			"userOrRole", userOrRole,
+			"error", err)
+		return nil, fmt.Errorf("unable to build graphql schema for '%s'", userOrRole)
+	}
+
+	cache.put(userOrRole, keyspace, schema)
+	return schema, nil
+}
+
 // Keyspaces gets a slice of keyspace names that are considered by the route generator.
 func (rg *RouteGenerator) Keyspaces() ([]string, error) {
 	keyspaces, err := rg.dbClient.Keyspaces("")
@@ -191,13 +234,29 @@ func routesForSchema(pattern string, execute executeQueryFunc) []types.Route {
 }
 
 func (rg *RouteGenerator) executeQuery(request RequestBody, ctx context.Context, schema graphql.Schema) *graphql.Result {
+	if err := checkQueryLimits(rg.limits, schema, request); err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+
+	batch := &mutationBatch{}
 	result := graphql.Do(graphql.Params{
 		Schema:        schema,
-		Context:       ctx,
+		Context:       withMutationBatch(ctx, batch),
 		RequestString: request.Query,
 		OperationName: request.OperationName,
 		VariableValues: request.Variables,
 	})
+
+	if len(batch.statements) > 0 {
+		if len(result.Errors) > 0 {
+			// Atomic operations are only executed when all the mutations are valid
+			batch.discard()
+			result.Data = nil
+		} else if err := batch.execute(rg.dbClient); err != nil {
+			result = &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+		}
+	}
+
 	if len(result.Errors) > 0 {
 		rg.logger.Error("unexpected errors processing graphql query", "errors", result.Errors)
 	}
@@ -60,6 +60,16 @@ var localTime = newStringScalar(
 		" Values are represented as strings, such as 13:30:54.234..",
 	types.DurationToCqlFormattedString, errToNilDeserializer(types.CqlFormattedStringToDuration))
 
+var date = newStringScalar(
+	"Date", "The `Date` scalar type represents a CQL date."+
+		" Values are represented as strings in the yyyy-mm-dd format, such as 2020-03-25.",
+	identityFn, errToNilDeserializer(types.StringToDate))
+
+var duration = newStringScalar(
+	"Duration", "The `Duration` scalar type represents a CQL duration."+
+		" Values are represented as strings in the CQL standard format, such as 1y2mo3d4h5m6s.",
+	types.CqlDurationToString, errToNilDeserializer(types.StringToCqlDuration))
+
 // newStringNativeScalar Creates an string-based scalar with custom serialization functions
 func newStringScalar(
 	name string, description string, serializeFn graphql.SerializeFn, deserializeFn graphql.ParseValueFn,
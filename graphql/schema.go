@@ -2,18 +2,21 @@ package graphql
 
 import (
 	"fmt"
+	"github.com/datastax/cassandra-data-apis/audit"
 	"github.com/datastax/cassandra-data-apis/auth"
 	"github.com/datastax/cassandra-data-apis/config"
 	"github.com/datastax/cassandra-data-apis/db"
 	"github.com/datastax/cassandra-data-apis/log"
+	"github.com/datastax/cassandra-data-apis/types"
 	"github.com/gocql/gocql"
 	"github.com/graphql-go/graphql"
 )
 
 const (
-	insertPrefix = "insert"
-	deletePrefix = "delete"
-	updatePrefix = "update"
+	insertPrefix    = "insert"
+	deletePrefix    = "delete"
+	updatePrefix    = "update"
+	incrementPrefix = "increment"
 )
 
 type SchemaGenerator struct {
@@ -22,6 +25,9 @@ type SchemaGenerator struct {
 	useUserOrRoleAuth bool
 	ksExcluded        map[string]bool
 	logger            log.Logger
+	policy            *auth.Policy
+	auditor           *audit.Auditor
+	allowFiltering    config.AllowFilteringSettings
 }
 
 func NewSchemaGenerator(dbClient *db.Db, cfg config.Config) *SchemaGenerator {
@@ -38,6 +44,9 @@ func NewSchemaGenerator(dbClient *db.Db, cfg config.Config) *SchemaGenerator {
 		useUserOrRoleAuth: cfg.UseUserOrRoleAuth(),
 		ksExcluded:        ksExcluded,
 		logger:            cfg.Logger(),
+		policy:            cfg.Policy(),
+		auditor:           cfg.Auditor(),
+		allowFiltering:    cfg.AllowFiltering(),
 	}
 }
 
@@ -47,42 +56,48 @@ func (sg *SchemaGenerator) buildQueriesFields(
 ) graphql.Fields {
 	fields := graphql.Fields{}
 	for _, table := range keyspace.Tables {
-		if ksSchema.ignoredTables[table.Name] {
+		if ksSchema.ignoredTables[table.Name] || !ksSchema.permissions.CanSelect(table.Keyspace, table.Name) {
 			continue
 		}
 
+		queryArgs := graphql.FieldConfigArgument{
+			"value":   {Type: ksSchema.tableScalarInputTypes[table.Name]},
+			"orderBy": {Type: graphql.NewList(ksSchema.orderEnums[table.Name])},
+			"options": {Type: inputQueryOptions, DefaultValue: inputQueryOptionsDefault},
+		}
+		ksSchema.annOfArgs(table.Name, queryArgs)
+
 		fields[ksSchema.naming.ToGraphQLOperation("", table.Name)] = &graphql.Field{
 			Description: fmt.Sprintf("Retrieves data from '%s' table using the equality operator.\n", table.Name) +
 				"The amount of values contained in the result is limited by the page size " +
 				fmt.Sprintf(" (defaults to %d). Use the pageState included in the result to ", config.DefaultPageSize) +
 				"obtain the following rows.\n" +
 				"When no fields are provided, it returns all rows in the table, limited by the page size.",
-			Type: ksSchema.resultSelectTypes[table.Name],
-			Args: graphql.FieldConfigArgument{
-				"value":   {Type: ksSchema.tableScalarInputTypes[table.Name]},
-				"orderBy": {Type: graphql.NewList(ksSchema.orderEnums[table.Name])},
-				"options": {Type: inputQueryOptions, DefaultValue: inputQueryOptionsDefault},
-			},
+			Type:    ksSchema.resultSelectTypes[table.Name],
+			Args:    queryArgs,
 			Resolve: sg.queryFieldResolver(table, ksSchema, false),
 		}
 
+		filterArgs := graphql.FieldConfigArgument{
+			"filter":  {Type: graphql.NewNonNull(ksSchema.tableOperatorInputTypes[table.Name])},
+			"orderBy": {Type: graphql.NewList(ksSchema.orderEnums[table.Name])},
+			"options": {Type: inputQueryOptions, DefaultValue: inputQueryOptionsDefault},
+		}
+		ksSchema.annOfArgs(table.Name, filterArgs)
+
 		fields[ksSchema.naming.ToGraphQLOperation("", table.Name)+"Filter"] = &graphql.Field{
 			Description: fmt.Sprintf("Retrieves data from '%s' table using equality \n", table.Name) +
 				"and non-equality operators.\n" +
 				"The amount of values contained in the result is limited by the page size " +
 				fmt.Sprintf(" (defaults to %d). Use the pageState included in the result to ", config.DefaultPageSize) +
 				"obtain the following rows.\n",
-			Type: ksSchema.resultSelectTypes[table.Name],
-			Args: graphql.FieldConfigArgument{
-				"filter":  {Type: graphql.NewNonNull(ksSchema.tableOperatorInputTypes[table.Name])},
-				"orderBy": {Type: graphql.NewList(ksSchema.orderEnums[table.Name])},
-				"options": {Type: inputQueryOptions, DefaultValue: inputQueryOptionsDefault},
-			},
+			Type:    ksSchema.resultSelectTypes[table.Name],
+			Args:    filterArgs,
 			Resolve: sg.queryFieldResolver(table, ksSchema, true),
 		}
 	}
 
-	if len(keyspace.Tables) == 0 || len(keyspace.Tables) == len(ksSchema.ignoredTables) {
+	if len(fields) == 0 {
 		// graphql-go requires at least a single query and a single mutation
 		fields["__keyspaceEmptyQuery"] = &graphql.Field{
 			Description: "Placeholder query that is exposed when a keyspace is empty.",
@@ -114,51 +129,78 @@ func (sg *SchemaGenerator) buildMutationFields(
 ) graphql.Fields {
 	fields := graphql.Fields{}
 	for name, table := range keyspace.Tables {
-		if ksSchema.ignoredTables[table.Name] || views[name] {
+		if ksSchema.ignoredTables[table.Name] || views[name] ||
+			!ksSchema.permissions.CanModify(table.Keyspace, table.Name) {
 			continue
 		}
 
-		fields[ksSchema.naming.ToGraphQLOperation(insertPrefix, name)] = &graphql.Field{
-			Description: fmt.Sprintf("Inserts an entire row or upserts data into an existing row of '%s' table. ", table.Name) +
-				"Requires a value for each component of the primary key, but not for any other columns. " +
-				"Missing values are left unset.",
-			Type: ksSchema.resultUpdateTypes[table.Name],
-			Args: graphql.FieldConfigArgument{
-				"value":       {Type: graphql.NewNonNull(ksSchema.tableScalarInputTypes[table.Name])},
-				"ifNotExists": {Type: graphql.Boolean},
-				"options":     {Type: inputMutationOptions, DefaultValue: inputMutationOptionsDefault},
-			},
-			Resolve: sg.mutationFieldResolver(table, ksSchema, insertOperation),
+		if db.IsCounterTable(table) {
+			// Counter columns can't be inserted, only incremented
+			fields[ksSchema.naming.ToGraphQLOperation(incrementPrefix, name)] = &graphql.Field{
+				Description: fmt.Sprintf("Adds the provided values to the counter columns of a row in '%s' table. ", table.Name) +
+					"Requires a value for each component of the primary key, use negative values to decrement.",
+				Type: ksSchema.resultUpdateTypes[table.Name],
+				Args: graphql.FieldConfigArgument{
+					"value":   {Type: graphql.NewNonNull(ksSchema.tableScalarInputTypes[table.Name])},
+					"options": {Type: inputMutationOptions, DefaultValue: inputMutationOptionsDefault},
+				},
+				Resolve: sg.mutationFieldResolver(table, ksSchema, incrementOperation),
+			}
+		} else {
+			fields[ksSchema.naming.ToGraphQLOperation(insertPrefix, name)] = &graphql.Field{
+				Description: fmt.Sprintf("Inserts an entire row or upserts data into an existing row of '%s' table. ", table.Name) +
+					"Requires a value for each component of the primary key, but not for any other columns. " +
+					"Missing values are left unset.",
+				Type: ksSchema.resultUpdateTypes[table.Name],
+				Args: graphql.FieldConfigArgument{
+					"value":       {Type: graphql.NewNonNull(ksSchema.tableScalarInputTypes[table.Name])},
+					"ifNotExists": {Type: graphql.Boolean},
+					"options":     {Type: inputMutationOptions, DefaultValue: inputMutationOptionsDefault},
+				},
+				Resolve: sg.mutationFieldResolver(table, ksSchema, insertOperation),
+			}
 		}
 
+		deleteArgs := graphql.FieldConfigArgument{
+			"value":       {Type: graphql.NewNonNull(ksSchema.tableScalarInputTypes[table.Name])},
+			"ifExists":    {Type: graphql.Boolean},
+			"ifCondition": {Type: ksSchema.tableOperatorInputTypes[table.Name]},
+			"options":     {Type: inputMutationOptions, DefaultValue: inputMutationOptionsDefault},
+		}
+		ksSchema.deleteTargetArgs(table.Name, deleteArgs)
+
 		fields[ksSchema.naming.ToGraphQLOperation(deletePrefix, name)] = &graphql.Field{
-			Description: fmt.Sprintf("Removes an entire row in '%s' table.", table.Name),
-			Type:        ksSchema.resultUpdateTypes[table.Name],
-			Args: graphql.FieldConfigArgument{
-				"value":       {Type: graphql.NewNonNull(ksSchema.tableScalarInputTypes[table.Name])},
-				"ifExists":    {Type: graphql.Boolean},
-				"ifCondition": {Type: ksSchema.tableOperatorInputTypes[table.Name]},
-				"options":     {Type: inputMutationOptions, DefaultValue: inputMutationOptionsDefault},
-			},
+			Description: fmt.Sprintf("Removes an entire row in '%s' table, ", table.Name) +
+				"or only the provided columns and collection elements.",
+			Type:    ksSchema.resultUpdateTypes[table.Name],
+			Args:    deleteArgs,
 			Resolve: sg.mutationFieldResolver(table, ksSchema, deleteOperation),
 		}
 
+		updateArgs := graphql.FieldConfigArgument{
+			"value":       {Type: graphql.NewNonNull(ksSchema.tableScalarInputTypes[table.Name])},
+			"ifExists":    {Type: graphql.Boolean},
+			"ifCondition": {Type: ksSchema.tableOperatorInputTypes[table.Name]},
+			"options":     {Type: inputMutationOptions, DefaultValue: inputMutationOptionsDefault},
+		}
+		if operationsType, ok := ksSchema.collectionOperationsTypes[table.Name]; ok {
+			updateArgs[operationsArgName] = &graphql.ArgumentConfig{
+				Type:        operationsType,
+				Description: "Operations on the elements of the collection columns, applied along with the values.",
+			}
+		}
+
 		fields[ksSchema.naming.ToGraphQLOperation(updatePrefix, name)] = &graphql.Field{
 			Description: fmt.Sprintf("Updates one or more column values to a row in '%s' table.", table.Name) +
 				"Like the insert operation, update is an upsert operation: if the specified row does not exist," +
 				"the command creates it.",
-			Type: ksSchema.resultUpdateTypes[table.Name],
-			Args: graphql.FieldConfigArgument{
-				"value":       {Type: graphql.NewNonNull(ksSchema.tableScalarInputTypes[table.Name])},
-				"ifExists":    {Type: graphql.Boolean},
-				"ifCondition": {Type: ksSchema.tableOperatorInputTypes[table.Name]},
-				"options":     {Type: inputMutationOptions, DefaultValue: inputMutationOptionsDefault},
-			},
+			Type:    ksSchema.resultUpdateTypes[table.Name],
+			Args:    updateArgs,
 			Resolve: sg.mutationFieldResolver(table, ksSchema, updateOperation),
 		}
 	}
 
-	if len(keyspace.Tables) == 0 || len(keyspace.Tables) == len(ksSchema.ignoredTables) {
+	if len(fields) == 0 {
 		// graphql-go requires at least a single query and a single mutation
 		fields["__keyspaceEmptyMutation"] = &graphql.Field{
 			Description: "Placeholder mutation that is exposed when a keyspace is empty.",
@@ -188,7 +230,7 @@ func (sg *SchemaGenerator) BuildSchemas(singleKeyspace string) (map[string]*grap
 	if singleKeyspace != "" {
 		sg.logger.Info("building schema", "keyspace", singleKeyspace)
 		// Schema generator is only focused on a single keyspace
-		if schema, err := sg.buildSchema(singleKeyspace); err != nil {
+		if schema, err := sg.buildSchema(singleKeyspace, nil); err != nil {
 			return nil, err
 		} else {
 			return map[string]*graphql.Schema{singleKeyspace: &schema}, nil
@@ -207,7 +249,7 @@ func (sg *SchemaGenerator) BuildSchemas(singleKeyspace string) (map[string]*grap
 		if sg.isKeyspaceExcluded(ksName) {
 			continue
 		}
-		schema, err := sg.buildSchema(ksName)
+		schema, err := sg.buildSchema(ksName, nil)
 		if err != nil {
 			return nil, err
 		}
@@ -223,8 +265,25 @@ func (sg *SchemaGenerator) BuildSchemas(singleKeyspace string) (map[string]*grap
 	return result, nil
 }
 
-// Build GraphQL schema for tables in the provided keyspace metadata
-func (sg *SchemaGenerator) buildSchema(keyspaceName string) (graphql.Schema, error) {
+// BuildRoleSchema builds the GraphQL schema of a keyspace only containing the queries and mutations of the tables that
+// the role is allowed to SELECT and MODIFY
+func (sg *SchemaGenerator) BuildRoleSchema(keyspaceName string, userOrRole string) (*graphql.Schema, error) {
+	permissions, err := sg.dbClient.RolePermissions(userOrRole)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, err := sg.buildSchema(keyspaceName, permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	return &schema, nil
+}
+
+// Build GraphQL schema for tables in the provided keyspace metadata.
+// When permissions are provided, only the tables granted to the role are included.
+func (sg *SchemaGenerator) buildSchema(keyspaceName string, permissions *db.RolePermissions) (graphql.Schema, error) {
 	keyspace, err := sg.dbClient.Keyspace(keyspaceName)
 	if err != nil {
 		return graphql.Schema{}, err
@@ -240,6 +299,7 @@ func (sg *SchemaGenerator) buildSchema(keyspaceName string) (graphql.Schema, err
 		ignoredTables: make(map[string]bool),
 		schemaGen:     sg,
 		naming:        sg.namingFn(ksNaming),
+		permissions:   permissions,
 	}
 
 	if err := keyspaceSchema.BuildTypes(keyspace); err != nil {
@@ -248,8 +308,9 @@ func (sg *SchemaGenerator) buildSchema(keyspaceName string) (graphql.Schema, err
 
 	return graphql.NewSchema(
 		graphql.SchemaConfig{
-			Query:    sg.buildQuery(keyspaceSchema, keyspace),
-			Mutation: sg.buildMutation(keyspaceSchema, keyspace, views),
+			Query:      sg.buildQuery(keyspaceSchema, keyspace),
+			Mutation:   sg.buildMutation(keyspaceSchema, keyspace, views),
+			Directives: schemaDirectives,
 		},
 	)
 }
@@ -268,3 +329,27 @@ func (sg *SchemaGenerator) checkUserOrRoleAuth(params graphql.ResolveParams) (st
 	}
 	return "", nil
 }
+
+// checkPolicy verifies that the policy grants the permissions on the table and the provided columns to the user or
+// role of the request
+func (sg *SchemaGenerator) checkPolicy(
+	params graphql.ResolveParams,
+	table *gocql.TableMetadata,
+	perms auth.Permissions,
+	columns []string,
+) error {
+	return sg.policy.CheckColumns(auth.ContextUserOrRole(params.Context), table, perms, columns)
+}
+
+// checkAllowFiltering verifies that the table accepts queries using ALLOW FILTERING and that the query restricts the
+// partition key or uses a limit within the configured maximum
+func (sg *SchemaGenerator) checkAllowFiltering(
+	table *gocql.TableMetadata,
+	where []types.ConditionItem,
+	limit int,
+) error {
+	if !sg.allowFiltering.IsAllowed(table.Keyspace, table.Name) {
+		return fmt.Errorf("allow filtering is not enabled for table '%s'", table.Name)
+	}
+	return db.ValidateAllowFiltering(table, where, limit, sg.allowFiltering.Limit())
+}
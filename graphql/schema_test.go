@@ -0,0 +1,47 @@
+package graphql
+
+import (
+	"github.com/datastax/cassandra-data-apis/config"
+	"github.com/datastax/cassandra-data-apis/db"
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSchemaGenerator_BuildRoleSchema(t *testing.T) {
+	sessionMock := db.NewSessionMock()
+	sessionMock.AddKeyspace(db.NewKeyspaceMock(
+		"store", map[string][]*gocql.ColumnMetadata{
+			"books":   db.BooksColumnsMock,
+			"authors": db.BooksColumnsMock,
+			"orders":  db.BooksColumnsMock,
+		}))
+	sessionMock.AddViews(nil)
+	sessionMock.
+		AddRolePermissions("reader", false, []string{"writer"}, map[string][]string{
+			"data/store/books": {"SELECT"},
+		}).
+		AddRolePermissions("writer", false, nil, map[string][]string{
+			"data/store/authors": {"MODIFY"},
+		}).
+		AddRolePermissions("admin", true, nil, nil)
+
+	schemaGen := NewSchemaGenerator(db.NewDbWithSession(sessionMock), config.NewConfigMock().Default())
+
+	schema, err := schemaGen.BuildRoleSchema("store", "reader")
+	assert.NoError(t, err)
+	queries := schema.QueryType().Fields()
+	mutations := schema.MutationType().Fields()
+	assert.Contains(t, queries, "books")
+	assert.NotContains(t, queries, "authors")
+	assert.NotContains(t, queries, "orders")
+	assert.NotContains(t, mutations, "insertBooks")
+	// Permission inherited from writer role
+	assert.Contains(t, mutations, "insertAuthors")
+	assert.NotContains(t, mutations, "insertOrders")
+
+	schema, err = schemaGen.BuildRoleSchema("store", "admin")
+	assert.NoError(t, err)
+	assert.Contains(t, schema.QueryType().Fields(), "orders")
+	assert.Contains(t, schema.MutationType().Fields(), "insertOrders")
+}
@@ -3,7 +3,9 @@ package graphql
 import (
 	"errors"
 	"fmt"
+	"github.com/datastax/cassandra-data-apis/audit"
 	"github.com/datastax/cassandra-data-apis/db"
+	"github.com/datastax/cassandra-data-apis/types"
 	"github.com/gocql/gocql"
 	"github.com/graphql-go/graphql"
 	"github.com/mitchellh/mapstructure"
@@ -295,6 +297,7 @@ func (sg *SchemaGenerator) createTable(params graphql.ResolveParams) (interface{
 		Values:         values,
 		IfNotExists:    ifNotExists,
 	}, db.NewQueryOptions().WithUserOrRole(userOrRole).WithContext(params.Context))
+	sg.auditSchemaChange(params, audit.OperationCreateTable, ksName, tableName, err)
 	return err != nil, err
 }
 
@@ -323,6 +326,7 @@ func (sg *SchemaGenerator) alterTableAdd(params graphql.ResolveParams) (interfac
 		Table:    tableName,
 		ToAdd:    toAdd,
 	}, db.NewQueryOptions().WithUserOrRole(userOrRole).WithContext(params.Context))
+	sg.auditSchemaChange(params, audit.OperationAlterTableAdd, ksName, tableName, err)
 	return err != nil, err
 }
 
@@ -351,6 +355,7 @@ func (sg *SchemaGenerator) alterTableDrop(params graphql.ResolveParams) (interfa
 		Table:    tableName,
 		ToDrop:   toDrop,
 	}, db.NewQueryOptions().WithUserOrRole(userOrRole).WithContext(params.Context))
+	sg.auditSchemaChange(params, audit.OperationAlterTableDrop, ksName, tableName, err)
 	return err != nil, err
 }
 
@@ -367,6 +372,7 @@ func (sg *SchemaGenerator) dropTable(params graphql.ResolveParams) (interface{},
 		Table:    tableName,
 		IfExists: getBoolArg(args, "ifExists")},
 		db.NewQueryOptions().WithUserOrRole(userOrRole).WithContext(params.Context))
+	sg.auditSchemaChange(params, audit.OperationDropTable, ksName, tableName, err)
 	return err != nil, err
 }
 
@@ -404,8 +410,24 @@ func toColumnType(info gocql.TypeInfo) (*dataTypeValue, error) {
 		subTypeInfo = &dataTypeInfo{
 			Name: info.Custom(),
 		}
-	case gocql.TypeUDT, gocql.TypeTuple:
-		return nil, errors.New("Not yet supported")
+	case gocql.TypeUDT:
+		subTypeInfo = &dataTypeInfo{
+			Name: info.(gocql.UDTTypeInfo).Name,
+		}
+	case gocql.TypeTuple:
+		tupleInfo := info.(gocql.TupleTypeInfo)
+		subTypes := make([]dataTypeValue, 0, len(tupleInfo.Elems))
+		for _, elem := range tupleInfo.Elems {
+			elemType, err := toColumnType(elem)
+			if err != nil {
+				return nil, err
+			}
+			subTypes = append(subTypes, *elemType)
+		}
+
+		subTypeInfo = &dataTypeInfo{
+			SubTypes: subTypes,
+		}
 	}
 
 	return &dataTypeValue{
@@ -452,6 +474,9 @@ func toDbColumnType(info *dataTypeValue) (gocql.TypeInfo, error) {
 			Elem:       valueType,
 		}, nil
 	case gocql.TypeCustom:
+		if vectorType, ok := types.ParseVectorType(info.TypeInfo.Name); ok {
+			return vectorType, nil
+		}
 		return gocql.NewNativeType(0, info.Basic, info.TypeInfo.Name), nil
 	case gocql.TypeUDT, gocql.TypeTuple:
 		return nil, errors.New("udts and tuples are not supported yet")
@@ -0,0 +1,150 @@
+package graphql
+
+import (
+	"fmt"
+	"github.com/datastax/cassandra-data-apis/types"
+	"github.com/gocql/gocql"
+	"github.com/graphql-go/graphql"
+	"strings"
+)
+
+const tupleFieldPrefix = "item"
+
+// buildUDTType returns the object or input type of a user-defined type, using the names of the fields
+func (s *KeyspaceGraphQLSchema) buildUDTType(udt gocql.UDTTypeInfo, isInput bool) (graphql.Output, error) {
+	suffix := "Udt"
+	if isInput {
+		suffix = "UdtInput"
+	}
+
+	typeName := s.naming.ToGraphQLTypeUnique(udt.Name, suffix)
+	if t, ok := s.compositeTypes[typeName]; ok {
+		return t, nil
+	}
+
+	fields := graphql.Fields{}
+	inputFields := graphql.InputObjectConfigFieldMap{}
+	for _, field := range udt.Elements {
+		if !validName.MatchString(field.Name) {
+			return nil, fmt.Errorf("field %s of type %s didn't match regex %s", field.Name, udt.Name, validName.String())
+		}
+
+		fieldType, err := s.buildType(field.Type, isInput)
+		if err != nil {
+			return nil, err
+		}
+		fields[field.Name] = &graphql.Field{Type: fieldType}
+		inputFields[field.Name] = &graphql.InputObjectFieldConfig{Type: fieldType}
+	}
+
+	var t graphql.Output
+	if isInput {
+		t = graphql.NewInputObject(graphql.InputObjectConfig{
+			Description: fmt.Sprintf("Input type for the values of the '%s' user-defined type.", udt.Name),
+			Name:        typeName,
+			Fields:      inputFields,
+		})
+	} else {
+		t = graphql.NewObject(graphql.ObjectConfig{
+			Description: fmt.Sprintf("Type to populate the values of the '%s' user-defined type.", udt.Name),
+			Name:        typeName,
+			Fields:      fields,
+		})
+	}
+
+	s.compositeTypes[typeName] = t
+	return t, nil
+}
+
+// buildTupleType returns the object or input type of a tuple, with a field for each element: item0, item1, ...
+func (s *KeyspaceGraphQLSchema) buildTupleType(tuple gocql.TupleTypeInfo, isInput bool) (graphql.Output, error) {
+	elemTypes := make([]graphql.Output, 0, len(tuple.Elems))
+	elemNames := make([]string, 0, len(tuple.Elems))
+	for _, elem := range tuple.Elems {
+		elemType, err := s.buildType(elem, isInput)
+		if err != nil {
+			return nil, err
+		}
+
+		elemName := getTypeName(elemType)
+		if elemName == "" {
+			return nil, fmt.Errorf("Type for %s could not be created", tuple.Type().String())
+		}
+		elemTypes = append(elemTypes, elemType)
+		elemNames = append(elemNames, elemName)
+	}
+
+	baseName := fmt.Sprintf("Tuple%s", strings.Join(elemNames, ""))
+	var typeName string
+	if isInput {
+		typeName = s.naming.ToGraphQLTypeUnique(baseName, "Input")
+	} else {
+		typeName = s.naming.ToGraphQLTypeUnique(baseName, "")
+	}
+
+	if t, ok := s.compositeTypes[typeName]; ok {
+		return t, nil
+	}
+
+	var t graphql.Output
+	if isInput {
+		fields := graphql.InputObjectConfigFieldMap{}
+		for i, elemType := range elemTypes {
+			fields[tupleFieldName(i)] = &graphql.InputObjectFieldConfig{Type: elemType}
+		}
+		t = graphql.NewInputObject(graphql.InputObjectConfig{Name: typeName, Fields: fields})
+	} else {
+		fields := graphql.Fields{}
+		for i, elemType := range elemTypes {
+			fields[tupleFieldName(i)] = &graphql.Field{Type: elemType}
+		}
+		t = graphql.NewObject(graphql.ObjectConfig{Name: typeName, Fields: fields})
+	}
+
+	s.compositeTypes[typeName] = t
+	return t, nil
+}
+
+func tupleFieldName(index int) string {
+	return fmt.Sprintf("%s%d", tupleFieldPrefix, index)
+}
+
+// objectValue is the value of an input object, representing a user-defined type or a tuple. It's converted using
+// the type of the parameter when the query is executed.
+type objectValue map[string]interface{}
+
+func (v objectValue) MarshalCQL(info gocql.TypeInfo) ([]byte, error) {
+	switch t := info.(type) {
+	case gocql.UDTTypeInfo:
+		return types.UDTParameter(v).MarshalCQL(info)
+	case gocql.TupleTypeInfo:
+		elements := make(types.TupleParameter, 0, len(t.Elems))
+		for i := range t.Elems {
+			elements = append(elements, v[tupleFieldName(i)])
+		}
+		return elements.MarshalCQL(info)
+	}
+
+	return nil, fmt.Errorf("can not marshal an object into %s", info.Type().String())
+}
+
+// objectListValue is the value of a list of input objects, representing either a map as a list of key/value objects
+// or a collection of user-defined types or tuples. It's converted using the type of the parameter when the query is
+// executed.
+type objectListValue []objectValue
+
+func (v objectListValue) MarshalCQL(info gocql.TypeInfo) ([]byte, error) {
+	if info.Type() == gocql.TypeMap {
+		entries := make(map[interface{}]interface{}, len(v))
+		for _, entry := range v {
+			entries[entry["key"]] = entry["value"]
+		}
+		return gocql.Marshal(info, entries)
+	}
+
+	elements := make([]interface{}, 0, len(v))
+	for _, element := range v {
+		elements = append(elements, element)
+	}
+	return gocql.Marshal(info, elements)
+}
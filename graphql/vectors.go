@@ -0,0 +1,115 @@
+package graphql
+
+import (
+	"fmt"
+	"github.com/datastax/cassandra-data-apis/db"
+	"github.com/datastax/cassandra-data-apis/types"
+	"github.com/gocql/gocql"
+	"github.com/graphql-go/graphql"
+)
+
+const annOfArgName = "annOf"
+
+// buildAnnTypes builds the input types to order the rows by similarity to a vector, for the tables that contain
+// vector columns with an index
+func (s *KeyspaceGraphQLSchema) buildAnnTypes(keyspace *gocql.KeyspaceMetadata) {
+	s.annInputTypes = make(map[string]*graphql.InputObject)
+
+	for _, table := range keyspace.Tables {
+		valueType, ok := s.tableValueTypes[table.Name]
+		if !ok {
+			continue
+		}
+
+		valueFields := valueType.Fields()
+		fields := graphql.InputObjectConfigFieldMap{}
+		for name, column := range table.Columns {
+			fieldName := s.naming.ToGraphQLField(table.Name, name)
+			if _, ok := valueFields[fieldName]; !ok {
+				continue
+			}
+
+			if _, ok := db.VectorSimilarityFunction(column); ok {
+				fields[fieldName] = &graphql.InputObjectFieldConfig{
+					Type: graphql.NewList(graphql.NewNonNull(float32Scalar)),
+				}
+			}
+		}
+
+		if len(fields) == 0 {
+			continue
+		}
+
+		s.annInputTypes[table.Name] = graphql.NewInputObject(graphql.InputObjectConfig{
+			Description: fmt.Sprintf("Input type to order the '%s' table rows by similarity to a vector. ", table.Name) +
+				"Requires a single column and a limit.",
+			Name:   s.naming.ToGraphQLTypeUnique(table.Name, "AnnInput"),
+			Fields: fields,
+		})
+	}
+}
+
+// similarityField is the field of the similarity score of the rows, included in the types of the tables that contain
+// vector columns with an index
+var similarityField = &graphql.Field{
+	Type:        float32Scalar,
+	Description: fmt.Sprintf("The similarity of the row to the vector of the '%s' argument.", annOfArgName),
+}
+
+func (s *KeyspaceGraphQLSchema) annOfArgs(tableName string, args graphql.FieldConfigArgument) {
+	if annType, ok := s.annInputTypes[tableName]; ok {
+		args[annOfArgName] = &graphql.ArgumentConfig{
+			Type: annType,
+			Description: "Orders the rows by similarity to the vector of an indexed column, it can't be combined " +
+				"with orderBy.",
+		}
+	}
+}
+
+// adaptAnnOrder converts the annOf argument of a query into the vector to order the rows by, using the type of the
+// column
+func (s *KeyspaceGraphQLSchema) adaptAnnOrder(
+	table *gocql.TableMetadata,
+	data map[string]interface{},
+) (*db.AnnOrder, error) {
+	if len(data) != 1 {
+		return nil, fmt.Errorf("%s requires a single column", annOfArgName)
+	}
+
+	var result *db.AnnOrder
+	for fieldName, value := range data {
+		column, ok := table.Columns[s.naming.ToCQLColumn(table.Name, fieldName)]
+		if !ok {
+			return nil, fmt.Errorf("column for field '%s' not found", fieldName)
+		}
+
+		vectorType, ok := column.Type.(types.VectorType)
+		if !ok || value == nil {
+			return nil, fmt.Errorf("a vector must be provided for field '%s'", fieldName)
+		}
+
+		vector, err := types.ArrayToVectorParameter(value, vectorType)
+		if err != nil {
+			return nil, err
+		}
+		result = &db.AnnOrder{Column: column.Name, Vector: vector}
+	}
+
+	return result, nil
+}
+
+// floatListValue is the value of a list of Float32 values, representing either a collection of floats or a vector.
+// It's converted using the type of the parameter when the query is executed.
+type floatListValue []interface{}
+
+func (v floatListValue) MarshalCQL(info gocql.TypeInfo) ([]byte, error) {
+	if vectorType, ok := types.AsVectorType(info); ok {
+		vector, err := types.ArrayToVectorParameter([]interface{}(v), vectorType)
+		if err != nil {
+			return nil, err
+		}
+		return gocql.Marshal(info, vector)
+	}
+
+	return gocql.Marshal(info, []interface{}(v))
+}
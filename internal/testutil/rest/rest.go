@@ -34,6 +34,19 @@ func ExecutePost(
 	return execute(http.MethodPost, routes, routeFormat, requestBody, responsePtr, values...)
 }
 
+// ExecutePostWithContentType performs a POST request with a body of the provided content type, e.g. text/csv
+func ExecutePostWithContentType(
+	routes []types.Route,
+	routeFormat string,
+	contentType string,
+	requestBody string,
+	responsePtr interface{},
+	values ...interface{},
+) int {
+	return executeWithContentType(http.MethodPost, routes, routeFormat, contentType, requestBody, responsePtr,
+		values...)
+}
+
 func ExecutePut(
 	routes []types.Route,
 	routeFormat string,
@@ -52,6 +65,34 @@ func ExecuteDelete(
 	return execute(http.MethodDelete, routes, routeFormat, "", nil, values...)
 }
 
+// ExecuteRaw performs a request with the provided query string and returns the recorded response, without decoding the
+// body
+func ExecuteRaw(
+	method string,
+	routes []types.Route,
+	routeFormat string,
+	rawQuery string,
+	requestBody string,
+	values ...interface{},
+) *httptest.ResponseRecorder {
+	targetPath := path.Join(Prefix, fmt.Sprintf(routeFormat, values...))
+	if rawQuery != "" {
+		targetPath += "?" + rawQuery
+	}
+
+	r := httptest.NewRequest(method, targetPath, bytes.NewBufferString(requestBody))
+	if requestBody != "" {
+		r.Header.Set("Content-Type", "application/json")
+	}
+
+	w := httptest.NewRecorder()
+	route := lookupRoute(routes, method, routeFormat)
+	router := httprouter.New()
+	router.Handler(method, route.Pattern, route.Handler)
+	router.ServeHTTP(w, r)
+	return w
+}
+
 // ExecuteGetDataTypeJson performs a GET request and returns the json decoded value of the provided row cell
 func ExecuteGetDataTypeJsonValue(routes []types.Route, dataType, id string) interface{} {
 	var response models.Rows
@@ -67,6 +108,19 @@ func execute(
 	requestBody string,
 	responsePtr interface{},
 	values ...interface{},
+) int {
+	return executeWithContentType(method, routes, routeFormat, "application/json", requestBody, responsePtr,
+		values...)
+}
+
+func executeWithContentType(
+	method string,
+	routes []types.Route,
+	routeFormat string,
+	contentType string,
+	requestBody string,
+	responsePtr interface{},
+	values ...interface{},
 ) int {
 	rv := reflect.ValueOf(responsePtr)
 	if responsePtr != nil && rv.Kind() != reflect.Ptr {
@@ -81,7 +135,7 @@ func execute(
 
 	r, _ := http.NewRequest(method, targetPath, body)
 	if body != nil {
-		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set("Content-Type", contentType)
 	}
 
 	w := httptest.NewRecorder()
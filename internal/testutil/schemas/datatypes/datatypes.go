@@ -264,5 +264,7 @@ func ScalarJsonValues() [][]interface{} {
 		{"blob", "ABEi"},
 		{"timestamp", "2005-08-05T13:20:21.52Z"},
 		{"time", "08:45:02"},
+		{"date", "2020-03-25"},
+		{"duration", "1y2mo3d4h5m6s"},
 	}
 }
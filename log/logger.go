@@ -24,6 +24,13 @@ func (rec *statusRecorder) WriteHeader(code int) {
 	rec.ResponseWriter.WriteHeader(code)
 }
 
+// Flush allows streaming responses when request logging is enabled
+func (rec *statusRecorder) Flush() {
+	if flusher, ok := rec.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
 type LoggingHandler struct {
 	handler http.Handler
 	logger  Logger
@@ -0,0 +1,200 @@
+package ratelimit
+
+import (
+	"fmt"
+	"github.com/datastax/cassandra-data-apis/auth"
+	"github.com/datastax/cassandra-data-apis/log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Operation int
+
+const (
+	OperationRead Operation = iota
+	OperationWrite
+	OperationSchema
+)
+
+func (o Operation) String() string {
+	switch o {
+	case OperationRead:
+		return "read"
+	case OperationWrite:
+		return "write"
+	case OperationSchema:
+		return "schema"
+	}
+	return "unknown"
+}
+
+const (
+	KeyRole     = "role"
+	KeyIp       = "ip"
+	KeyKeyspace = "keyspace"
+)
+
+// idleTimeout is the time after which the buckets that were not used are removed
+const idleTimeout = 5 * time.Minute
+
+// Limit is the rate of requests per second allowed, with bursts of up to Burst requests.
+// A zero Rate disables the limit.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+type Options struct {
+	Read   Limit
+	Write  Limit
+	Schema Limit
+	// KeyBy contains the parts of the request used to identify the budgets: "role", "ip" and/or "keyspace"
+	KeyBy []string
+}
+
+type bucket struct {
+	tokens   float64
+	lastUsed time.Time
+}
+
+// Limiter is a token bucket rate limiter with separate budgets for read, write and schema operations
+type Limiter struct {
+	mutex     sync.Mutex
+	limits    map[Operation]Limit
+	keyBy     map[string]bool
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewLimiter(options Options) (*Limiter, error) {
+	limits := map[Operation]Limit{
+		OperationRead:   options.Read,
+		OperationWrite:  options.Write,
+		OperationSchema: options.Schema,
+	}
+
+	for op, limit := range limits {
+		if limit.Rate < 0 || (limit.Rate > 0 && limit.Burst < 1) {
+			return nil, fmt.Errorf("invalid %s rate limit, rate must be positive and burst at least 1", op)
+		}
+	}
+
+	keyBy := make(map[string]bool, len(options.KeyBy))
+	for _, key := range options.KeyBy {
+		key = strings.ToLower(key)
+		switch key {
+		case KeyRole, KeyIp, KeyKeyspace:
+			keyBy[key] = true
+		default:
+			return nil, fmt.Errorf("invalid rate limit key: %s", key)
+		}
+	}
+
+	return &Limiter{
+		limits:  limits,
+		keyBy:   keyBy,
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}, nil
+}
+
+// Allow consumes a token from the budget of the operation for the user or role, client IP and keyspace, when the
+// budget is exhausted, it returns false and the time to wait before retrying
+func (l *Limiter) Allow(userOrRole string, ip string, keyspace string, op Operation) (bool, time.Duration) {
+	limit := l.limits[op]
+	if limit.Rate == 0 {
+		return true, 0
+	}
+
+	key := op.String()
+	if l.keyBy[KeyRole] {
+		key += "|" + userOrRole
+	}
+	if l.keyBy[KeyIp] {
+		key += "|" + ip
+	}
+	if l.keyBy[KeyKeyspace] {
+		key += "|" + keyspace
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, found := l.buckets[key]
+	if !found {
+		b = &bucket{tokens: float64(limit.Burst)}
+		l.buckets[key] = b
+	} else {
+		b.tokens = math.Min(float64(limit.Burst), b.tokens+now.Sub(b.lastUsed).Seconds()*limit.Rate)
+	}
+	b.lastUsed = now
+
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second))
+	}
+
+	b.tokens--
+	return true, 0
+}
+
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < idleTimeout {
+		return
+	}
+
+	for key, b := range l.buckets {
+		if now.Sub(b.lastUsed) > idleTimeout {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+// Classifier returns the keyspace and the operation of a request
+type Classifier func(r *http.Request) (string, Operation)
+
+type limitHandler struct {
+	handler  http.Handler
+	limiter  *Limiter
+	classify Classifier
+	logger   log.Logger
+}
+
+// NewHandler creates a http handler that rejects the requests exceeding the limits with a 429 status code and a
+// Retry-After header. It should be wrapped by the authentication handler to limit by user or role.
+func NewHandler(handler http.Handler, limiter *Limiter, classify Classifier, logger log.Logger) http.Handler {
+	return &limitHandler{
+		handler:  handler,
+		limiter:  limiter,
+		classify: classify,
+		logger:   logger,
+	}
+}
+
+func (h *limitHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	keyspace, op := h.classify(r)
+	userOrRole := auth.ContextUserOrRole(r.Context())
+	ip := auth.ClientIp(r)
+
+	if allowed, wait := h.limiter.Allow(userOrRole, ip, keyspace, op); !allowed {
+		h.logger.Debug("request rate limit exceeded",
+			"requestURI", r.RequestURI,
+			"userOrRole", userOrRole,
+			"ip", ip,
+			"keyspace", keyspace,
+			"operation", op)
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+		return
+	}
+
+	h.handler.ServeHTTP(w, r)
+}
@@ -0,0 +1,91 @@
+package ratelimit
+
+import (
+	"github.com/datastax/cassandra-data-apis/auth"
+	"github.com/datastax/cassandra-data-apis/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLimiter_Allow(t *testing.T) {
+	limiter, err := NewLimiter(Options{
+		Read:   Limit{Rate: 10, Burst: 2},
+		Schema: Limit{Rate: 0.1, Burst: 1},
+		KeyBy:  []string{KeyRole, KeyKeyspace},
+	})
+	require.NoError(t, err)
+
+	now := time.Now()
+	limiter.now = func() time.Time { return now }
+
+	allowed, _ := limiter.Allow("role1", "10.0.0.1", "ks1", OperationRead)
+	assert.True(t, allowed)
+	allowed, _ = limiter.Allow("role1", "10.0.0.2", "ks1", OperationRead)
+	assert.True(t, allowed)
+	allowed, wait := limiter.Allow("role1", "10.0.0.1", "ks1", OperationRead)
+	assert.False(t, allowed)
+	assert.Equal(t, 100*time.Millisecond, wait)
+
+	// Separate budgets by role and keyspace
+	allowed, _ = limiter.Allow("role2", "10.0.0.1", "ks1", OperationRead)
+	assert.True(t, allowed)
+	allowed, _ = limiter.Allow("role1", "10.0.0.1", "ks2", OperationRead)
+	assert.True(t, allowed)
+
+	// Writes are not limited
+	for i := 0; i < 10; i++ {
+		allowed, _ = limiter.Allow("role1", "10.0.0.1", "ks1", OperationWrite)
+		assert.True(t, allowed)
+	}
+
+	allowed, _ = limiter.Allow("role1", "10.0.0.1", "ks1", OperationSchema)
+	assert.True(t, allowed)
+	allowed, wait = limiter.Allow("role1", "10.0.0.1", "ks1", OperationSchema)
+	assert.False(t, allowed)
+	assert.Equal(t, 10*time.Second, wait)
+
+	now = now.Add(100 * time.Millisecond)
+	allowed, _ = limiter.Allow("role1", "10.0.0.1", "ks1", OperationRead)
+	assert.True(t, allowed)
+
+	// Idle buckets are removed
+	now = now.Add(idleTimeout + time.Second)
+	allowed, _ = limiter.Allow("role1", "10.0.0.1", "ks1", OperationRead)
+	assert.True(t, allowed)
+	assert.Len(t, limiter.buckets, 1)
+}
+
+func TestNewLimiter_Invalid(t *testing.T) {
+	_, err := NewLimiter(Options{Write: Limit{Rate: 1}})
+	assert.EqualError(t, err, "invalid write rate limit, rate must be positive and burst at least 1")
+
+	_, err = NewLimiter(Options{KeyBy: []string{"table"}})
+	assert.EqualError(t, err, "invalid rate limit key: table")
+}
+
+func TestHandler(t *testing.T) {
+	limiter, err := NewLimiter(Options{Write: Limit{Rate: 1, Burst: 1}, KeyBy: []string{KeyRole, KeyIp}})
+	require.NoError(t, err)
+
+	handler := NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), limiter,
+		func(r *http.Request) (string, Operation) { return "ks1", OperationWrite }, log.NewZapLogger(zap.NewNop()))
+
+	request := func(userOrRole string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		r = r.WithContext(auth.WithContextUserOrRole(r.Context(), userOrRole))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, request("role1").Code)
+	w := request("role1")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+	assert.Equal(t, http.StatusOK, request("role2").Code)
+}
@@ -0,0 +1,84 @@
+package endpoint
+
+import (
+	"fmt"
+	"github.com/datastax/cassandra-data-apis/db"
+	m "github.com/datastax/cassandra-data-apis/rest/models"
+	"github.com/datastax/cassandra-data-apis/types"
+	"github.com/gocql/gocql"
+	"strings"
+)
+
+// queryAggregates validates the aggregation of a query and returns the aggregates to compute, along with the columns
+// that are read by the aggregation
+func queryAggregates(table *gocql.TableMetadata, aggregation *m.Aggregation) ([]db.Aggregate, []string, error) {
+	aggregates := make([]db.Aggregate, 0, len(aggregation.Functions))
+	readColumns := make([]string, 0, len(aggregation.Functions)+len(aggregation.GroupBy))
+	aliases := make(map[string]bool, len(aggregation.Functions))
+
+	for _, function := range aggregation.Functions {
+		alias := aggregateAlias(function)
+		if aliases[alias] {
+			return nil, nil, fmt.Errorf("aggregate function '%s' is duplicated", alias)
+		}
+		aliases[alias] = true
+
+		if function.ColumnName == "" {
+			if function.Name != "count" {
+				return nil, nil, fmt.Errorf("aggregate function '%s' requires a column", function.Name)
+			}
+		} else {
+			if _, ok := table.Columns[function.ColumnName]; !ok {
+				return nil, nil, fmt.Errorf("column '%s' not found", function.ColumnName)
+			}
+			readColumns = append(readColumns, function.ColumnName)
+		}
+
+		aggregates = append(aggregates, db.Aggregate{
+			Function: db.AggregateFunction(strings.ToUpper(function.Name)),
+			Column:   function.ColumnName,
+			Alias:    alias,
+		})
+	}
+
+	for _, columnName := range aggregation.GroupBy {
+		column, ok := table.Columns[columnName]
+		if !ok {
+			return nil, nil, fmt.Errorf("column '%s' not found", columnName)
+		}
+		if column.Kind != gocql.ColumnPartitionKey && column.Kind != gocql.ColumnClusteringKey {
+			return nil, nil, fmt.Errorf("column '%s' is not a primary key column", columnName)
+		}
+		readColumns = append(readColumns, columnName)
+	}
+
+	return aggregates, readColumns, nil
+}
+
+// aggregateAlias returns the name of the result of an aggregate function: "count" when counting rows and
+// "<function>_<column>" otherwise
+func aggregateAlias(function m.AggregateFunction) string {
+	if function.ColumnName == "" {
+		return function.Name
+	}
+	return function.Name + "_" + function.ColumnName
+}
+
+// toJsonAggregates converts the results of the aggregate functions using the type of the column they were applied on
+func toJsonAggregates(
+	rows []map[string]interface{},
+	table *gocql.TableMetadata,
+	aggregates []db.Aggregate,
+) []map[string]interface{} {
+	for _, row := range rows {
+		for _, aggregate := range aggregates {
+			if aggregate.Function == db.AggregateCount || aggregate.Column == "" {
+				continue
+			}
+			if value, ok := row[aggregate.Alias]; ok {
+				row[aggregate.Alias] = types.ToJsonValue(value, table.Columns[aggregate.Column].Type)
+			}
+		}
+	}
+	return rows
+}
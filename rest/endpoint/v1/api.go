@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/datastax/cassandra-data-apis/audit"
 	"github.com/datastax/cassandra-data-apis/auth"
 	"github.com/datastax/cassandra-data-apis/config"
 	"github.com/datastax/cassandra-data-apis/db"
@@ -16,7 +17,9 @@ import (
 	"github.com/go-playground/validator/v10"
 	enTranslations "github.com/go-playground/validator/v10/translations/en"
 	"github.com/gocql/gocql"
+	"io"
 	"net/http"
+	"sort"
 	"strings"
 )
 
@@ -143,6 +146,7 @@ func (s *routeList) AddColumn(w http.ResponseWriter, r *http.Request) {
 	}
 
 	err = s.dbClient.AlterTableAdd(&tableInfo, newDbOptions(user))
+	s.audit(r, audit.OperationAlterTableAdd, keyspaceName, tableName, nil, err)
 	if err != nil {
 		msg := "unable to execute alter table query"
 		s.logger.Debug(msg, "keyspace", keyspaceName, "table", tableName, "error", err)
@@ -173,6 +177,7 @@ func (s *routeList) DeleteColumn(w http.ResponseWriter, r *http.Request) {
 		Table:    tableName,
 		ToDrop:   []string{columnName},
 	}, newDbOptions(user))
+	s.audit(r, audit.OperationAlterTableDrop, keyspaceName, tableName, nil, err)
 
 	if err != nil {
 		msg := "unable to execute alter table query"
@@ -251,7 +256,7 @@ func (s *routeList) GetRow(w http.ResponseWriter, r *http.Request) {
 	}
 
 	rowsModel := m.Rows{
-		Rows:  types.ToJsonValues(rs.Values(), tblMetadata),
+		Rows:  types.ToJsonValues(s.policy.FilterRows(user, tblMetadata, rs.Values()), tblMetadata),
 		Count: length,
 	}
 
@@ -291,6 +296,11 @@ func (s *routeList) AddRow(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if db.IsCounterTable(tblMetadata) {
+		RespondWithError(w, "Rows of counter tables can only be incremented", http.StatusBadRequest)
+		return
+	}
+
 	columns := make([]string, len(rowAdd.Columns))
 	values := make([]interface{}, len(rowAdd.Columns))
 
@@ -314,13 +324,20 @@ func (s *routeList) AddRow(w http.ResponseWriter, r *http.Request) {
 		values[i] = convertedType
 	}
 
+	if err := s.policy.CheckColumns(user, tblMetadata, auth.PermissionInsert, columns); err != nil {
+		RespondWithError(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
 	_, err = s.dbClient.Insert(&db.InsertInfo{
 		Keyspace:    keyspaceName,
 		Table:       tableName,
 		Columns:     columns,
 		QueryParams: values,
 		TTL:         0,
+		Timestamp:   rowAdd.Timestamp,
 	}, newDbOptions(user))
+	s.audit(r, audit.OperationInsert, keyspaceName, tableName, primaryKeyMap(tblMetadata, columns, values), err)
 
 	if err != nil {
 		msg := "unable to execute insert query"
@@ -363,21 +380,96 @@ func (s *routeList) Query(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	where := make([]types.ConditionItem, len(queryModel.Filters))
+	where, err := filterConditions(tblMetadata, queryModel.Filters)
+	if err != nil {
+		RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	for i, filter := range queryModel.Filters {
-		operator, found := types.CqlOperators[filter.Operator]
+	readColumns := append([]string{}, queryModel.ColumnNames...)
+	for _, filter := range queryModel.Filters {
+		readColumns = append(readColumns, filter.ColumnName)
+	}
 
-		if !found {
-			RespondWithError(w, fmt.Sprintf("operator '%s' not found", filter.Operator), http.StatusBadRequest)
+	columns := queryModel.ColumnNames
+	var aggregates []db.Aggregate
+	var groupBy []string
+	if queryModel.Aggregation != nil {
+		if len(queryModel.ColumnNames) > 0 {
+			RespondWithError(w, "columnNames can not be used with aggregation, use groupBy instead",
+				http.StatusBadRequest)
 			return
 		}
 
-		where[i] = types.ConditionItem{
-			Column:   filter.ColumnName,
-			Operator: operator,
-			Value:    filter.Value,
+		if len(queryModel.WriteTime) > 0 || len(queryModel.TTL) > 0 {
+			RespondWithError(w, "writeTime and ttl can not be used with aggregation", http.StatusBadRequest)
+			return
 		}
+
+		var aggregateColumns []string
+		if aggregates, aggregateColumns, err = queryAggregates(tblMetadata, queryModel.Aggregation); err != nil {
+			RespondWithError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		readColumns = append(readColumns, aggregateColumns...)
+		// The values of the grouping columns are included in the result
+		groupBy = queryModel.Aggregation.GroupBy
+		columns = groupBy
+	}
+
+	var annOf *db.AnnOrder
+	similarity := ""
+	if queryModel.AnnOf != nil {
+		if queryModel.OrderBy != nil {
+			RespondWithError(w, "orderBy can not be used with annOf", http.StatusBadRequest)
+			return
+		}
+
+		if annOf, similarity, err = annOrder(tblMetadata, queryModel.AnnOf, queryModel.Limit); err != nil {
+			RespondWithError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		readColumns = append(readColumns, annOf.Column)
+	}
+
+	if queryModel.AllowFiltering {
+		if !s.allowFiltering.IsAllowed(keyspaceName, tableName) {
+			RespondWithError(w, fmt.Sprintf(`allow filtering is not enabled for table "%s"."%s"`,
+				keyspaceName, tableName), http.StatusBadRequest)
+			return
+		}
+
+		limit := queryModel.Limit
+		if queryModel.Aggregation != nil {
+			// The limit doesn't bound the rows read to compute the aggregates
+			limit = 0
+		}
+		if err := db.ValidateAllowFiltering(tblMetadata, where, limit, s.allowFiltering.Limit()); err != nil {
+			RespondWithError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if len(queryModel.WriteTime) > 0 || len(queryModel.TTL) > 0 || similarity != "" {
+		metadataColumns := append(append([]string{}, queryModel.WriteTime...), queryModel.TTL...)
+		if err := checkCellMetadataColumns(tblMetadata, metadataColumns); err != nil {
+			RespondWithError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		readColumns = append(readColumns, metadataColumns...)
+
+		if len(columns) == 0 {
+			// The columns must be listed along with the write time, ttl and similarity selectors
+			for name := range tblMetadata.Columns {
+				columns = append(columns, name)
+			}
+			sort.Strings(columns)
+		}
+	}
+
+	if err := s.policy.CheckColumns(user, tblMetadata, auth.PermissionRead, readColumns); err != nil {
+		RespondWithError(w, err.Error(), http.StatusForbidden)
+		return
 	}
 
 	pageState, err := base64.StdEncoding.DecodeString(queryModel.PageState)
@@ -399,12 +491,40 @@ func (s *routeList) Query(w http.ResponseWriter, r *http.Request) {
 		}}
 	}
 
+	if queryModel.Aggregation != nil && len(where) == 0 && len(groupBy) == 0 &&
+		db.CanScanAggregates(tblMetadata, aggregates) {
+		// Aggregating all the rows of the table is distributed across the token ranges
+		row, err := s.dbClient.ScanAggregates(
+			keyspaceName, tableName, aggregates, newDbOptions(user), db.DefaultScanConcurrency)
+		if err != nil {
+			msg := "unable to execute aggregate queries"
+			s.logger.Debug(msg, "keyspace", keyspaceName, "table", tableName, "error", err)
+			RespondWithError(w, msg, http.StatusInternalServerError)
+			return
+		}
+
+		rows := types.ToJsonValues([]map[string]interface{}{row}, tblMetadata)
+		RespondJSONObjectWithCode(w, http.StatusOK, m.Rows{
+			Rows:  toJsonAggregates(rows, tblMetadata, aggregates),
+			Count: len(rows),
+		})
+		return
+	}
+
 	rs, err := s.dbClient.Select(&db.SelectInfo{
-		Keyspace: keyspaceName,
-		Table:    tableName,
-		Columns:  queryModel.ColumnNames,
-		Where:    where,
-		OrderBy:  orderBy,
+		Keyspace:       keyspaceName,
+		Table:          tableName,
+		Columns:        columns,
+		Aggregates:     aggregates,
+		GroupBy:        groupBy,
+		Where:          where,
+		OrderBy:        orderBy,
+		WriteTime:      queryModel.WriteTime,
+		TTL:            queryModel.TTL,
+		AnnOf:          annOf,
+		Similarity:     similarity,
+		Options:        &types.QueryOptions{Limit: queryModel.Limit},
+		AllowFiltering: queryModel.AllowFiltering,
 	}, newDbOptions(user).WithPageSize(queryModel.PageSize).WithPageState(pageState))
 
 	if err != nil {
@@ -414,8 +534,9 @@ func (s *routeList) Query(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	rows := types.ToJsonValues(s.policy.FilterRows(user, tblMetadata, rs.Values()), tblMetadata)
 	rowsModel := m.Rows{
-		Rows:      types.ToJsonValues(rs.Values(), tblMetadata),
+		Rows:      toJsonAggregates(rows, tblMetadata, aggregates),
 		PageState: base64.StdEncoding.EncodeToString(rs.PageState()),
 		Count:     len(rs.Values()),
 	}
@@ -456,18 +577,34 @@ func (s *routeList) UpdateRow(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	columns := make([]string, len(rowUpdate.Changeset)+len(primaryKeysColumns))
-	values := make([]interface{}, len(columns))
+	columns := make([]string, 0, len(rowUpdate.Changeset)+len(primaryKeysColumns))
+	values := make([]interface{}, 0, cap(columns))
+	updatedColumns := make([]string, 0, len(rowUpdate.Changeset))
+	var collectionUpdates []db.CollectionUpdate
 
-	for i, val := range rowUpdate.Changeset {
-		if _, ok := tblMetadata.Columns[val.Column]; !ok {
+	for _, val := range rowUpdate.Changeset {
+		column, ok := tblMetadata.Columns[val.Column]
+		if !ok {
 			msg := "missing column for changeset"
 			s.logger.Debug(msg, "keyspace", keyspaceName, "table", tableName, "error", err)
 			RespondWithError(w, msg, http.StatusBadRequest)
 			return
 		}
+		updatedColumns = append(updatedColumns, val.Column)
+
+		if val.Operation != "" {
+			update, updateErr := collectionUpdate(column, val)
+			if updateErr != nil {
+				s.logger.Debug("invalid collection operation", "keyspace", keyspaceName, "table", tableName,
+					"error", updateErr)
+				RespondWithError(w, updateErr.Error(), http.StatusBadRequest)
+				return
+			}
+			collectionUpdates = append(collectionUpdates, update)
+			continue
+		}
 
-		convertedType, typeErr := types.FromJsonValue(val.Value, tblMetadata.Columns[val.Column].Type)
+		convertedType, typeErr := types.FromJsonValue(val.Value, column.Type)
 		if typeErr != nil {
 			msg := "wrong type provided for column " + val.Column
 			s.logger.Debug(msg, "keyspace", keyspaceName, "table", tableName, "error", err)
@@ -475,27 +612,108 @@ func (s *routeList) UpdateRow(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		columns[i] = val.Column
-		values[i] = convertedType
+		columns = append(columns, val.Column)
+		values = append(values, convertedType)
 	}
 
-	index := len(rowUpdate.Changeset)
-	for i, key := range primaryKeysColumns {
-		columns[index] = key
-		values[index] = primaryKeyValues[i]
-		index++
+	err = s.policy.CheckColumns(user, tblMetadata, auth.PermissionUpdate, updatedColumns)
+	if err != nil {
+		RespondWithError(w, err.Error(), http.StatusForbidden)
+		return
 	}
 
+	columns = append(columns, primaryKeysColumns...)
+	values = append(values, primaryKeyValues...)
+
 	_, err = s.dbClient.Update(&db.UpdateInfo{
+		Keyspace:          keyspaceName,
+		Table:             tblMetadata,
+		Columns:           columns,
+		QueryParams:       values,
+		TTL:               -1,
+		Timestamp:         rowUpdate.Timestamp,
+		CollectionUpdates: collectionUpdates,
+	}, newDbOptions(user))
+	s.audit(r, audit.OperationUpdate, keyspaceName, tableName, primaryKeyMap(tblMetadata, columns, values), err)
+
+	if err != nil {
+		msg := "Unable to execute update query"
+		s.logger.Debug(msg, "keyspace", keyspaceName, "table", tableName, "error", err)
+		RespondWithError(w, msg, http.StatusInternalServerError)
+		return
+	}
+
+	RespondJSONObjectWithCode(w, http.StatusOK, &m.RowsResponse{
+		Success:      true,
+		RowsModified: 1,
+	})
+}
+
+func (s *routeList) IncrementRow(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	keyspaceName := s.params(r, keyspaceParam)
+	tableName := s.params(r, tableParam)
+	rowIdentifier := s.params(r, "rowIdentifier")
+	user := auth.ContextUserOrRole(r.Context())
+
+	tblMetadata, err := s.dbClient.Table(keyspaceName, tableName)
+	if err != nil {
+		if _, ok := err.(*db.DbObjectNotFound); ok {
+			RespondWithError(w, fmt.Sprintf(`Table "%s"."%s" not found`, keyspaceName, tableName), http.StatusNotFound)
+			return
+		}
+
+		msg := "Unable to get table metadata"
+		s.logger.Debug(msg, "keyspace", keyspaceName, "table", tableName, "error", err)
+		RespondWithError(w, msg, http.StatusInternalServerError)
+		return
+	}
+
+	primaryKeysColumns, primaryKeyValues, err := primaryKeyValues(rowIdentifier, tblMetadata)
+	if err != nil {
+		RespondWithError(w, "Invalid primary keys", http.StatusBadRequest)
+		return
+	}
+
+	var rowIncrement m.RowIncrement
+	if err := parseAndValidatePayload(&rowIncrement, r); err != nil {
+		msg := "unable to parse payload"
+		s.logger.Debug(msg, "keyspace", keyspaceName, "table", tableName, "error", err)
+		RespondWithError(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	columns := make([]string, 0, len(rowIncrement.Deltas)+len(primaryKeysColumns))
+	values := make([]interface{}, 0, cap(columns))
+	for _, delta := range rowIncrement.Deltas {
+		column, ok := tblMetadata.Columns[delta.Column]
+		if !ok || column.Type.Type() != gocql.TypeCounter {
+			RespondWithError(w, fmt.Sprintf("column '%s' is not a counter", delta.Column), http.StatusBadRequest)
+			return
+		}
+		columns = append(columns, delta.Column)
+		values = append(values, delta.Value)
+	}
+
+	if err = s.policy.CheckColumns(user, tblMetadata, auth.PermissionUpdate, columns); err != nil {
+		RespondWithError(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	columns = append(columns, primaryKeysColumns...)
+	values = append(values, primaryKeyValues...)
+
+	_, err = s.dbClient.Increment(&db.IncrementInfo{
 		Keyspace:    keyspaceName,
 		Table:       tblMetadata,
 		Columns:     columns,
 		QueryParams: values,
-		TTL:         -1,
 	}, newDbOptions(user))
+	s.audit(r, audit.OperationUpdate, keyspaceName, tableName, primaryKeyMap(tblMetadata, columns, values), err)
 
 	if err != nil {
-		msg := "Unable to execute update query"
+		msg := "Unable to execute increment query"
 		s.logger.Debug(msg, "keyspace", keyspaceName, "table", tableName, "error", err)
 		RespondWithError(w, msg, http.StatusInternalServerError)
 		return
@@ -537,12 +755,44 @@ func (s *routeList) DeleteRow(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The payload is optional
+	var rowDelete m.RowDelete
+	if r.Body != nil {
+		if err := json.NewDecoder(r.Body).Decode(&rowDelete); err != nil && err != io.EOF {
+			msg := "unable to parse payload"
+			s.logger.Debug(msg, "keyspace", keyspaceName, "table", tableName, "error", err)
+			RespondWithError(w, msg, http.StatusBadRequest)
+			return
+		}
+	}
+
+	targets, err := deleteTargets(tblMetadata, rowDelete)
+	if err == nil {
+		err = db.ValidateDeleteTargets(tblMetadata, columns, targets)
+	}
+	if err != nil {
+		RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	targetColumns := make([]string, 0, len(targets))
+	for _, target := range targets {
+		targetColumns = append(targetColumns, target.Column)
+	}
+	if err = s.policy.CheckColumns(user, tblMetadata, auth.PermissionDelete, targetColumns); err != nil {
+		RespondWithError(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
 	_, err = s.dbClient.Delete(&db.DeleteInfo{
 		Keyspace:    keyspaceName,
 		Table:       tableName,
 		Columns:     columns,
 		QueryParams: values,
+		Timestamp:   rowDelete.Timestamp,
+		Targets:     targets,
 	}, newDbOptions(user))
+	s.audit(r, audit.OperationDelete, keyspaceName, tableName, primaryKeyMap(tblMetadata, columns, values), err)
 
 	if err != nil {
 		msg := "unable to execute delete query"
@@ -586,7 +836,15 @@ func (s *routeList) GetTables(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	RespondJSONObjectWithCode(w, http.StatusOK, tables)
+	// Only list the tables granted by the policy
+	result := make([]string, 0, len(tables))
+	for _, table := range tables {
+		if s.policy.IsAllowed(user, keyspaceName, table, auth.AllPermissions) {
+			result = append(result, table)
+		}
+	}
+
+	RespondJSONObjectWithCode(w, http.StatusOK, result)
 }
 
 func (s *routeList) GetTable(w http.ResponseWriter, r *http.Request) {
@@ -628,6 +886,11 @@ func (s *routeList) AddTable(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := s.policy.CheckTable(user, keyspaceName, tableAdd.Name, auth.PermissionSchema); err != nil {
+		RespondWithError(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
 	tableInfo := db.CreateTableInfo{
 		Keyspace:    keyspaceName,
 		Table:       tableAdd.Name,
@@ -660,6 +923,7 @@ func (s *routeList) AddTable(w http.ResponseWriter, r *http.Request) {
 	}
 
 	err := s.dbClient.CreateTable(&tableInfo, newDbOptions(user))
+	s.audit(r, audit.OperationCreateTable, keyspaceName, tableAdd.Name, nil, err)
 	if err != nil {
 		msg := "unable to execute create table query"
 		s.logger.Debug(msg, "keyspace", keyspaceName, "error", err)
@@ -681,6 +945,7 @@ func (s *routeList) DeleteTable(w http.ResponseWriter, r *http.Request) {
 		Keyspace: keyspaceName,
 		Table:    tableName,
 	}, newDbOptions(user))
+	s.audit(r, audit.OperationDropTable, keyspaceName, tableName, nil, err)
 
 	if err != nil {
 		msg := "unable to execute drop table query"
@@ -727,7 +992,7 @@ func (s *routeList) GetKeyspaces(w http.ResponseWriter, r *http.Request) {
 		// Filter out excluded keyspaces
 		result = make([]string, 0, len(keyspaces))
 		for _, ks := range keyspaces {
-			if s.excludedKeyspaces[ks] {
+			if s.excludedKeyspaces[ks] || !s.policy.IsKeyspaceVisible(user, ks) {
 				continue
 			}
 			result = append(result, ks)
@@ -783,9 +1048,14 @@ func columnMetadataToColumnDefinition(columns map[string]*gocql.ColumnMetadata)
 			isStatic = true
 		}
 
+		typeDefinition := col.Type.Type().String()
+		if vectorType, ok := col.Type.(types.VectorType); ok {
+			typeDefinition = vectorType.String()
+		}
+
 		columnDefinitions = append(columnDefinitions, m.ColumnDefinition{
 			Name:           col.Name,
-			TypeDefinition: col.Type.Type().String(),
+			TypeDefinition: typeDefinition,
 			Static:         isStatic,
 		})
 	}
@@ -804,6 +1074,72 @@ func parseAndValidatePayload(obj interface{}, r *http.Request) error {
 	return nil
 }
 
+// filterConditions converts the filters of a query into the conditions of the where clause
+func filterConditions(table *gocql.TableMetadata, filters []m.Filter) ([]types.ConditionItem, error) {
+	where := make([]types.ConditionItem, len(filters))
+	for i, filter := range filters {
+		operator, found := types.CqlOperators[filter.Operator]
+		if !found {
+			return nil, fmt.Errorf("operator '%s' not found", filter.Operator)
+		}
+
+		if operator == "LIKE" {
+			column, ok := table.Columns[filter.ColumnName]
+			if !ok {
+				return nil, fmt.Errorf("column '%s' not found", filter.ColumnName)
+			}
+			if !db.SupportsLikeOperator(column) {
+				return nil, fmt.Errorf("operator '%s' is not supported for column '%s'", filter.Operator, filter.ColumnName)
+			}
+		}
+
+		value, err := filterValue(filter, operator)
+		if err != nil {
+			return nil, err
+		}
+
+		where[i] = types.ConditionItem{
+			Column:   filter.ColumnName,
+			Operator: operator,
+			Value:    value,
+		}
+	}
+	return where, nil
+}
+
+// filterValue returns the value of a filter: the list of values for "in", the key and value of the entry for
+// "containsEntry" and the single value provided for the rest of the operators
+func filterValue(filter m.Filter, operator string) (interface{}, error) {
+	switch operator {
+	case "IN":
+		return filter.Value, nil
+	case types.ContainsEntryOperator:
+		if len(filter.Value) != 2 {
+			return nil, fmt.Errorf("operator '%s' requires the key and the value of the entry", filter.Operator)
+		}
+		return types.MapEntry{Key: filter.Value[0], Value: filter.Value[1]}, nil
+	default:
+		if len(filter.Value) != 1 {
+			return nil, fmt.Errorf("operator '%s' requires a single value", filter.Operator)
+		}
+		return filter.Value[0], nil
+	}
+}
+
+// checkCellMetadataColumns returns an error when the write time and ttl of any of the columns can not be selected
+func checkCellMetadataColumns(tblMetadata *gocql.TableMetadata, columns []string) error {
+	for _, columnName := range columns {
+		column, ok := tblMetadata.Columns[columnName]
+		if !ok {
+			return fmt.Errorf("column '%s' not found", columnName)
+		}
+		if !db.SupportsCellMetadata(column) {
+			return fmt.Errorf("write time and ttl are not supported for column '%s'", columnName)
+		}
+	}
+	return nil
+}
+
 func (s *routeList) primaryKeyToString(m []types.ConditionItem) string {
 	jsonString, err := json.Marshal(m)
 	if err != nil {
@@ -0,0 +1,81 @@
+package endpoint
+
+import (
+	"encoding/json"
+	"github.com/datastax/cassandra-data-apis/config"
+	"github.com/datastax/cassandra-data-apis/db"
+	m "github.com/datastax/cassandra-data-apis/rest/models"
+	"github.com/datastax/cassandra-data-apis/types"
+	"github.com/gocql/gocql"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFilterConditions_Like(t *testing.T) {
+	table := &gocql.TableMetadata{
+		Name: "books",
+		Columns: map[string]*gocql.ColumnMetadata{
+			"title": {Name: "title", Type: gocql.NewNativeType(0, gocql.TypeText, "")},
+			"code":  {Name: "code", Type: gocql.NewNativeType(0, gocql.TypeAscii, "")},
+			"pages": {Name: "pages", Type: gocql.NewNativeType(0, gocql.TypeInt, "")},
+		},
+	}
+
+	where, err := filterConditions(table, []m.Filter{
+		{ColumnName: "title", Operator: "like", Value: []interface{}{"Moby%"}},
+		{ColumnName: "code", Operator: "like", Value: []interface{}{"A%"}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []types.ConditionItem{
+		{Column: "title", Operator: "LIKE", Value: "Moby%"},
+		{Column: "code", Operator: "LIKE", Value: "A%"},
+	}, where)
+
+	_, err = filterConditions(table, []m.Filter{{ColumnName: "pages", Operator: "like", Value: []interface{}{"1%"}}})
+	assert.EqualError(t, err, "operator 'like' is not supported for column 'pages'")
+
+	_, err = filterConditions(table, []m.Filter{{ColumnName: "author", Operator: "like", Value: []interface{}{"H%"}}})
+	assert.EqualError(t, err, "column 'author' not found")
+}
+
+func TestQuery_AggregateAllRows(t *testing.T) {
+	session := db.NewSessionMock().Default()
+	session.On("TokenRanges").Return([]db.TokenRange{{End: int64(0)}, {Start: int64(0)}}, nil)
+	for _, item := range []struct {
+		condition string
+		count     string
+		max       int
+	}{{`token("title") <= ?`, "3", 120}, {`token("title") > ?`, "4", 80}} {
+		count, max := item.count, item.max
+		result := &db.ResultMock{}
+		result.On("Values").Return([]map[string]interface{}{{"partial0": &count, "partial1": &max}}, nil)
+		session.On("ExecuteIter",
+			`SELECT COUNT(*) AS "partial0", MAX("pages") AS "partial1" FROM "store"."books" WHERE `+item.condition,
+			mock.Anything, mock.Anything).
+			Return(result, nil)
+	}
+
+	cfg := config.NewConfigMock().Default()
+	cfg.On("RouterInfo").Return(config.DefaultRouterInfo())
+	router := httprouter.New()
+	for _, route := range Routes("/rest", config.AllSchemaOperations, "", cfg, db.NewDbWithSession(session)) {
+		router.Handler(route.Method, route.Pattern, route.Handler)
+	}
+
+	body := `{"filters": [], "aggregation": {"functions": [{"name": "count"}, {"name": "max", "columnName": "pages"}]}}`
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/rest/v1/keyspaces/store/tables/books/rows/query",
+		strings.NewReader(body)))
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	var rows m.Rows
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&rows))
+	assert.Equal(t, []map[string]interface{}{{"count": "7", "max_pages": float64(120)}}, rows.Rows)
+	assert.Equal(t, 1, rows.Count)
+}
@@ -0,0 +1,42 @@
+package endpoint
+
+import (
+	"github.com/datastax/cassandra-data-apis/audit"
+	"github.com/gocql/gocql"
+	"net/http"
+)
+
+// audit records the result of a mutation or schema change
+func (s *routeList) audit(
+	r *http.Request,
+	operation string,
+	keyspace string,
+	table string,
+	primaryKey map[string]interface{},
+	err error,
+) {
+	event := audit.Event{
+		Source:     audit.SourceRest,
+		Operation:  operation,
+		Keyspace:   keyspace,
+		Table:      table,
+		PrimaryKey: primaryKey,
+		Applied:    err == nil,
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	s.auditor.Log(r.Context(), event)
+}
+
+// primaryKeyMap gets the values of the partition and clustering key columns
+func primaryKeyMap(tblMetadata *gocql.TableMetadata, columns []string, values []interface{}) map[string]interface{} {
+	result := make(map[string]interface{})
+	for i, name := range columns {
+		column, found := tblMetadata.Columns[name]
+		if found && (column.Kind == gocql.ColumnPartitionKey || column.Kind == gocql.ColumnClusteringKey) {
+			result[name] = values[i]
+		}
+	}
+	return result
+}
@@ -0,0 +1,82 @@
+package endpoint
+
+import (
+	"github.com/datastax/cassandra-data-apis/auth"
+	"github.com/datastax/cassandra-data-apis/config"
+	"github.com/datastax/cassandra-data-apis/db"
+	"github.com/datastax/cassandra-data-apis/log"
+	m "github.com/datastax/cassandra-data-apis/rest/models"
+	"github.com/datastax/cassandra-data-apis/types"
+	"net/http"
+	"path"
+)
+
+const AuthPathFormat = "v1/auth"
+
+// CredentialsValidator validates a username and password, e.g. against the cluster. It returns a
+// *db.InvalidCredentials error when the credentials are rejected.
+type CredentialsValidator interface {
+	ValidateCredentials(username string, password string) error
+}
+
+type authRoute struct {
+	logger    log.Logger
+	validator CredentialsValidator
+	tokens    *auth.TokenStore
+}
+
+// AuthRoutes returns the route that issues auth tokens for valid credentials
+func AuthRoutes(
+	prefix string,
+	cfg config.Config,
+	validator CredentialsValidator,
+	tokens *auth.TokenStore,
+) []types.Route {
+	route := authRoute{
+		logger:    cfg.Logger(),
+		validator: validator,
+		tokens:    tokens,
+	}
+
+	return []types.Route{
+		{
+			Method:  http.MethodPost,
+			Pattern: path.Join(prefix, AuthPathFormat),
+			Handler: http.HandlerFunc(route.CreateToken),
+		},
+	}
+}
+
+func (s *authRoute) CreateToken(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	var credentials m.AuthTokenRequest
+	if err := parseAndValidatePayload(&credentials, r); err != nil {
+		RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.validator.ValidateCredentials(credentials.Username, credentials.Password); err != nil {
+		if _, ok := err.(*db.InvalidCredentials); ok {
+			s.logger.Debug("invalid credentials", "username", credentials.Username, "error", err)
+			RespondWithError(w, "invalid credentials", http.StatusUnauthorized)
+			return
+		}
+
+		// Other errors, e.g. an unreachable cluster, are not caused by the credentials
+		msg := "unable to validate credentials"
+		s.logger.Error(msg, "username", credentials.Username, "error", err)
+		RespondWithError(w, msg, http.StatusServiceUnavailable)
+		return
+	}
+
+	token, err := s.tokens.Create(credentials.Username)
+	if err != nil {
+		msg := "unable to create token"
+		s.logger.Error(msg, "error", err)
+		RespondWithError(w, msg, http.StatusInternalServerError)
+		return
+	}
+
+	RespondJSONObjectWithCode(w, http.StatusCreated, m.AuthTokenResponse{AuthToken: token})
+}
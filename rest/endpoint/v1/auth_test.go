@@ -0,0 +1,57 @@
+package endpoint
+
+import (
+	"errors"
+	"github.com/datastax/cassandra-data-apis/auth"
+	"github.com/datastax/cassandra-data-apis/config"
+	"github.com/datastax/cassandra-data-apis/db"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type validatorMock func(username string, password string) error
+
+func (v validatorMock) ValidateCredentials(username string, password string) error {
+	return v(username, password)
+}
+
+func TestAuthRoute_CreateToken(t *testing.T) {
+	validator := validatorMock(func(username string, password string) error {
+		switch password {
+		case "secret":
+			return nil
+		case "unreachable":
+			return errors.New("gocql: unable to create session: no connections were made")
+		}
+		return &db.InvalidCredentials{}
+	})
+
+	tokens := auth.NewTokenStore(time.Minute)
+	routes := AuthRoutes("/rest", config.NewConfigMock().Default(), validator, tokens)
+	assert.Len(t, routes, 1)
+
+	execute := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		routes[0].Handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/rest/v1/auth", strings.NewReader(body)))
+		return w
+	}
+
+	w := execute(`{"username": "user1", "password": "secret"}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"authToken":"`)
+
+	w = execute(`{"username": "user1"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = execute(`{"username": "user1", "password": "wrong"}`)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid credentials")
+
+	w = execute(`{"username": "user1", "password": "unreachable"}`)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.NotContains(t, w.Body.String(), "invalid credentials")
+}
@@ -0,0 +1,518 @@
+package endpoint
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/datastax/cassandra-data-apis/audit"
+	"github.com/datastax/cassandra-data-apis/auth"
+	"github.com/datastax/cassandra-data-apis/db"
+	m "github.com/datastax/cassandra-data-apis/rest/models"
+	"github.com/datastax/cassandra-data-apis/types"
+	"github.com/gocql/gocql"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+const (
+	// bulkConcurrency is the maximum number of insert requests in flight for a bulk request
+	bulkConcurrency = 16
+	// bulkMaxBatchSize is the maximum number of rows of the same partition inserted in a single batch
+	bulkMaxBatchSize = 20
+	// bulkMaxBufferedRows is the maximum number of rows buffered to group them by partition
+	bulkMaxBufferedRows = 1000
+	// bulkMaxErrors is the maximum number of row errors included in the response
+	bulkMaxErrors = 100
+	// bulkMaxLineSize is the maximum size of a NDJSON line
+	bulkMaxLineSize = 4 * 1024 * 1024
+	// bulkProtoVersion is the protocol version used to serialize the partition key values to group the rows
+	bulkProtoVersion = 4
+)
+
+const (
+	mediaTypeNdjson = "application/x-ndjson"
+	mediaTypeCsv    = "text/csv"
+)
+
+type bulkRow struct {
+	index        int
+	columns      []string
+	values       []interface{}
+	partitionKey string
+}
+
+// bulkRowError is returned by the readers when a row is invalid, the following rows can still be read
+type bulkRowError struct {
+	index int
+	err   error
+}
+
+func (e *bulkRowError) Error() string {
+	return e.err.Error()
+}
+
+// bulkRowReader reads the rows of a request body one at a time
+type bulkRowReader interface {
+	// next returns the next row, io.EOF when there are no more rows or a *bulkRowError when the row is invalid
+	next() (*bulkRow, error)
+}
+
+type bulkResult struct {
+	mutex    sync.Mutex
+	inserted int
+	failed   int
+	errors   []m.BulkRowError
+}
+
+// AddRows inserts the rows of a NDJSON or CSV request body, streaming the body and inserting the rows concurrently
+func (s *routeList) AddRows(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	keyspaceName := s.params(r, keyspaceParam)
+	tableName := s.params(r, tableParam)
+	user := auth.ContextUserOrRole(r.Context())
+
+	tblMetadata, err := s.dbClient.Table(keyspaceName, tableName)
+	if err != nil {
+		if _, ok := err.(*db.DbObjectNotFound); ok {
+			RespondWithError(w, fmt.Sprintf(`Table "%s"."%s" not found`, keyspaceName, tableName), http.StatusNotFound)
+			return
+		}
+
+		msg := "unable to get table metadata"
+		s.logger.Debug(msg, "keyspace", keyspaceName, "table", tableName, "error", err)
+		RespondWithError(w, msg, http.StatusInternalServerError)
+		return
+	}
+
+	if r.Body == nil {
+		RespondWithError(w, "no request body", http.StatusBadRequest)
+		return
+	}
+
+	var reader bulkRowReader
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case mediaTypeCsv:
+		if reader, err = newCsvRowReader(r.Body, tblMetadata); err != nil {
+			RespondWithError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	case "", mediaTypeNdjson, "application/json":
+		reader = newNdjsonRowReader(r.Body, tblMetadata)
+	default:
+		RespondWithError(w, fmt.Sprintf("unsupported content type, use %s or %s", mediaTypeNdjson, mediaTypeCsv),
+			http.StatusUnsupportedMediaType)
+		return
+	}
+
+	result := &bulkResult{}
+	groups := make(chan []*bulkRow, bulkConcurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < bulkConcurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for group := range groups {
+				s.insertRows(r, tblMetadata, user, group, result)
+			}
+		}()
+	}
+
+	// The rows are grouped by partition within a window of buffered rows
+	grouper := newBulkGrouper(func(group []*bulkRow) { groups <- group })
+	index := 0
+	for {
+		row, err := reader.next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			if rowErr, ok := err.(*bulkRowError); ok {
+				index = rowErr.index
+				result.fail(rowErr.index, rowErr.err)
+				continue
+			}
+
+			s.logger.Debug("unable to read bulk request body", "keyspace", keyspaceName, "table", tableName,
+				"error", err)
+			result.fail(index+1, fmt.Errorf("unable to read request body: %s", err))
+			break
+		}
+
+		index = row.index
+		if err := s.policy.CheckColumns(user, tblMetadata, auth.PermissionInsert, row.columns); err != nil {
+			result.fail(row.index, err)
+			continue
+		}
+
+		grouper.add(row)
+	}
+
+	grouper.flushAll()
+	close(groups)
+	wg.Wait()
+
+	RespondJSONObjectWithCode(w, http.StatusOK, result.response())
+}
+
+// bulkGrouper groups the rows of the same partition, a group is flushed once it reaches bulkMaxBatchSize rows and
+// all the groups are flushed once bulkMaxBufferedRows rows are buffered
+type bulkGrouper struct {
+	groups   map[string][]*bulkRow
+	keys     []string
+	buffered int
+	flush    func([]*bulkRow)
+}
+
+func newBulkGrouper(flush func([]*bulkRow)) *bulkGrouper {
+	return &bulkGrouper{groups: make(map[string][]*bulkRow), flush: flush}
+}
+
+func (g *bulkGrouper) add(row *bulkRow) {
+	group, ok := g.groups[row.partitionKey]
+	if !ok {
+		g.keys = append(g.keys, row.partitionKey)
+	}
+
+	group = append(group, row)
+	g.buffered++
+	if len(group) == bulkMaxBatchSize {
+		g.flush(group)
+		g.buffered -= len(group)
+		group = nil
+	}
+	g.groups[row.partitionKey] = group
+
+	if g.buffered >= bulkMaxBufferedRows {
+		g.flushAll()
+	}
+}
+
+// flushAll flushes the buffered groups in the order the partitions were first read
+func (g *bulkGrouper) flushAll() {
+	for _, key := range g.keys {
+		if group := g.groups[key]; len(group) > 0 {
+			g.flush(group)
+		}
+	}
+	g.groups = make(map[string][]*bulkRow)
+	g.keys = nil
+	g.buffered = 0
+}
+
+// insertRows inserts a group of rows of the same partition, using an unlogged batch for multiple rows
+func (s *routeList) insertRows(
+	r *http.Request,
+	table *gocql.TableMetadata,
+	user string,
+	rows []*bulkRow,
+	result *bulkResult,
+) {
+	var err error
+	if len(rows) == 1 {
+		_, err = s.dbClient.Insert(bulkInsertInfo(table, rows[0]), newDbOptions(user))
+	} else {
+		statements := make([]db.Statement, 0, len(rows))
+		for _, row := range rows {
+			statements = append(statements, db.InsertStatement(bulkInsertInfo(table, row)))
+		}
+		err = s.dbClient.ExecuteBatch(gocql.UnloggedBatch, statements, newDbOptions(user))
+	}
+
+	if err != nil {
+		s.logger.Debug("unable to execute bulk insert", "keyspace", table.Keyspace, "table", table.Name,
+			"error", err)
+	}
+
+	for _, row := range rows {
+		s.audit(r, audit.OperationInsert, table.Keyspace, table.Name,
+			primaryKeyMap(table, row.columns, row.values), err)
+		if err != nil {
+			result.fail(row.index, fmt.Errorf("unable to execute insert query: %s", err))
+		} else {
+			result.succeed()
+		}
+	}
+}
+
+func bulkInsertInfo(table *gocql.TableMetadata, row *bulkRow) *db.InsertInfo {
+	queryParams := make([]interface{}, len(row.values))
+	copy(queryParams, row.values)
+	return &db.InsertInfo{
+		Keyspace:    table.Keyspace,
+		Table:       table.Name,
+		Columns:     row.columns,
+		QueryParams: queryParams,
+		TTL:         0,
+	}
+}
+
+func newBulkRow(table *gocql.TableMetadata, index int, columns []string, values []interface{}) (*bulkRow, error) {
+	if len(columns) == 0 {
+		return nil, &bulkRowError{index, errors.New("columns can not be empty")}
+	}
+
+	for _, column := range append(append([]*gocql.ColumnMetadata{}, table.PartitionKey...), table.ClusteringColumns...) {
+		if !hasValue(column.Name, columns, values) {
+			return nil, &bulkRowError{index, fmt.Errorf("missing primary key column '%s'", column.Name)}
+		}
+	}
+
+	// The partition key is built from the serialized values, as the formatted values of pointer types like
+	// *inf.Dec or *big.Int contain their addresses
+	var key bytes.Buffer
+	for _, column := range table.PartitionKey {
+		size := int32(-1)
+		var data []byte
+		for j, name := range columns {
+			if name == column.Name && values[j] != nil {
+				var err error
+				if data, err = gocql.Marshal(versionedType(column.Type), values[j]); err != nil {
+					return nil, &bulkRowError{index, fmt.Errorf("invalid value for column '%s': %s", name, err)}
+				}
+				size = int32(len(data))
+			}
+		}
+		// Prefixed by the length, a length of -1 represents null
+		_ = binary.Write(&key, binary.BigEndian, size)
+		key.Write(data)
+	}
+
+	return &bulkRow{
+		index:        index,
+		columns:      columns,
+		values:       values,
+		partitionKey: key.String(),
+	}, nil
+}
+
+// hasValue determines whether the row contains a non-null value for the column
+func hasValue(name string, columns []string, values []interface{}) bool {
+	for i, column := range columns {
+		if column == name {
+			return values[i] != nil
+		}
+	}
+	return false
+}
+
+// versionedType returns a copy of the type with the protocol version set, which is required to serialize values, as
+// the types of the schema metadata don't have one
+func versionedType(info gocql.TypeInfo) gocql.TypeInfo {
+	native := func(t gocql.NativeType) gocql.NativeType {
+		return gocql.NewNativeType(bulkProtoVersion, t.Type(), t.Custom())
+	}
+
+	switch t := info.(type) {
+	case gocql.NativeType:
+		return native(t)
+	case gocql.CollectionType:
+		return gocql.CollectionType{NativeType: native(t.NativeType), Key: versionedType(t.Key), Elem: versionedType(t.Elem)}
+	case gocql.TupleTypeInfo:
+		elems := make([]gocql.TypeInfo, len(t.Elems))
+		for i, elem := range t.Elems {
+			elems[i] = versionedType(elem)
+		}
+		return gocql.TupleTypeInfo{NativeType: native(t.NativeType), Elems: elems}
+	case gocql.UDTTypeInfo:
+		elements := make([]gocql.UDTField, len(t.Elements))
+		for i, field := range t.Elements {
+			elements[i] = gocql.UDTField{Name: field.Name, Type: versionedType(field.Type)}
+		}
+		return gocql.UDTTypeInfo{NativeType: native(t.NativeType), KeySpace: t.KeySpace, Name: t.Name, Elements: elements}
+	}
+	return info
+}
+
+func (r *bulkResult) succeed() {
+	r.mutex.Lock()
+	r.inserted++
+	r.mutex.Unlock()
+}
+
+func (r *bulkResult) fail(index int, err error) {
+	r.mutex.Lock()
+	r.failed++
+	if len(r.errors) < bulkMaxErrors {
+		r.errors = append(r.errors, m.BulkRowError{Row: index, Description: err.Error()})
+	}
+	r.mutex.Unlock()
+}
+
+func (r *bulkResult) response() *m.BulkRowsResponse {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	sort.Slice(r.errors, func(i, j int) bool {
+		return r.errors[i].Row < r.errors[j].Row
+	})
+	return &m.BulkRowsResponse{
+		RowsInserted: r.inserted,
+		RowsFailed:   r.failed,
+		Errors:       r.errors,
+	}
+}
+
+// ndjsonRowReader reads rows represented as JSON objects, one per line
+type ndjsonRowReader struct {
+	scanner *bufio.Scanner
+	table   *gocql.TableMetadata
+	index   int
+}
+
+func newNdjsonRowReader(body io.Reader, table *gocql.TableMetadata) *ndjsonRowReader {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), bulkMaxLineSize)
+	return &ndjsonRowReader{scanner: scanner, table: table}
+}
+
+func (r *ndjsonRowReader) next() (*bulkRow, error) {
+	for r.scanner.Scan() {
+		line := bytes.TrimSpace(r.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		r.index++
+		var row map[string]interface{}
+		if err := json.Unmarshal(line, &row); err != nil {
+			return nil, &bulkRowError{r.index, fmt.Errorf("invalid JSON object: %s", err)}
+		}
+
+		columns := make([]string, 0, len(row))
+		values := make([]interface{}, 0, len(row))
+		for name, value := range row {
+			column, ok := r.table.Columns[name]
+			if !ok {
+				return nil, &bulkRowError{r.index, fmt.Errorf("column '%s' not found in table", name)}
+			}
+
+			if value != nil {
+				var err error
+				if value, err = types.FromJsonValue(value, column.Type); err != nil {
+					return nil, &bulkRowError{r.index, fmt.Errorf("wrong type provided for column '%s'", name)}
+				}
+			}
+
+			columns = append(columns, name)
+			values = append(values, value)
+		}
+
+		return newBulkRow(r.table, r.index, columns, values)
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return nil, io.EOF
+}
+
+// csvRowReader reads rows from a CSV body, the first record contains the column names
+type csvRowReader struct {
+	reader  *csv.Reader
+	table   *gocql.TableMetadata
+	columns []*gocql.ColumnMetadata
+	index   int
+}
+
+func newCsvRowReader(body io.Reader, table *gocql.TableMetadata) (*csvRowReader, error) {
+	reader := csv.NewReader(body)
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("CSV header not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV header: %s", err)
+	}
+
+	columns := make([]*gocql.ColumnMetadata, len(header))
+	for i, name := range header {
+		column, ok := table.Columns[name]
+		if !ok {
+			return nil, fmt.Errorf("column '%s' not found in table", name)
+		}
+		columns[i] = column
+	}
+
+	return &csvRowReader{reader: reader, table: table, columns: columns}, nil
+}
+
+func (r *csvRowReader) next() (*bulkRow, error) {
+	record, err := r.reader.Read()
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+
+	r.index++
+	if err != nil {
+		if _, ok := err.(*csv.ParseError); ok {
+			return nil, &bulkRowError{r.index, err}
+		}
+		return nil, err
+	}
+
+	columns := make([]string, 0, len(record))
+	values := make([]interface{}, 0, len(record))
+	for i, field := range record {
+		column := r.columns[i]
+		if field == "" && !isTextType(column.Type) {
+			// Empty fields of non-text columns are not inserted, text columns are set to an empty string
+			continue
+		}
+
+		value, err := csvValue(field, column.Type)
+		if err != nil {
+			return nil, &bulkRowError{r.index, fmt.Errorf("wrong type provided for column '%s'", column.Name)}
+		}
+
+		columns = append(columns, column.Name)
+		values = append(values, value)
+	}
+
+	return newBulkRow(r.table, r.index, columns, values)
+}
+
+func isTextType(typeInfo gocql.TypeInfo) bool {
+	switch typeInfo.Type() {
+	case gocql.TypeText, gocql.TypeVarchar, gocql.TypeAscii:
+		return true
+	}
+	return false
+}
+
+// csvValue converts the CSV field into the value expected for the column type, using JSON for collections
+func csvValue(field string, typeInfo gocql.TypeInfo) (interface{}, error) {
+	var value interface{}
+	var err error
+
+	switch typeInfo.Type() {
+	case gocql.TypeInt, gocql.TypeTinyInt, gocql.TypeSmallInt, gocql.TypeFloat, gocql.TypeDouble:
+		value, err = strconv.ParseFloat(field, 64)
+	case gocql.TypeBigInt, gocql.TypeCounter:
+		value, err = strconv.ParseInt(field, 10, 64)
+	case gocql.TypeBoolean:
+		value, err = strconv.ParseBool(field)
+	case gocql.TypeList, gocql.TypeSet, gocql.TypeMap, gocql.TypeUDT, gocql.TypeTuple:
+		err = json.Unmarshal([]byte(field), &value)
+	default:
+		value = field
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return types.FromJsonValue(value, typeInfo)
+}
@@ -0,0 +1,134 @@
+package endpoint
+
+import (
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/inf.v0"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestNewBulkRow_PartitionKey(t *testing.T) {
+	table := &gocql.TableMetadata{
+		Keyspace: "store",
+		Name:     "prices",
+		PartitionKey: []*gocql.ColumnMetadata{
+			{Name: "amount", Kind: gocql.ColumnPartitionKey, Type: gocql.NewNativeType(0, gocql.TypeDecimal, "")},
+			{Name: "units", Kind: gocql.ColumnPartitionKey, Type: gocql.NewNativeType(0, gocql.TypeVarint, "")},
+		},
+	}
+	columns := []string{"amount", "units", "name"}
+
+	newRow := func(amount string, units int64, name string) *bulkRow {
+		value, ok := new(inf.Dec).SetString(amount)
+		require.True(t, ok)
+		row, err := newBulkRow(table, 0, columns, []interface{}{value, big.NewInt(units), name})
+		require.NoError(t, err)
+		return row
+	}
+
+	// Equal values using different pointers belong to the same partition
+	assert.Equal(t, newRow("1.5", 10, "a").partitionKey, newRow("1.5", 10, "b").partitionKey)
+	assert.NotEqual(t, newRow("1.5", 10, "a").partitionKey, newRow("1.5", 11, "a").partitionKey)
+	assert.NotEqual(t, newRow("1.5", 10, "a").partitionKey, newRow("2.5", 10, "a").partitionKey)
+
+	_, err := newBulkRow(table, 0, columns, []interface{}{"invalid", big.NewInt(1), "a"})
+	assert.Error(t, err)
+}
+
+func TestVersionedType(t *testing.T) {
+	info := versionedType(gocql.CollectionType{
+		NativeType: gocql.NewNativeType(0, gocql.TypeMap, ""),
+		Key:        gocql.NewNativeType(0, gocql.TypeText, ""),
+		Elem: gocql.TupleTypeInfo{
+			NativeType: gocql.NewNativeType(0, gocql.TypeTuple, ""),
+			Elems:      []gocql.TypeInfo{gocql.NewNativeType(0, gocql.TypeInt, "")},
+		},
+	})
+
+	data, err := gocql.Marshal(info, map[string][]interface{}{"a": {1}})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, data)
+}
+
+func TestNewBulkRow_MissingPrimaryKey(t *testing.T) {
+	table := &gocql.TableMetadata{
+		PartitionKey:      []*gocql.ColumnMetadata{{Name: "id", Type: gocql.NewNativeType(0, gocql.TypeInt, "")}},
+		ClusteringColumns: []*gocql.ColumnMetadata{{Name: "ts", Type: gocql.NewNativeType(0, gocql.TypeInt, "")}},
+	}
+
+	_, err := newBulkRow(table, 3, []string{"id", "name"}, []interface{}{1, "a"})
+	assert.EqualError(t, err, "missing primary key column 'ts'")
+	assert.Equal(t, 3, err.(*bulkRowError).index)
+
+	_, err = newBulkRow(table, 3, []string{"id", "ts"}, []interface{}{nil, 1})
+	assert.EqualError(t, err, "missing primary key column 'id'")
+}
+
+func TestCsvRowReader_EmptyFields(t *testing.T) {
+	table := &gocql.TableMetadata{
+		PartitionKey: []*gocql.ColumnMetadata{{Name: "id", Type: gocql.NewNativeType(0, gocql.TypeInt, "")}},
+		Columns: map[string]*gocql.ColumnMetadata{
+			"id":    {Name: "id", Type: gocql.NewNativeType(0, gocql.TypeInt, "")},
+			"title": {Name: "title", Type: gocql.NewNativeType(0, gocql.TypeText, "")},
+			"pages": {Name: "pages", Type: gocql.NewNativeType(0, gocql.TypeInt, "")},
+		},
+	}
+	table.PartitionKey[0] = table.Columns["id"]
+
+	reader, err := newCsvRowReader(strings.NewReader("id,title,pages\n1,,\n,a,2\n"), table)
+	require.NoError(t, err)
+
+	// Empty text fields are empty strings, the empty fields of the rest of the columns are not inserted
+	row, err := reader.next()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"id", "title"}, row.columns)
+	assert.Equal(t, "", row.values[1])
+
+	_, err = reader.next()
+	assert.EqualError(t, err, "missing primary key column 'id'")
+
+	_, err = reader.next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestBulkGrouper(t *testing.T) {
+	var flushed [][]int
+	grouper := newBulkGrouper(func(group []*bulkRow) {
+		indexes := make([]int, len(group))
+		for i, row := range group {
+			indexes[i] = row.index
+		}
+		flushed = append(flushed, indexes)
+	})
+
+	// Rows of the same partition are grouped when they're not consecutive
+	for i, key := range []string{"a", "b", "a", "c", "b", "a"} {
+		grouper.add(&bulkRow{index: i, partitionKey: key})
+	}
+	assert.Empty(t, flushed)
+	grouper.flushAll()
+	assert.Equal(t, [][]int{{0, 2, 5}, {1, 4}, {3}}, flushed)
+
+	// Groups are flushed once they reach the maximum batch size
+	flushed = nil
+	for i := 0; i < bulkMaxBatchSize+1; i++ {
+		grouper.add(&bulkRow{index: i, partitionKey: "a"})
+	}
+	assert.Len(t, flushed, 1)
+	assert.Len(t, flushed[0], bulkMaxBatchSize)
+
+	// All the groups are flushed once the maximum number of rows is buffered
+	grouper.flushAll()
+	flushed = nil
+	for i := 0; i < bulkMaxBufferedRows; i++ {
+		grouper.add(&bulkRow{index: i, partitionKey: strconv.Itoa(i)})
+	}
+	assert.Len(t, flushed, bulkMaxBufferedRows)
+	grouper.flushAll()
+	assert.Len(t, flushed, bulkMaxBufferedRows)
+}
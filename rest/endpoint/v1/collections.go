@@ -0,0 +1,110 @@
+package endpoint
+
+import (
+	"fmt"
+	"github.com/datastax/cassandra-data-apis/db"
+	m "github.com/datastax/cassandra-data-apis/rest/models"
+	"github.com/datastax/cassandra-data-apis/types"
+	"github.com/gocql/gocql"
+)
+
+// collectionOperators contains the collection operator by changeset operation name
+var collectionOperators = map[string]db.CollectionOperator{
+	"append":  db.CollectionAppend,
+	"prepend": db.CollectionPrepend,
+	"remove":  db.CollectionRemove,
+	"put":     db.CollectionPut,
+}
+
+var listIndexType = gocql.NewNativeType(0, gocql.TypeInt, "")
+
+// collectionUpdate validates a changeset that modifies the elements of a collection column and converts its values
+// using the types of the elements
+func collectionUpdate(column *gocql.ColumnMetadata, changeset m.Changeset) (db.CollectionUpdate, error) {
+	operator, ok := collectionOperators[changeset.Operation]
+	if !ok {
+		return db.CollectionUpdate{}, fmt.Errorf("operation '%s' not supported", changeset.Operation)
+	}
+
+	collectionType, ok := column.Type.(gocql.CollectionType)
+	if !ok || !db.SupportsCollectionOperator(column, operator) {
+		return db.CollectionUpdate{}, fmt.Errorf(
+			"operation '%s' not supported for column '%s'", changeset.Operation, column.Name)
+	}
+
+	update := db.CollectionUpdate{Column: column.Name, Operator: operator}
+	var err error
+	switch {
+	case operator == db.CollectionPut:
+		if changeset.Key == nil {
+			return db.CollectionUpdate{}, fmt.Errorf("operation 'put' requires a key for column '%s'", column.Name)
+		}
+		keyType := collectionType.Key
+		if collectionType.Type() == gocql.TypeList {
+			keyType = listIndexType
+		}
+		if update.Key, err = types.FromJsonValue(changeset.Key, keyType); err == nil {
+			update.Value, err = types.FromJsonValue(changeset.Value, collectionType.Elem)
+		}
+	case collectionType.Type() == gocql.TypeMap:
+		// Removing from a map uses the keys
+		update.Value, err = fromJsonElements(changeset.Value, collectionType.Key)
+	default:
+		update.Value, err = fromJsonElements(changeset.Value, collectionType.Elem)
+	}
+
+	if err != nil {
+		return db.CollectionUpdate{}, fmt.Errorf("wrong type provided for column %s", column.Name)
+	}
+	return update, nil
+}
+
+// fromJsonElements converts each element of a json array using the provided type
+func fromJsonElements(value interface{}, typeInfo gocql.TypeInfo) (interface{}, error) {
+	elements, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected an array of elements, got %T", value)
+	}
+
+	result := make([]interface{}, 0, len(elements))
+	for _, element := range elements {
+		converted, err := types.FromJsonValue(element, typeInfo)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, converted)
+	}
+	return result, nil
+}
+
+// deleteTargets returns the columns and collection elements to delete, converting the keys of the elements using the
+// type of the map keys or list indexes
+func deleteTargets(table *gocql.TableMetadata, rowDelete m.RowDelete) ([]db.DeleteTarget, error) {
+	targets := make([]db.DeleteTarget, 0, len(rowDelete.Columns)+len(rowDelete.Elements))
+	for _, columnName := range rowDelete.Columns {
+		targets = append(targets, db.DeleteTarget{Column: columnName})
+	}
+
+	for _, element := range rowDelete.Elements {
+		column, ok := table.Columns[element.Column]
+		if !ok {
+			return nil, fmt.Errorf("column '%s' not found", element.Column)
+		}
+		collectionType, ok := column.Type.(gocql.CollectionType)
+		if !ok || collectionType.Type() == gocql.TypeSet || element.Key == nil {
+			return nil, fmt.Errorf("elements of column '%s' can't be deleted", element.Column)
+		}
+
+		keyType := collectionType.Key
+		if collectionType.Type() == gocql.TypeList {
+			keyType = listIndexType
+		}
+		key, err := types.FromJsonValue(element.Key, keyType)
+		if err != nil {
+			return nil, fmt.Errorf("wrong type provided for the key of column %s", element.Column)
+		}
+		targets = append(targets, db.DeleteTarget{Column: element.Column, Key: key})
+	}
+
+	return targets, nil
+}
@@ -0,0 +1,530 @@
+package endpoint
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/datastax/cassandra-data-apis/auth"
+	"github.com/datastax/cassandra-data-apis/db"
+	m "github.com/datastax/cassandra-data-apis/rest/models"
+	"github.com/datastax/cassandra-data-apis/types"
+	"github.com/gocql/gocql"
+	"github.com/xitongsys/parquet-go/marshal"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/source"
+	"github.com/xitongsys/parquet-go/writer"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// exportPageSize is the number of rows retrieved per page while exporting a table
+	exportPageSize = 1000
+	// exportRowGroupSize is the size in bytes of each parquet row group, buffered in memory before being written
+	exportRowGroupSize = 16 * 1024 * 1024
+)
+
+const mediaTypeParquet = "application/vnd.apache.parquet"
+
+var exportFormats = map[string]string{
+	"ndjson":  mediaTypeNdjson,
+	"csv":     mediaTypeCsv,
+	"parquet": mediaTypeParquet,
+}
+
+// rowsWriter writes the exported rows to the response body
+type rowsWriter interface {
+	// write writes the rows of a page, containing the values as converted by types.ToJsonValues
+	write(rows []map[string]interface{}) error
+	// close writes the remaining data, if any
+	close() error
+}
+
+// ExportRows streams all the rows of a table, or the rows matching the filters of the request body, as NDJSON, CSV or
+// Parquet using chunked transfer encoding
+func (s *routeList) ExportRows(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	keyspaceName := s.params(r, keyspaceParam)
+	tableName := s.params(r, tableParam)
+	user := auth.ContextUserOrRole(r.Context())
+
+	tblMetadata, err := s.dbClient.Table(keyspaceName, tableName)
+	if err != nil {
+		if _, ok := err.(*db.DbObjectNotFound); ok {
+			RespondWithError(w, fmt.Sprintf(`Table "%s"."%s" not found`, keyspaceName, tableName), http.StatusNotFound)
+			return
+		}
+
+		msg := "unable to get table metadata"
+		s.logger.Debug(msg, "keyspace", keyspaceName, "table", tableName, "error", err)
+		RespondWithError(w, msg, http.StatusInternalServerError)
+		return
+	}
+
+	format, mediaType, err := exportFormat(r)
+	if err != nil {
+		RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var exportQuery m.ExportQuery
+	if r.Method == http.MethodPost {
+		if err := parseAndValidatePayload(&exportQuery, r); err != nil {
+			msg := "unable to parse payload"
+			s.logger.Debug(msg, "keyspace", keyspaceName, "table", tableName, "error", err)
+			RespondWithError(w, msg, http.StatusBadRequest)
+			return
+		}
+	}
+
+	where, err := filterConditions(tblMetadata, exportQuery.Filters)
+	if err != nil {
+		RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	readColumns := append([]string{}, exportQuery.ColumnNames...)
+	for _, filter := range exportQuery.Filters {
+		readColumns = append(readColumns, filter.ColumnName)
+	}
+
+	if err := s.policy.CheckColumns(user, tblMetadata, auth.PermissionRead, readColumns); err != nil {
+		RespondWithError(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	columns := exportQuery.ColumnNames
+	for _, columnName := range columns {
+		if _, ok := tblMetadata.Columns[columnName]; !ok {
+			RespondWithError(w, fmt.Sprintf("column '%s' not found", columnName), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if len(columns) == 0 {
+		columns = s.exportColumns(user, tblMetadata)
+	}
+
+	selectInfo := &db.SelectInfo{
+		Keyspace: keyspaceName,
+		Table:    tableName,
+		Columns:  columns,
+		Where:    where,
+	}
+
+	var writer rowsWriter
+	flusher, _ := w.(http.Flusher)
+	writePage := func(values []map[string]interface{}) error {
+		if writer == nil {
+			// The response starts with the first page, to be able to respond with an error status before
+			w.Header().Set("Content-Type", mediaType)
+			w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, tableName, format))
+			w.WriteHeader(http.StatusOK)
+
+			var err error
+			if writer, err = newRowsWriter(mediaType, w, tblMetadata, columns); err != nil {
+				return err
+			}
+		}
+
+		rows := types.ToJsonValues(s.policy.FilterRows(user, tblMetadata, values), tblMetadata)
+		if err := writer.write(rows); err != nil {
+			return err
+		}
+
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	}
+
+	options := newDbOptions(user).WithPageSize(exportPageSize).WithContext(r.Context())
+	if len(where) == 0 {
+		// Full table exports query the token ranges of the ring concurrently
+		err = s.dbClient.Scan(selectInfo, options, db.DefaultScanConcurrency, writePage)
+	} else {
+		err = s.exportPages(selectInfo, options, writePage)
+	}
+
+	if err == nil && writer == nil {
+		err = writePage(nil)
+	}
+
+	if err == nil {
+		err = writer.close()
+	}
+
+	if err != nil {
+		if writer == nil {
+			msg := "unable to execute select query"
+			s.logger.Debug(msg, "keyspace", keyspaceName, "table", tableName, "error", err)
+			RespondWithError(w, msg, http.StatusInternalServerError)
+			return
+		}
+
+		s.logger.Debug("unable to export rows", "keyspace", keyspaceName, "table", tableName, "error", err)
+		// The status was already sent, abort the response to signal the client that the body is incomplete
+		panic(http.ErrAbortHandler)
+	}
+
+	if flusher != nil {
+		flusher.Flush()
+	}
+}
+
+// exportPages retrieves the pages of the select query one after the other
+func (s *routeList) exportPages(info *db.SelectInfo, options *db.QueryOptions, fn db.ScanFn) error {
+	for {
+		rs, err := s.dbClient.Select(info, options)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(rs.Values()); err != nil {
+			return err
+		}
+
+		if len(rs.PageState()) == 0 {
+			return nil
+		}
+
+		if err := options.Context.Err(); err != nil {
+			return err
+		}
+
+		options = newDbOptions(options.UserOrRole).
+			WithPageSize(exportPageSize).
+			WithPageState(rs.PageState()).
+			WithContext(options.Context)
+	}
+}
+
+// exportFormat gets the format from the "format" query parameter or from the Accept header, NDJSON by default
+func exportFormat(r *http.Request) (string, string, error) {
+	if format := r.URL.Query().Get("format"); format != "" {
+		mediaType, ok := exportFormats[strings.ToLower(format)]
+		if !ok {
+			return "", "", fmt.Errorf("unsupported format '%s', use ndjson, csv or parquet", format)
+		}
+		return strings.ToLower(format), mediaType, nil
+	}
+
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		acceptedType, _, _ := mime.ParseMediaType(strings.TrimSpace(accepted))
+		for format, mediaType := range exportFormats {
+			if acceptedType == mediaType {
+				return format, mediaType, nil
+			}
+		}
+	}
+
+	return "ndjson", mediaTypeNdjson, nil
+}
+
+// exportColumns returns the columns the user or role is allowed to read: the primary key columns first, followed by
+// the rest of the columns sorted by name
+func (s *routeList) exportColumns(userOrRole string, table *gocql.TableMetadata) []string {
+	columns := make([]string, 0, len(table.Columns))
+	isKey := make(map[string]bool)
+	for _, keyColumns := range [][]*gocql.ColumnMetadata{table.PartitionKey, table.ClusteringColumns} {
+		for _, column := range keyColumns {
+			columns = append(columns, column.Name)
+			isKey[column.Name] = true
+		}
+	}
+
+	regularColumns := make([]string, 0, len(table.Columns))
+	for name := range table.Columns {
+		if !isKey[name] && s.policy.IsColumnAllowed(userOrRole, table, name, auth.PermissionRead) {
+			regularColumns = append(regularColumns, name)
+		}
+	}
+	sort.Strings(regularColumns)
+
+	return append(columns, regularColumns...)
+}
+
+func newRowsWriter(
+	mediaType string,
+	w io.Writer,
+	table *gocql.TableMetadata,
+	columns []string,
+) (rowsWriter, error) {
+	switch mediaType {
+	case mediaTypeCsv:
+		writer := &csvRowsWriter{writer: csv.NewWriter(w), columns: columns}
+		if err := writer.writer.Write(columns); err != nil {
+			return nil, err
+		}
+		return writer, nil
+	case mediaTypeParquet:
+		return newParquetRowsWriter(w, table, columns)
+	}
+
+	return &ndjsonRowsWriter{encoder: json.NewEncoder(w)}, nil
+}
+
+type ndjsonRowsWriter struct {
+	encoder *json.Encoder
+}
+
+func (n *ndjsonRowsWriter) write(rows []map[string]interface{}) error {
+	for _, row := range rows {
+		// Encode() appends a new line after each value
+		if err := n.encoder.Encode(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (n *ndjsonRowsWriter) close() error {
+	return nil
+}
+
+// csvRowsWriter writes a header with the column names followed by the rows, null values are written as empty fields
+// and the collections as JSON
+type csvRowsWriter struct {
+	writer  *csv.Writer
+	columns []string
+}
+
+func (c *csvRowsWriter) write(rows []map[string]interface{}) error {
+	record := make([]string, len(c.columns))
+	for _, row := range rows {
+		for i, name := range c.columns {
+			value, _, err := textValue(row[name])
+			if err != nil {
+				return err
+			}
+			record[i] = value
+		}
+
+		if err := c.writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+func (c *csvRowsWriter) close() error {
+	return nil
+}
+
+// parquetRowsWriter writes a parquet file containing a column per exported column, booleans and numbers use typed
+// columns and the rest of the values are written as strings, with the collections encoded as JSON
+type parquetRowsWriter struct {
+	writer  *writer.ParquetWriter
+	columns []string
+	schema  []*parquet.SchemaElement
+}
+
+func newParquetRowsWriter(w io.Writer, table *gocql.TableMetadata, columns []string) (*parquetRowsWriter, error) {
+	root := parquet.NewSchemaElement()
+	root.Name = "schema"
+	root.NumChildren = int32Ptr(int32(len(columns)))
+	root.RepetitionType = parquet.FieldRepetitionTypePtr(parquet.FieldRepetitionType_REQUIRED)
+
+	schema := make([]*parquet.SchemaElement, 0, len(columns)+1)
+	schema = append(schema, root)
+	for _, name := range columns {
+		schema = append(schema, parquetSchemaElement(name, table.Columns[name].Type))
+	}
+
+	parquetWriter, err := writer.NewParquetWriter(&parquetStream{w}, schema, 1)
+	if err != nil {
+		return nil, err
+	}
+	// The rows are written as lists of values, in the order of the columns
+	parquetWriter.MarshalFunc = marshal.MarshalCSV
+	parquetWriter.RowGroupSize = exportRowGroupSize
+
+	return &parquetRowsWriter{writer: parquetWriter, columns: columns, schema: schema[1:]}, nil
+}
+
+func (p *parquetRowsWriter) write(rows []map[string]interface{}) error {
+	for _, row := range rows {
+		// The writer keeps the records until they are flushed, each row uses its own slice
+		record := make([]interface{}, len(p.columns))
+		for i, name := range p.columns {
+			value, err := parquetValue(row[name], p.schema[i])
+			if err != nil {
+				return err
+			}
+			record[i] = value
+		}
+
+		if err := p.writer.Write(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *parquetRowsWriter) close() error {
+	return p.writer.WriteStop()
+}
+
+// parquetSchemaElement returns the optional column of the parquet schema used to write the values of the CQL type
+func parquetSchemaElement(name string, typeInfo gocql.TypeInfo) *parquet.SchemaElement {
+	element := parquet.NewSchemaElement()
+	element.Name = name
+	element.RepetitionType = parquet.FieldRepetitionTypePtr(parquet.FieldRepetitionType_OPTIONAL)
+
+	switch typeInfo.Type() {
+	case gocql.TypeBoolean:
+		element.Type = parquet.TypePtr(parquet.Type_BOOLEAN)
+	case gocql.TypeTinyInt:
+		element.Type = parquet.TypePtr(parquet.Type_INT32)
+		element.ConvertedType = parquet.ConvertedTypePtr(parquet.ConvertedType_INT_8)
+	case gocql.TypeSmallInt:
+		element.Type = parquet.TypePtr(parquet.Type_INT32)
+		element.ConvertedType = parquet.ConvertedTypePtr(parquet.ConvertedType_INT_16)
+	case gocql.TypeInt:
+		element.Type = parquet.TypePtr(parquet.Type_INT32)
+	case gocql.TypeBigInt, gocql.TypeCounter:
+		element.Type = parquet.TypePtr(parquet.Type_INT64)
+	case gocql.TypeFloat:
+		element.Type = parquet.TypePtr(parquet.Type_FLOAT)
+	case gocql.TypeDouble:
+		element.Type = parquet.TypePtr(parquet.Type_DOUBLE)
+	case gocql.TypeList, gocql.TypeSet, gocql.TypeMap, gocql.TypeUDT, gocql.TypeTuple:
+		element.Type = parquet.TypePtr(parquet.Type_BYTE_ARRAY)
+		element.ConvertedType = parquet.ConvertedTypePtr(parquet.ConvertedType_JSON)
+	default:
+		// The rest of the types are represented as strings in JSON, i.e: uuid, timestamp, decimal and blob (base64)
+		element.Type = parquet.TypePtr(parquet.Type_BYTE_ARRAY)
+		element.ConvertedType = parquet.ConvertedTypePtr(parquet.ConvertedType_UTF8)
+	}
+
+	return element
+}
+
+// parquetValue converts a JSON value into the go type used by the parquet writer for the physical type of the column,
+// it returns nil for null values
+func parquetValue(value interface{}, element *parquet.SchemaElement) (interface{}, error) {
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.Ptr {
+		// Null values are represented as nil pointers
+		if rv.IsNil() {
+			return nil, nil
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return nil, nil
+	}
+
+	switch element.GetType() {
+	case parquet.Type_BOOLEAN:
+		if rv.Kind() == reflect.Bool {
+			return rv.Bool(), nil
+		}
+	case parquet.Type_INT32:
+		if number, ok := intValue(rv); ok {
+			return int32(number), nil
+		}
+	case parquet.Type_INT64:
+		if rv.Kind() == reflect.String {
+			// bigint and counter values are retrieved as strings
+			return strconv.ParseInt(rv.String(), 10, 64)
+		}
+		if number, ok := intValue(rv); ok {
+			return number, nil
+		}
+	case parquet.Type_FLOAT:
+		if rv.Kind() == reflect.Float32 || rv.Kind() == reflect.Float64 {
+			return float32(rv.Float()), nil
+		}
+	case parquet.Type_DOUBLE:
+		if rv.Kind() == reflect.Float32 || rv.Kind() == reflect.Float64 {
+			return rv.Float(), nil
+		}
+	case parquet.Type_BYTE_ARRAY:
+		text, ok, err := textValue(rv.Interface())
+		if err != nil || !ok {
+			return nil, err
+		}
+		return text, nil
+	}
+
+	return nil, fmt.Errorf("unexpected value of type %T for parquet column '%s'", value, element.Name)
+}
+
+func intValue(rv reflect.Value) (int64, bool) {
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), true
+	}
+	return 0, false
+}
+
+func int32Ptr(value int32) *int32 {
+	return &value
+}
+
+// parquetStream is a parquet file that is written sequentially to the response body, the writer doesn't read it
+type parquetStream struct {
+	io.Writer
+}
+
+func (s *parquetStream) Read([]byte) (int, error) {
+	return 0, errors.New("parquet stream can not be read")
+}
+
+func (s *parquetStream) Seek(int64, int) (int64, error) {
+	return 0, errors.New("parquet stream can not be seeked")
+}
+
+func (s *parquetStream) Close() error {
+	return nil
+}
+
+func (s *parquetStream) Open(string) (source.ParquetFile, error) {
+	return nil, errors.New("parquet stream can not be opened")
+}
+
+func (s *parquetStream) Create(string) (source.ParquetFile, error) {
+	return nil, errors.New("parquet stream can not be created")
+}
+
+// textValue converts a JSON value into a string, JSON strings are unquoted. It returns false when the value is null.
+func textValue(value interface{}) (string, bool, error) {
+	switch value := value.(type) {
+	case nil:
+		return "", false, nil
+	case string:
+		return value, true, nil
+	case *string:
+		if value == nil {
+			return "", false, nil
+		}
+		return *value, true, nil
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", false, err
+	}
+
+	if string(encoded) == "null" {
+		return "", false, nil
+	}
+
+	if encoded[0] == '"' {
+		var text string
+		err = json.Unmarshal(encoded, &text)
+		return text, true, err
+	}
+
+	return string(encoded), true, nil
+}
@@ -0,0 +1,135 @@
+package endpoint
+
+import (
+	"bytes"
+	"errors"
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/reader"
+	"github.com/xitongsys/parquet-go/source"
+	"testing"
+)
+
+// bufferFile is an in-memory parquet file used to read the exported files
+type bufferFile struct {
+	*bytes.Reader
+	data []byte
+}
+
+func newBufferFile(data []byte) *bufferFile {
+	return &bufferFile{Reader: bytes.NewReader(data), data: data}
+}
+
+func (f *bufferFile) Open(string) (source.ParquetFile, error) {
+	return newBufferFile(f.data), nil
+}
+
+func (f *bufferFile) Create(string) (source.ParquetFile, error) {
+	return nil, errors.New("read only file")
+}
+
+func (f *bufferFile) Write([]byte) (int, error) {
+	return 0, errors.New("read only file")
+}
+
+func (f *bufferFile) Close() error {
+	return nil
+}
+
+func TestParquetRowsWriter_RoundTrip(t *testing.T) {
+	columnTypes := []struct {
+		name     string
+		typeInfo gocql.TypeInfo
+	}{
+		{"enabled", gocql.NewNativeType(4, gocql.TypeBoolean, "")},
+		{"tiny", gocql.NewNativeType(4, gocql.TypeTinyInt, "")},
+		{"small", gocql.NewNativeType(4, gocql.TypeSmallInt, "")},
+		{"id", gocql.NewNativeType(4, gocql.TypeInt, "")},
+		{"total", gocql.NewNativeType(4, gocql.TypeBigInt, "")},
+		{"ratio", gocql.NewNativeType(4, gocql.TypeFloat, "")},
+		{"score", gocql.NewNativeType(4, gocql.TypeDouble, "")},
+		{"name", gocql.NewNativeType(4, gocql.TypeText, "")},
+		{"tags", gocql.CollectionType{
+			NativeType: gocql.NewNativeType(4, gocql.TypeSet, ""),
+			Elem:       gocql.NewNativeType(4, gocql.TypeText, ""),
+		}},
+	}
+	table := &gocql.TableMetadata{Columns: map[string]*gocql.ColumnMetadata{}}
+	columns := make([]string, len(columnTypes))
+	for i, column := range columnTypes {
+		columns[i] = column.name
+		table.Columns[column.name] = &gocql.ColumnMetadata{Name: column.name, Type: column.typeInfo}
+	}
+
+	enabled, tiny, small, id, total := true, int8(-1), int16(300), 1, "1099511627776"
+	ratio, score, name := float32(0.5), 1.25, "ñ"
+	rows := []map[string]interface{}{
+		{
+			"enabled": &enabled, "tiny": &tiny, "small": &small, "id": &id, "total": &total,
+			"ratio": &ratio, "score": &score, "name": &name, "tags": []string{"a", "b"},
+		},
+		{
+			"enabled": (*bool)(nil), "tiny": (*int8)(nil), "small": (*int16)(nil), "id": (*int)(nil),
+			"total": (*string)(nil), "ratio": (*float32)(nil), "score": (*float64)(nil), "name": (*string)(nil),
+			"tags": nil,
+		},
+	}
+
+	buffer := &bytes.Buffer{}
+	rowsWriter, err := newRowsWriter(mediaTypeParquet, buffer, table, columns)
+	require.NoError(t, err)
+	require.NoError(t, rowsWriter.write(rows))
+	require.NoError(t, rowsWriter.close())
+
+	r, err := reader.NewParquetColumnReader(newBufferFile(buffer.Bytes()), 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(rows)), r.GetNumRows())
+
+	schema := r.Footer.Schema
+	require.Len(t, schema, len(columns)+1)
+	expectedTypes := []parquet.Type{
+		parquet.Type_BOOLEAN, parquet.Type_INT32, parquet.Type_INT32, parquet.Type_INT32, parquet.Type_INT64,
+		parquet.Type_FLOAT, parquet.Type_DOUBLE, parquet.Type_BYTE_ARRAY, parquet.Type_BYTE_ARRAY,
+	}
+	for i, name := range columns {
+		element := schema[i+1]
+		assert.Equal(t, name, r.SchemaHandler.Infos[i+1].ExName)
+		assert.Equal(t, expectedTypes[i], element.GetType(), name)
+		assert.Equal(t, parquet.FieldRepetitionType_OPTIONAL, element.GetRepetitionType(), name)
+	}
+	assert.Equal(t, parquet.ConvertedType_INT_8, schema[2].GetConvertedType())
+	assert.Equal(t, parquet.ConvertedType_INT_16, schema[3].GetConvertedType())
+	assert.Equal(t, parquet.ConvertedType_UTF8, schema[8].GetConvertedType())
+	assert.Equal(t, parquet.ConvertedType_JSON, schema[9].GetConvertedType())
+
+	expected := [][]interface{}{
+		{true, nil},
+		{int32(-1), nil},
+		{int32(300), nil},
+		{int32(1), nil},
+		{int64(1) << 40, nil},
+		{float32(0.5), nil},
+		{1.25, nil},
+		{"ñ", nil},
+		{`["a","b"]`, nil},
+	}
+	for i, name := range columns {
+		values, _, definitionLevels, err := r.ReadColumnByIndex(int64(i), int64(len(rows)))
+		require.NoError(t, err, name)
+		assert.Equal(t, expected[i], values, name)
+		assert.Equal(t, []int32{1, 0}, definitionLevels, name)
+	}
+}
+
+func TestParquetRowsWriter_InvalidValue(t *testing.T) {
+	table := &gocql.TableMetadata{Columns: map[string]*gocql.ColumnMetadata{
+		"id": {Name: "id", Type: gocql.NewNativeType(4, gocql.TypeInt, "")},
+	}}
+
+	rowsWriter, err := newRowsWriter(mediaTypeParquet, &bytes.Buffer{}, table, []string{"id"})
+	require.NoError(t, err)
+	assert.EqualError(t, rowsWriter.write([]map[string]interface{}{{"id": "1"}}),
+		"unexpected value of type string for parquet column 'id'")
+}
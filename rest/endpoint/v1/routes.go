@@ -1,12 +1,16 @@
 package endpoint
 
 import (
+	"github.com/datastax/cassandra-data-apis/audit"
+	"github.com/datastax/cassandra-data-apis/auth"
 	"github.com/datastax/cassandra-data-apis/config"
 	"github.com/datastax/cassandra-data-apis/db"
 	"github.com/datastax/cassandra-data-apis/log"
+	"github.com/datastax/cassandra-data-apis/ratelimit"
 	"github.com/datastax/cassandra-data-apis/types"
 	"net/http"
 	"path"
+	"strings"
 )
 
 const (
@@ -23,6 +27,9 @@ const (
 	RowsPathFormat         = "v1/keyspaces/%s/tables/%s/rows"
 	RowSinglePathFormat    = "v1/keyspaces/%s/tables/%s/rows/%s"
 	QueryPathFormat        = "v1/keyspaces/%s/tables/%s/rows/query"
+	BulkRowsPathFormat     = "v1/keyspaces/%s/tables/%s/rows/bulk"
+	ExportPathFormat       = "v1/keyspaces/%s/tables/%s/export"
+	RowIncrementPathFormat = "v1/keyspaces/%s/tables/%s/increment/%s"
 )
 
 // routeList describes how to route an endpoint
@@ -33,6 +40,9 @@ type routeList struct {
 	operations        config.SchemaOperations
 	excludedKeyspaces map[string]bool
 	singleKeyspace    string
+	policy            *auth.Policy
+	auditor           *audit.Auditor
+	allowFiltering    config.AllowFilteringSettings
 }
 
 // Routes returns a slice of all the REST endpoint routes
@@ -49,6 +59,9 @@ func Routes(prefix string, operations config.SchemaOperations, singleKeyspace st
 		operations:        operations,
 		excludedKeyspaces: excludedKeyspaces,
 		singleKeyspace:    singleKeyspace,
+		policy:            cfg.Policy(),
+		auditor:           cfg.Auditor(),
+		allowFiltering:    cfg.AllowFiltering(),
 	}
 
 	urlPattern := cfg.RouterInfo().UrlPattern()
@@ -61,72 +74,95 @@ func Routes(prefix string, operations config.SchemaOperations, singleKeyspace st
 	urlRows := url(prefix, urlPattern, RowsPathFormat, keyspaceParam, tableParam)
 	urlSingleRow := url(prefix, urlPattern, RowSinglePathFormat, keyspaceParam, tableParam, "rowIdentifier")
 	urlQuery := url(prefix, urlPattern, QueryPathFormat, keyspaceParam, tableParam)
+	urlBulkRows := url(prefix, urlPattern, BulkRowsPathFormat, keyspaceParam, tableParam)
+	urlExport := url(prefix, urlPattern, ExportPathFormat, keyspaceParam, tableParam)
+	urlIncrementRow := url(prefix, urlPattern, RowIncrementPathFormat, keyspaceParam, tableParam, "rowIdentifier")
 
 	routes := []types.Route{
 		{
 			Method:  http.MethodGet,
 			Pattern: urlColumns,
-			Handler: rl.validateKeyspace(rl.GetColumns),
+			Handler: rl.validateKeyspace(auth.PermissionRead, rl.GetColumns),
 		},
 		{
 			Method:  http.MethodPost,
 			Pattern: urlColumns,
-			Handler: rl.validateKeyspace(rl.isSupported(config.TableAlterAdd, rl.AddColumn)),
+			Handler: rl.validateKeyspace(auth.PermissionSchema, rl.isSupported(config.TableAlterAdd, rl.AddColumn)),
 		},
 		{
 			Method:  http.MethodDelete,
 			Pattern: urlSingleColumn,
-			Handler: rl.validateKeyspace(rl.isSupported(config.TableAlterDrop, rl.DeleteColumn)),
+			Handler: rl.validateKeyspace(auth.PermissionSchema, rl.isSupported(config.TableAlterDrop, rl.DeleteColumn)),
 		},
 		{
 			Method:  http.MethodGet,
 			Pattern: urlSingleColumn,
-			Handler: rl.validateKeyspace(rl.GetColumn),
+			Handler: rl.validateKeyspace(auth.PermissionRead, rl.GetColumn),
 		},
 		{
 			Method:  http.MethodPost,
 			Pattern: urlRows,
-			Handler: rl.validateKeyspace(rl.AddRow),
+			Handler: rl.validateKeyspace(auth.PermissionInsert, rl.AddRow),
 		},
 		{
 			Method:  http.MethodGet,
 			Pattern: urlSingleRow,
-			Handler: rl.validateKeyspace(rl.GetRow),
+			Handler: rl.validateKeyspace(auth.PermissionRead, rl.GetRow),
 		},
 		{
 			Method:  http.MethodPut,
 			Pattern: urlSingleRow,
-			Handler: rl.validateKeyspace(rl.UpdateRow),
+			Handler: rl.validateKeyspace(auth.PermissionUpdate, rl.UpdateRow),
+		},
+		{
+			Method:  http.MethodPost,
+			Pattern: urlIncrementRow,
+			Handler: rl.validateKeyspace(auth.PermissionUpdate, rl.IncrementRow),
 		},
 		{
 			Method:  http.MethodDelete,
 			Pattern: urlSingleRow,
-			Handler: rl.validateKeyspace(rl.DeleteRow),
+			Handler: rl.validateKeyspace(auth.PermissionDelete, rl.DeleteRow),
 		},
 		{
 			Method:  http.MethodPost,
 			Pattern: urlQuery,
-			Handler: rl.validateKeyspace(rl.Query),
+			Handler: rl.validateKeyspace(auth.PermissionRead, rl.Query),
+		},
+		{
+			Method:  http.MethodPost,
+			Pattern: urlBulkRows,
+			Handler: rl.validateKeyspace(auth.PermissionInsert, rl.AddRows),
+		},
+		{
+			Method:  http.MethodGet,
+			Pattern: urlExport,
+			Handler: rl.validateKeyspace(auth.PermissionRead, rl.ExportRows),
+		},
+		{
+			Method:  http.MethodPost,
+			Pattern: urlExport,
+			Handler: rl.validateKeyspace(auth.PermissionRead, rl.ExportRows),
 		},
 		{
 			Method:  http.MethodGet,
 			Pattern: urlTables,
-			Handler: rl.validateKeyspace(rl.GetTables),
+			Handler: rl.validateKeyspace(auth.AllPermissions, rl.GetTables),
 		},
 		{
 			Method:  http.MethodPost,
 			Pattern: urlTables,
-			Handler: rl.validateKeyspace(rl.isSupported(config.TableCreate, rl.AddTable)),
+			Handler: rl.validateKeyspace(auth.PermissionSchema, rl.isSupported(config.TableCreate, rl.AddTable)),
 		},
 		{
 			Method:  http.MethodGet,
 			Pattern: urlSingleTable,
-			Handler: rl.validateKeyspace(rl.GetTable),
+			Handler: rl.validateKeyspace(auth.AllPermissions, rl.GetTable),
 		},
 		{
 			Method:  http.MethodDelete,
 			Pattern: urlSingleTable,
-			Handler: rl.validateKeyspace(rl.isSupported(config.TableDrop, rl.DeleteTable)),
+			Handler: rl.validateKeyspace(auth.PermissionSchema, rl.isSupported(config.TableDrop, rl.DeleteTable)),
 		},
 		{
 			Method:  http.MethodGet,
@@ -142,7 +178,9 @@ func url(prefix string, urlPattern config.UrlPattern, format string, parameterNa
 	return path.Join(prefix, urlPattern.UrlPathFormat(format, parameterNames...))
 }
 
-func (s *routeList) validateKeyspace(next http.HandlerFunc) http.HandlerFunc {
+// validateKeyspace checks that the keyspace is exposed and that the policy grants the permissions on the table, when
+// the route contains a table, or any permission on the keyspace otherwise
+func (s *routeList) validateKeyspace(perms auth.Permissions, next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		keyspaceName := s.params(r, keyspaceParam)
 
@@ -157,6 +195,19 @@ func (s *routeList) validateKeyspace(next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
+		userOrRole := auth.ContextUserOrRole(r.Context())
+		if !s.policy.IsKeyspaceVisible(userOrRole, keyspaceName) {
+			RespondWithKeyspaceNotAllowed(w)
+			return
+		}
+
+		if tableName := s.params(r, tableParam); tableName != "" {
+			if err := s.policy.CheckTable(userOrRole, keyspaceName, tableName, perms); err != nil {
+				RespondWithError(w, err.Error(), http.StatusForbidden)
+				return
+			}
+		}
+
 		next(w, r)
 	}
 }
@@ -172,3 +223,27 @@ func (s *routeList) isSupported(requiredOp config.SchemaOperations, handler http
 func forbiddenHandler(w http.ResponseWriter, _ *http.Request) {
 	http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
 }
+
+// RateLimitClassifier returns a classifier for the requests to the REST routes, queries and exports are classified as
+// reads, auth token requests as writes and changes to tables and columns as schema changes
+func RateLimitClassifier(params config.UrlParamGetter) ratelimit.Classifier {
+	return func(r *http.Request) (string, ratelimit.Operation) {
+		keyspace := params(r, keyspaceParam)
+		switch {
+		case r.Method == http.MethodGet:
+			return keyspace, ratelimit.OperationRead
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/rows/query"):
+			return keyspace, ratelimit.OperationRead
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/"+AuthPathFormat):
+			// Validating the credentials opens a session with the cluster
+			return keyspace, ratelimit.OperationWrite
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/export"):
+			// Filtered exports are reads, the same as the exports using GET
+			return keyspace, ratelimit.OperationRead
+		case strings.Contains(r.URL.Path, "/rows") || strings.Contains(r.URL.Path, "/increment/"):
+			return keyspace, ratelimit.OperationWrite
+		default:
+			return keyspace, ratelimit.OperationSchema
+		}
+	}
+}
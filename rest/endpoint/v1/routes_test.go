@@ -0,0 +1,60 @@
+package endpoint
+
+import (
+	"github.com/datastax/cassandra-data-apis/config"
+	"github.com/datastax/cassandra-data-apis/ratelimit"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRoutes_RegisterOnSingleRouter(t *testing.T) {
+	cfg := config.NewConfigMock().Default()
+	cfg.On("RouterInfo").Return(config.DefaultRouterInfo())
+
+	routes := Routes("/rest", config.AllSchemaOperations, "", cfg, nil)
+	routes = append(routes, AuthRoutes("/rest", cfg, nil, nil)...)
+
+	router := httprouter.New()
+	for _, route := range routes {
+		assert.NotPanics(t, func() {
+			router.Handler(route.Method, route.Pattern, route.Handler)
+		}, "registering %s %s", route.Method, route.Pattern)
+	}
+}
+
+func TestRateLimitClassifier(t *testing.T) {
+	params := func(r *http.Request, name string) string {
+		if name == keyspaceParam {
+			return "store"
+		}
+		return ""
+	}
+	classify := RateLimitClassifier(params)
+
+	items := []struct {
+		method    string
+		path      string
+		operation ratelimit.Operation
+	}{
+		{http.MethodGet, "/rest/v1/keyspaces/store/tables/books/rows/1", ratelimit.OperationRead},
+		{http.MethodGet, "/rest/v1/keyspaces/store/tables/books/export", ratelimit.OperationRead},
+		{http.MethodPost, "/rest/v1/keyspaces/store/tables/books/export", ratelimit.OperationRead},
+		{http.MethodPost, "/rest/v1/keyspaces/store/tables/books/rows/query", ratelimit.OperationRead},
+		{http.MethodPost, "/rest/v1/keyspaces/store/tables/books/rows", ratelimit.OperationWrite},
+		{http.MethodPost, "/rest/v1/keyspaces/store/tables/books/rows/bulk", ratelimit.OperationWrite},
+		{http.MethodPost, "/rest/v1/keyspaces/store/tables/views/increment/home", ratelimit.OperationWrite},
+		{http.MethodDelete, "/rest/v1/keyspaces/store/tables/books/rows/1", ratelimit.OperationWrite},
+		{http.MethodPost, "/rest/v1/auth", ratelimit.OperationWrite},
+		{http.MethodPost, "/rest/v1/keyspaces/store/tables", ratelimit.OperationSchema},
+		{http.MethodDelete, "/rest/v1/keyspaces/store/tables/books/columns/pages", ratelimit.OperationSchema},
+	}
+
+	for _, item := range items {
+		keyspace, operation := classify(httptest.NewRequest(item.method, item.path, nil))
+		assert.Equal(t, "store", keyspace)
+		assert.Equal(t, item.operation, operation, "%s %s", item.method, item.path)
+	}
+}
@@ -0,0 +1,38 @@
+package endpoint
+
+import (
+	"fmt"
+	"github.com/datastax/cassandra-data-apis/db"
+	m "github.com/datastax/cassandra-data-apis/rest/models"
+	"github.com/datastax/cassandra-data-apis/types"
+	"github.com/gocql/gocql"
+)
+
+// annOrder validates that the rows can be ordered by similarity to the vector and converts it using the type of the
+// column, it returns the similarity function of the index when the similarity score is requested
+func annOrder(table *gocql.TableMetadata, expression *m.AnnExpression, limit int) (*db.AnnOrder, string, error) {
+	column, ok := table.Columns[expression.ColumnName]
+	if !ok {
+		return nil, "", fmt.Errorf("column '%s' not found", expression.ColumnName)
+	}
+
+	function, ok := db.VectorSimilarityFunction(column)
+	if !ok {
+		return nil, "", fmt.Errorf("column '%s' is not a vector column with a storage-attached index",
+			expression.ColumnName)
+	}
+
+	if limit <= 0 {
+		return nil, "", fmt.Errorf("a limit is required when ordering by similarity")
+	}
+
+	vector, err := types.FromJsonValue(expression.Vector, column.Type)
+	if err != nil {
+		return nil, "", fmt.Errorf("wrong vector provided for column '%s': %s", expression.ColumnName, err)
+	}
+
+	if !expression.Similarity {
+		function = ""
+	}
+	return &db.AnnOrder{Column: column.Name, Vector: vector}, function, nil
+}
@@ -0,0 +1,12 @@
+package models
+
+// AuthTokenRequest contains the credentials used to generate an auth token
+type AuthTokenRequest struct {
+	Username string `json:"username" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+// AuthTokenResponse contains the generated auth token
+type AuthTokenResponse struct {
+	AuthToken string `json:"authToken"`
+}
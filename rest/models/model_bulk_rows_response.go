@@ -0,0 +1,19 @@
+package models
+
+// BulkRowsResponse summarizes the result of a bulk rows request
+type BulkRowsResponse struct {
+	RowsInserted int `json:"rowsInserted"`
+
+	RowsFailed int `json:"rowsFailed"`
+
+	// Errors contains the first errors, up to a maximum
+	Errors []BulkRowError `json:"errors,omitempty"`
+}
+
+// BulkRowError describes why a row of the request body could not be inserted
+type BulkRowError struct {
+	// Row is the position of the row in the request body, starting at 1 and excluding the CSV header
+	Row int `json:"row"`
+
+	Description string `json:"description"`
+}
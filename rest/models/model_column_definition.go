@@ -2,6 +2,7 @@ package models
 
 import (
 	"fmt"
+	"github.com/datastax/cassandra-data-apis/types"
 	"github.com/gocql/gocql"
 )
 
@@ -10,8 +11,8 @@ type ColumnDefinition struct {
 	// Name is a unique name for the column.
 	Name string `json:"name" validate:"required"`
 
-	// TypeDefinition defines the type of data allowed in the column
-	TypeDefinition string `json:"typeDefinition" validate:"required,oneof=ascii text varchar tinyint smallint int bigint varint decimal float double date duration time timestamp uuid timeuuid blob boolean counter inet"`
+	// TypeDefinition defines the type of data allowed in the column, including vectors of floats like vector<float, 3>
+	TypeDefinition string `json:"typeDefinition" validate:"required"`
 
 	// Denotes that the column is shared by all rows of a partition
 	Static bool `json:"static,omitempty"`
@@ -66,6 +67,9 @@ func toDbType(typeDefinition string) (gocql.TypeInfo, error) {
 	case gocql.TypeVarint.String():
 		t = gocql.TypeVarint
 	default:
+		if vectorType, ok := types.ParseVectorType(typeDefinition); ok {
+			return vectorType, nil
+		}
 		return nil, fmt.Errorf("type '%s' Not supported", typeDefinition)
 	}
 
@@ -0,0 +1,7 @@
+package models
+
+// ExportQuery selects the rows and columns of a table to export, all the rows are exported when there are no filters
+type ExportQuery struct {
+	ColumnNames []string `json:"columnNames,omitempty"`
+	Filters     []Filter `json:"filters,omitempty"`
+}
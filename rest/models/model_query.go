@@ -6,10 +6,37 @@ type Query struct {
 	OrderBy     *ClusteringExpression `json:"orderBy,omitempty"`
 	PageSize    int                   `json:"pageSize,omitempty"`
 	PageState   string                `json:"pageState,omitempty"`
+	Aggregation *Aggregation          `json:"aggregation,omitempty"`
+	WriteTime   []string              `json:"writeTime,omitempty"`
+	TTL         []string              `json:"ttl,omitempty"`
+	AnnOf       *AnnExpression        `json:"annOf,omitempty"`
+	Limit       int                   `json:"limit,omitempty" validate:"gte=0"`
+	// AllowFiltering executes the query using ALLOW FILTERING, it must be enabled for the table on the server
+	AllowFiltering bool `json:"allowFiltering,omitempty"`
 }
 
 type Filter struct {
 	ColumnName string        `json:"columnName" validate:"required"`
-	Operator   string        `json:"operator" validate:"required,oneof=eq notEq gt gte lt lte in"`
+	Operator   string        `json:"operator" validate:"required,oneof=eq notEq gt gte lt lte in contains containsKey containsEntry like"`
 	Value      []interface{} `json:"value" validate:"required"`
 }
+
+// Aggregation is the set of aggregate functions to compute over the rows matching the filters, optionally grouping the
+// rows by primary key columns
+type Aggregation struct {
+	Functions []AggregateFunction `json:"functions" validate:"required,min=1,dive"`
+	GroupBy   []string            `json:"groupBy,omitempty"`
+}
+
+type AggregateFunction struct {
+	Name       string `json:"name" validate:"required,oneof=count min max sum avg"`
+	ColumnName string `json:"columnName,omitempty"`
+}
+
+// AnnExpression orders the rows by similarity to a vector, using the index of a vector column, optionally including
+// the similarity score of each row
+type AnnExpression struct {
+	ColumnName string        `json:"columnName" validate:"required"`
+	Vector     []interface{} `json:"vector" validate:"required"`
+	Similarity bool          `json:"similarity,omitempty"`
+}
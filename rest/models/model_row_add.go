@@ -3,4 +3,6 @@ package models
 // RowAdd defines a row to be added to a table
 type RowAdd struct {
 	Columns []Column `json:"columns" validate:"required"`
+	// Timestamp is the write time in microseconds since the epoch, the server time is used when not provided
+	Timestamp *int64 `json:"timestamp,omitempty"`
 }
@@ -0,0 +1,20 @@
+package models
+
+// RowDelete defines the optional settings of a row deletion
+type RowDelete struct {
+	// Timestamp is the write time in microseconds since the epoch, the server time is used when not provided
+	Timestamp *int64 `json:"timestamp,omitempty"`
+	// Columns contains the regular or static columns to delete instead of the whole row
+	Columns []string `json:"columns,omitempty"`
+	// Elements contains the map entries or list elements to delete instead of the whole row
+	Elements []ElementDelete `json:"elements,omitempty"`
+}
+
+// ElementDelete identifies an element of a collection column to delete
+type ElementDelete struct {
+	// The name of the map or list column.
+	Column string `json:"column"`
+
+	// Key is the map key or the list index of the element.
+	Key interface{} `json:"key"`
+}
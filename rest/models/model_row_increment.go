@@ -0,0 +1,15 @@
+package models
+
+// RowIncrement defines the amounts to add to the counter columns of a row.
+type RowIncrement struct {
+	Deltas []CounterDelta `json:"deltas" validate:"required,min=1,dive"`
+}
+
+// CounterDelta is a counter column and the amount to add to it.
+type CounterDelta struct {
+	// The name of the counter column.
+	Column string `json:"column" validate:"required"`
+
+	// The amount to add to the counter, negative values decrement it.
+	Value int64 `json:"value"`
+}
@@ -3,6 +3,8 @@ package models
 // RowsUpdate defines an update operation on rows within a table.
 type RowsUpdate struct {
 	Changeset []Changeset `json:"changeset" validate:"required"`
+	// Timestamp is the write time in microseconds since the epoch, the server time is used when not provided
+	Timestamp *int64 `json:"timestamp,omitempty"`
 }
 
 // Changeset is a column and associated value to be used when updating a row.
@@ -12,4 +14,12 @@ type Changeset struct {
 
 	// The value for the column that will be updated for all matching rows.
 	Value interface{} `json:"value" validate:"required"`
+
+	// Operation modifies the elements of a list, set or map column instead of replacing its value: "append" and
+	// "remove" the elements of lists and sets, "prepend" elements to lists, "put" the value of a list index or a map
+	// key and "remove" the keys of maps.
+	Operation string `json:"operation,omitempty"`
+
+	// Key is the list index or the map key of the value when the operation is "put".
+	Key interface{} `json:"key,omitempty"`
 }
@@ -1,6 +1,7 @@
 package rest
 
 import (
+	"github.com/datastax/cassandra-data-apis/auth"
 	"github.com/datastax/cassandra-data-apis/config"
 	"github.com/datastax/cassandra-data-apis/db"
 	restEndpointV1 "github.com/datastax/cassandra-data-apis/rest/endpoint/v1"
@@ -25,3 +26,7 @@ func NewRouteGenerator(
 func (g *RouteGenerator) Routes(prefix string, operations config.SchemaOperations, singleKs string) []types.Route {
 	return restEndpointV1.Routes(prefix, operations, singleKs, g.config, g.dbClient)
 }
+
+func (g *RouteGenerator) RoutesAuth(prefix string, tokens *auth.TokenStore) []types.Route {
+	return restEndpointV1.AuthRoutes(prefix, g.config, g.dbClient, tokens)
+}
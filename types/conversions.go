@@ -8,10 +8,13 @@ import (
 	"github.com/gocql/gocql"
 	"gopkg.in/inf.v0"
 	"math/big"
+	"reflect"
 	"strings"
 	"time"
 )
 
+const dateLayout = "2006-01-02"
+
 type toJsonFn func(value interface{}) interface{}
 type fromJsonFn func(value interface{}) (interface{}, error)
 
@@ -27,7 +30,12 @@ func ToJsonValues(rows []map[string]interface{}, table *gocql.TableMetadata) []m
 	result := make([]map[string]interface{}, rowsLength)
 
 	for columnName := range firstRow {
-		column := table.Columns[columnName]
+		column, ok := table.Columns[columnName]
+		if !ok {
+			// Computed values, like the results of aggregate functions, are not converted
+			converters[columnName] = identityFn
+			continue
+		}
 		converters[columnName] = jsonConverterPerType(column.Type)
 	}
 
@@ -50,6 +58,14 @@ func ToJsonValues(rows []map[string]interface{}, table *gocql.TableMetadata) []m
 	return result
 }
 
+// ToJsonValue converts a single value of the provided type, like ToJsonValues does for the values of a column
+func ToJsonValue(value interface{}, typeInfo gocql.TypeInfo) interface{} {
+	if value == nil {
+		return nil
+	}
+	return jsonConverterPerType(typeInfo)(value)
+}
+
 func FromJsonValue(value interface{}, typeInfo gocql.TypeInfo) (interface{}, error) {
 	switch typeInfo.Type() {
 	case gocql.TypeTimestamp:
@@ -66,10 +82,83 @@ func FromJsonValue(value interface{}, typeInfo gocql.TypeInfo) (interface{}, err
 		return Float64ToFloat32(value)
 	case gocql.TypeTime:
 		return CqlFormattedStringToDuration(value)
+	case gocql.TypeDate:
+		return StringToDate(value)
+	case gocql.TypeDuration:
+		return StringToCqlDuration(value)
+	case gocql.TypeUDT:
+		return objectToUDTParameter(value, typeInfo.(gocql.UDTTypeInfo))
+	case gocql.TypeTuple:
+		return arrayToTupleParameter(value, typeInfo.(gocql.TupleTypeInfo))
+	case gocql.TypeCustom:
+		if vectorType, ok := AsVectorType(typeInfo); ok {
+			return ArrayToVectorParameter(value, vectorType)
+		}
 	}
 	return value, nil
 }
 
+// objectToUDTParameter converts a json object into the value of a user-defined type, converting each field using
+// its type
+func objectToUDTParameter(value interface{}, udt gocql.UDTTypeInfo) (interface{}, error) {
+	if value == nil {
+		// A nil pointer is written as null by the driver
+		return (*UDTParameter)(nil), nil
+	}
+
+	fields, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("wrong value provided for type %s", udt.Name)
+	}
+
+	result := make(UDTParameter, len(fields))
+	for name, fieldValue := range fields {
+		var fieldType gocql.TypeInfo
+		for _, field := range udt.Elements {
+			if field.Name == name {
+				fieldType = field.Type
+				break
+			}
+		}
+		if fieldType == nil {
+			return nil, fmt.Errorf("field '%s' not found in type %s", name, udt.Name)
+		}
+
+		if fieldValue != nil {
+			var err error
+			if fieldValue, err = FromJsonValue(fieldValue, fieldType); err != nil {
+				return nil, err
+			}
+		}
+		result[name] = fieldValue
+	}
+	return result, nil
+}
+
+// arrayToTupleParameter converts a json array into the value of a tuple, converting each element using its type
+func arrayToTupleParameter(value interface{}, tuple gocql.TupleTypeInfo) (interface{}, error) {
+	if value == nil {
+		return (*TupleParameter)(nil), nil
+	}
+
+	elements, ok := value.([]interface{})
+	if !ok || len(elements) != len(tuple.Elems) {
+		return nil, fmt.Errorf("expected an array of %d tuple elements", len(tuple.Elems))
+	}
+
+	result := make(TupleParameter, 0, len(elements))
+	for i, element := range elements {
+		if element != nil {
+			var err error
+			if element, err = FromJsonValue(element, tuple.Elems[i]); err != nil {
+				return nil, err
+			}
+		}
+		result = append(result, element)
+	}
+	return result, nil
+}
+
 func jsonConverterPerType(typeInfo gocql.TypeInfo) toJsonFn {
 	switch typeInfo.Type() {
 	case gocql.TypeVarint, gocql.TypeDecimal:
@@ -80,11 +169,77 @@ func jsonConverterPerType(typeInfo gocql.TypeInfo) toJsonFn {
 		return TimeAsString
 	case gocql.TypeTime:
 		return DurationToCqlFormattedString
+	case gocql.TypeDuration:
+		return CqlDurationToString
+	case gocql.TypeUDT:
+		return udtToJson(typeInfo.(gocql.UDTTypeInfo))
+	case gocql.TypeTuple:
+		return tupleToJson(typeInfo.(gocql.TupleTypeInfo))
 	}
 
 	return identityFn
 }
 
+// udtToJson returns a converter of user-defined type values into json objects, converting each field using its type
+func udtToJson(udt gocql.UDTTypeInfo) toJsonFn {
+	converters := make(map[string]toJsonFn, len(udt.Elements))
+	for _, field := range udt.Elements {
+		converters[field.Name] = jsonConverterPerType(field.Type)
+	}
+
+	return func(value interface{}) interface{} {
+		rv := reflect.Indirect(reflect.ValueOf(value))
+		if rv.Kind() != reflect.Map {
+			return nil
+		}
+
+		result := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			name := iter.Key().String()
+			field := iter.Value().Interface()
+			if converter, ok := converters[name]; ok && !isNil(field) {
+				field = converter(field)
+			}
+			result[name] = field
+		}
+		return result
+	}
+}
+
+// tupleToJson returns a converter of tuple values into json arrays, converting each element using its type
+func tupleToJson(tuple gocql.TupleTypeInfo) toJsonFn {
+	converters := make([]toJsonFn, 0, len(tuple.Elems))
+	for _, elem := range tuple.Elems {
+		converters = append(converters, jsonConverterPerType(elem))
+	}
+
+	return func(value interface{}) interface{} {
+		rv := reflect.Indirect(reflect.ValueOf(value))
+		if rv.Kind() != reflect.Slice {
+			return nil
+		}
+
+		result := make([]interface{}, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			element := rv.Index(i).Interface()
+			if i < len(converters) && !isNil(element) {
+				element = converters[i](element)
+			}
+			result = append(result, element)
+		}
+		return result
+	}
+}
+
+func isNil(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	rv := reflect.ValueOf(value)
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
+}
+
 func identityFn(value interface{}) interface{} {
 	return value
 }
@@ -200,6 +355,20 @@ func CqlFormattedStringToDuration(value interface{}) (interface{}, error) {
 	}
 }
 
+// StringToDate validates that the value is a date represented as yyyy-mm-dd, the driver converts the string into a
+// CQL date
+func StringToDate(value interface{}) (interface{}, error) {
+	switch value := value.(type) {
+	case string:
+		if _, err := time.Parse(dateLayout, value); err != nil {
+			return nil, errors.New("date has wrong format")
+		}
+		return value, nil
+	default:
+		return value, nil
+	}
+}
+
 func Base64StringToByteArray(value interface{}) (interface{}, error) {
 	switch value := value.(type) {
 	case string:
@@ -0,0 +1,153 @@
+package types
+
+import (
+	"fmt"
+	"github.com/gocql/gocql"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// durationUnitRegex matches a quantity and unit of a duration in the CQL standard format, like 1y2mo3d4h5m6s7ms
+var durationUnitRegex = regexp.MustCompile(`(?i)^(\d+)(y|mo|w|d|h|ms|us|µs|ns|m|s)`)
+
+type durationUnit struct {
+	months int64
+	days   int64
+	nanos  int64
+}
+
+var durationUnits = map[string]durationUnit{
+	"y":  {months: 12},
+	"mo": {months: 1},
+	"w":  {days: 7},
+	"d":  {days: 1},
+	"h":  {nanos: int64(time.Hour)},
+	"m":  {nanos: int64(time.Minute)},
+	"s":  {nanos: int64(time.Second)},
+	"ms": {nanos: int64(time.Millisecond)},
+	"us": {nanos: int64(time.Microsecond)},
+	"µs": {nanos: int64(time.Microsecond)},
+	"ns": {nanos: 1},
+}
+
+// nanosUnits contains the units used to format the nanoseconds of a duration, from the largest to the smallest
+var nanosUnits = []struct {
+	name  string
+	nanos int64
+}{
+	{"h", int64(time.Hour)},
+	{"m", int64(time.Minute)},
+	{"s", int64(time.Second)},
+	{"ms", int64(time.Millisecond)},
+	{"us", int64(time.Microsecond)},
+	{"ns", 1},
+}
+
+// CqlDurationToString formats a duration using the CQL standard format, for example: 1y2mo3d4h5m6s
+func CqlDurationToString(value interface{}) interface{} {
+	switch value := value.(type) {
+	case *gocql.Duration:
+		if value == nil {
+			return value
+		}
+		return formatDuration(*value)
+	case gocql.Duration:
+		return formatDuration(value)
+	default:
+		return value
+	}
+}
+
+// StringToCqlDuration parses a duration in the CQL standard format, a sequence of quantities and units optionally
+// preceded by a minus sign, for example: -1y2mo3d4h5m6s7ms8us9ns
+func StringToCqlDuration(value interface{}) (interface{}, error) {
+	switch value := value.(type) {
+	case string:
+		return parseDuration(value)
+	default:
+		return value, nil
+	}
+}
+
+func formatDuration(d gocql.Duration) string {
+	months, days, nanos := int64(d.Months), int64(d.Days), d.Nanoseconds
+	var b strings.Builder
+	if months < 0 || days < 0 || nanos < 0 {
+		b.WriteString("-")
+		months, days, nanos = -months, -days, -nanos
+	}
+
+	appendDurationUnit(&b, months/12, "y")
+	appendDurationUnit(&b, months%12, "mo")
+	appendDurationUnit(&b, days, "d")
+	for _, unit := range nanosUnits {
+		appendDurationUnit(&b, nanos/unit.nanos, unit.name)
+		nanos %= unit.nanos
+	}
+
+	if b.Len() == 0 {
+		return "0s"
+	}
+	return b.String()
+}
+
+func appendDurationUnit(b *strings.Builder, quantity int64, unit string) {
+	if quantity != 0 {
+		b.WriteString(strconv.FormatInt(quantity, 10))
+		b.WriteString(unit)
+	}
+}
+
+func parseDuration(value string) (gocql.Duration, error) {
+	wrongFormat := fmt.Errorf("duration has wrong format: '%s'", value)
+	outOfRange := fmt.Errorf("duration out of range: '%s'", value)
+	remaining := value
+	sign := int64(1)
+	if strings.HasPrefix(remaining, "-") {
+		sign = -1
+		remaining = remaining[1:]
+	}
+
+	if remaining == "" {
+		return gocql.Duration{}, wrongFormat
+	}
+
+	var months, days, nanos int64
+	for remaining != "" {
+		match := durationUnitRegex.FindStringSubmatch(remaining)
+		if match == nil {
+			return gocql.Duration{}, wrongFormat
+		}
+
+		quantity, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return gocql.Duration{}, wrongFormat
+		}
+
+		unit, ok := durationUnits[strings.ToLower(match[2])]
+		if !ok {
+			return gocql.Duration{}, wrongFormat
+		}
+
+		if (unit.nanos > 0 && quantity > (math.MaxInt64-nanos)/unit.nanos) ||
+			(unit.nanos == 0 && quantity > math.MaxInt32) {
+			return gocql.Duration{}, outOfRange
+		}
+		months += quantity * unit.months
+		days += quantity * unit.days
+		nanos += quantity * unit.nanos
+		if months > math.MaxInt32 || days > math.MaxInt32 {
+			return gocql.Duration{}, outOfRange
+		}
+		remaining = remaining[len(match[0]):]
+	}
+
+	return gocql.Duration{
+		Months:      int32(sign * months),
+		Days:        int32(sign * days),
+		Nanoseconds: sign * nanos,
+	}, nil
+}
@@ -0,0 +1,83 @@
+package types
+
+import (
+	"fmt"
+	"github.com/gocql/gocql"
+)
+
+// UDTParameter contains the values of the fields of a user-defined type by field name, to be used as a query
+// parameter. The fields that are not included are written as null.
+type UDTParameter map[string]interface{}
+
+// TupleParameter contains the values of the elements of a tuple, to be used as a query parameter
+type TupleParameter []interface{}
+
+func (p UDTParameter) MarshalCQL(info gocql.TypeInfo) ([]byte, error) {
+	udt, ok := info.(gocql.UDTTypeInfo)
+	if !ok {
+		return nil, fmt.Errorf("can not marshal a user-defined type value into %s", info.Type().String())
+	}
+
+	for name := range p {
+		if !hasField(udt, name) {
+			return nil, fmt.Errorf("field '%s' not found in type %s", name, udt.Name)
+		}
+	}
+
+	var buf []byte
+	for _, field := range udt.Elements {
+		var err error
+		if buf, err = appendElement(buf, field.Type, p[field.Name]); err != nil {
+			return nil, err
+		}
+	}
+	return buf, nil
+}
+
+func (p TupleParameter) MarshalCQL(info gocql.TypeInfo) ([]byte, error) {
+	tuple, ok := info.(gocql.TupleTypeInfo)
+	if !ok {
+		return nil, fmt.Errorf("can not marshal a tuple value into %s", info.Type().String())
+	}
+
+	if len(p) != len(tuple.Elems) {
+		return nil, fmt.Errorf("expected %d tuple elements, got %d", len(tuple.Elems), len(p))
+	}
+
+	var buf []byte
+	for i, elem := range tuple.Elems {
+		var err error
+		if buf, err = appendElement(buf, elem, p[i]); err != nil {
+			return nil, err
+		}
+	}
+	return buf, nil
+}
+
+// appendElement appends an element of a user-defined type or tuple value prefixed by its length, a length of -1
+// represents null
+func appendElement(buf []byte, info gocql.TypeInfo, value interface{}) ([]byte, error) {
+	var data []byte
+	if value != nil {
+		var err error
+		if data, err = gocql.Marshal(info, value); err != nil {
+			return nil, err
+		}
+	}
+
+	size := int32(len(data))
+	if data == nil {
+		size = -1
+	}
+	buf = append(buf, byte(size>>24), byte(size>>16), byte(size>>8), byte(size))
+	return append(buf, data...), nil
+}
+
+func hasField(udt gocql.UDTTypeInfo, name string) bool {
+	for _, field := range udt.Elements {
+		if field.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -9,23 +9,22 @@ type ModificationResult struct {
 	Value   map[string]interface{} `json:"value"`
 }
 
-type QueryResult struct {
-	PageState string                   `json:"pageState"`
-	Values    []map[string]interface{} `json:"values"`
-}
-
 type QueryOptions struct {
 	PageState         string `json:"pageState"`
 	PageSize          int    `json:"pageSize"`
 	Limit             int    `json:"limit"`
 	Consistency       int    `json:"consistency"`
 	SerialConsistency int    `json:"serialConsistency"`
+	// AllowFiltering determines whether the query uses ALLOW FILTERING, it must be enabled for the table
+	AllowFiltering bool `json:"allowFiltering"`
 }
 
 type MutationOptions struct {
 	TTL               int `json:"ttl"`
 	Consistency       int `json:"consistency"`
 	SerialConsistency int `json:"serialConsistency"`
+	// Timestamp is the write time in microseconds as a string, empty when not provided
+	Timestamp string `json:"timestamp"`
 }
 
 type ConditionItem struct {
@@ -34,15 +33,29 @@ type ConditionItem struct {
 	Value    interface{} `json:"value"`
 }
 
+// ContainsEntryOperator restricts the rows to the ones that contain the map entry provided as a MapEntry value, which
+// is expressed in CQL as "column"[key] = value
+const ContainsEntryOperator = "CONTAINS ENTRY"
+
 // CqlOperators contains the CQL operator for a given "graphql" operator
 var CqlOperators = map[string]string{
-	"eq":    "=",
-	"notEq": "!=",
-	"gt":    ">",
-	"gte":   ">=",
-	"lt":    "<",
-	"lte":   "<=",
-	"in":    "IN",
+	"eq":            "=",
+	"notEq":         "!=",
+	"gt":            ">",
+	"gte":           ">=",
+	"lt":            "<",
+	"lte":           "<=",
+	"in":            "IN",
+	"contains":      "CONTAINS",
+	"containsKey":   "CONTAINS KEY",
+	"containsEntry": ContainsEntryOperator,
+	"like":          "LIKE",
+}
+
+// MapEntry is the key and value of a map entry, used as the value of the conditions with the ContainsEntryOperator
+type MapEntry struct {
+	Key   interface{} `json:"key"`
+	Value interface{} `json:"value"`
 }
 
 // Route represents a request route to be served
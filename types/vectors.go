@@ -0,0 +1,126 @@
+package types
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/gocql/gocql"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// vectorClassPrefix is the prefix of the class name of vector types, as sent by the server in the metadata of the
+// results and parameters, for example: org.apache.cassandra.db.marshal.VectorType(...FloatType, 3)
+const vectorClassPrefix = "org.apache.cassandra.db.marshal.VectorType("
+
+const floatClassName = "org.apache.cassandra.db.marshal.FloatType"
+
+var vectorTypeRegex = regexp.MustCompile(`^vector<\s*float\s*,\s*(\d+)\s*>$`)
+
+// VectorType is the type info of a vector of floats with a fixed amount of dimensions, which the driver exposes as a
+// custom type
+type VectorType struct {
+	gocql.NativeType
+	Dimensions int
+}
+
+// NewVectorType returns the type info of a vector of floats with the provided amount of dimensions
+func NewVectorType(dimensions int) VectorType {
+	return VectorType{
+		NativeType: gocql.NewNativeType(0, gocql.TypeCustom, fmt.Sprintf("vector<float, %d>", dimensions)),
+		Dimensions: dimensions,
+	}
+}
+
+func (t VectorType) String() string {
+	return t.Custom()
+}
+
+// ParseVectorType parses the CQL representation of a vector of floats, for example: vector<float, 3>
+func ParseVectorType(name string) (VectorType, bool) {
+	match := vectorTypeRegex.FindStringSubmatch(strings.TrimSpace(name))
+	if match == nil {
+		return VectorType{}, false
+	}
+
+	dimensions, err := strconv.Atoi(match[1])
+	if err != nil || dimensions <= 0 {
+		return VectorType{}, false
+	}
+	return NewVectorType(dimensions), true
+}
+
+// AsVectorType returns the vector type of a column, parameter or result type info, the driver represents vectors as
+// custom types containing the class name
+func AsVectorType(info gocql.TypeInfo) (VectorType, bool) {
+	if t, ok := info.(VectorType); ok {
+		return t, true
+	}
+
+	if info == nil || info.Type() != gocql.TypeCustom {
+		return VectorType{}, false
+	}
+
+	custom := info.Custom()
+	if !strings.HasPrefix(custom, vectorClassPrefix) || !strings.HasSuffix(custom, ")") {
+		return ParseVectorType(custom)
+	}
+
+	parts := strings.Split(custom[len(vectorClassPrefix):len(custom)-1], ",")
+	if len(parts) != 2 || strings.TrimSpace(parts[0]) != floatClassName {
+		return VectorType{}, false
+	}
+
+	dimensions, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err != nil || dimensions <= 0 {
+		return VectorType{}, false
+	}
+	return NewVectorType(dimensions), true
+}
+
+// VectorParameter contains the values of a vector, to be used as a query parameter
+type VectorParameter []float32
+
+func (p VectorParameter) MarshalCQL(info gocql.TypeInfo) ([]byte, error) {
+	vectorType, ok := AsVectorType(info)
+	if !ok {
+		return nil, fmt.Errorf("can not marshal a vector value into %s", info.Type().String())
+	}
+
+	if len(p) != vectorType.Dimensions {
+		return nil, fmt.Errorf("expected a vector of %d dimensions, got %d", vectorType.Dimensions, len(p))
+	}
+
+	// The elements of fixed size are not prefixed by their length
+	buf := make([]byte, 4*len(p))
+	for i, value := range p {
+		binary.BigEndian.PutUint32(buf[i*4:], math.Float32bits(value))
+	}
+	return buf, nil
+}
+
+// ArrayToVectorParameter converts an array of numbers, like a json array, into the value of a vector
+func ArrayToVectorParameter(value interface{}, vectorType VectorType) (interface{}, error) {
+	if value == nil {
+		return (*VectorParameter)(nil), nil
+	}
+
+	elements, ok := value.([]interface{})
+	if !ok || len(elements) != vectorType.Dimensions {
+		return nil, fmt.Errorf("expected a vector of %d dimensions", vectorType.Dimensions)
+	}
+
+	result := make(VectorParameter, 0, len(elements))
+	for _, element := range elements {
+		switch element := element.(type) {
+		case float64:
+			result = append(result, float32(element))
+		case float32:
+			result = append(result, element)
+		default:
+			return nil, fmt.Errorf("wrong value provided for the elements of a vector")
+		}
+	}
+	return result, nil
+}